@@ -0,0 +1,160 @@
+// Command importusers imports users from a CSV or JSONL file through the ImportUsers RPC.
+//
+//	importusers -addr localhost:3000 -token $ADMIN_TOKEN [-dry-run] users.csv
+//
+// The outcome of every failed row is printed, the exit code is 1 if any row failed.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	userapi "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// chunkSize is the size of the input chunks sent per message
+const chunkSize = 64 * 1024
+
+func main() {
+	addr := flag.String("addr", "localhost:3000", "address of the user service")
+	token := flag.String("token", os.Getenv("USER_SERVICE_TOKEN"), "admin access token, defaults to $USER_SERVICE_TOKEN")
+	format := flag.String("format", "", "input format, csv or jsonl. detected from the file extension if empty")
+	dryRun := flag.Bool("dry-run", false, "validate the rows without creating users")
+	useTLS := flag.Bool("tls", false, "connect with TLS")
+	reportFile := flag.String("report", "", "write the result of every row as JSON to this file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	resp, err := run(*addr, *token, flag.Arg(0), *format, *dryRun, *useTLS)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import failed:", err)
+		os.Exit(1)
+	}
+
+	if *reportFile != "" {
+		if err := writeReport(*reportFile, resp); err != nil {
+			fmt.Fprintln(os.Stderr, "writing report failed:", err)
+			os.Exit(1)
+		}
+	}
+	for _, result := range resp.GetResults() {
+		if result.GetErrorCode() != "" {
+			fmt.Printf("line %d (%s): %s: %s\n", result.GetLine(), result.GetEmail(), result.GetErrorCode(), result.GetErrorMessage())
+		}
+	}
+	mode := "imported"
+	if resp.GetDryRun() {
+		mode = "valid (dry run)"
+	}
+	fmt.Printf("%d rows, %d %s, %d failed\n", resp.GetTotal(), resp.GetSucceeded(), mode, resp.GetFailed())
+	if resp.GetFailed() > 0 {
+		os.Exit(1)
+	}
+}
+
+// run streams the file to ImportUsers
+func run(addr, token, path, format string, dryRun, useTLS bool) (*userapi.ImportUsersResponse, error) {
+	importFormat, err := parseFormat(format, path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	stream, err := userapi.NewUserAPIClient(conn).ImportUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &userapi.ImportOptions{Format: importFormat, DryRun: dryRun}
+	if err := stream.Send(&userapi.ImportUsersRequest{Payload: &userapi.ImportUsersRequest_Options{Options: options}}); err != nil {
+		return nil, closeWithError(stream, err)
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			chunk := &userapi.ImportUsersRequest_Chunk{Chunk: buf[:n]}
+			if err := stream.Send(&userapi.ImportUsersRequest{Payload: chunk}); err != nil {
+				return nil, closeWithError(stream, err)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stream.CloseAndRecv()
+}
+
+// closeWithError returns the status of the call if the server ended it early, Send only reports io.EOF then
+func closeWithError(stream userapi.UserAPI_ImportUsersClient, err error) error {
+	if err == io.EOF {
+		_, err = stream.CloseAndRecv()
+	}
+	return err
+}
+
+// parseFormat returns the given format, or the one matching the file extension
+func parseFormat(format, path string) (userapi.ImportFormat, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	switch strings.ToLower(format) {
+	case "csv":
+		return userapi.ImportFormat_IMPORT_FORMAT_CSV, nil
+	case "jsonl", "ndjson":
+		return userapi.ImportFormat_IMPORT_FORMAT_JSONL, nil
+	default:
+		return userapi.ImportFormat_IMPORT_FORMAT_UNSPECIFIED, fmt.Errorf("unknown format %q, use -format csv or -format jsonl", format)
+	}
+}
+
+// writeReport writes the results of all rows, including the invite tokens
+func writeReport(path string, resp *userapi.ImportUsersResponse) error {
+	data, err := json.MarshalIndent(resp.GetResults(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -1,15 +1,29 @@
 package main
 
 import (
+	"context"
+
 	"github.com/nsaltun/user-service-grpc/internal/api"
+	"github.com/nsaltun/user-service-grpc/internal/attributes"
+	"github.com/nsaltun/user-service-grpc/internal/erasure"
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/nickname"
 	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/internal/search"
 	"github.com/nsaltun/user-service-grpc/internal/service"
+	"github.com/nsaltun/user-service-grpc/internal/service/user"
+	"github.com/nsaltun/user-service-grpc/internal/watch"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/blobstore"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/db/mongohandler"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/eventbus"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/grpc"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/logging"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/mail"
 	grpcmiddl "github.com/nsaltun/user-service-grpc/pkg/v1/middleware/grpc"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/sms"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
 	userapi "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
 )
 
@@ -26,27 +40,78 @@ func main() {
 	// Init repository
 	userRepo := repository.NewUserRepo(mongoWrapper)
 	s.MustInit(userRepo)
-	repo := repository.New(userRepo)
+	securityEventRepo := repository.NewSecurityEventRepo(mongoWrapper)
+	s.MustInit(securityEventRepo)
+	erasureRepo := repository.NewErasureRepo(mongoWrapper)
+	s.MustInit(erasureRepo)
+	repo := repository.New(userRepo, securityEventRepo, erasureRepo, repository.NewTransactor(mongoWrapper))
+
+	// Init search backend
+	searchBackend := search.NewMongoBackend(mongoWrapper)
+	s.MustInit(searchBackend)
+
+	// Page and resume tokens are shared by listing, exporting and watching users
+	pageTokens := types.NewPageTokenCodecFromEnv()
+	s.MustInit(pageTokens)
+
+	// Init user change watcher
+	watcher := watch.NewWatcherFromEnv(mongoWrapper, userRepo, pageTokens)
+
+	// Init custom attribute schemas
+	attributeRegistry := attributes.NewRegistryFromEnv()
+	s.MustInit(attributeRegistry)
+
+	// Init nickname policy
+	nicknamePolicy := nickname.NewPolicyFromEnv()
+	s.MustInit(nicknamePolicy)
 
 	// Init JWT manager
 	jwtManager := auth.NewJWTManager(mongoWrapper)
 	s.MustInit(jwtManager)
 
 	// Init services
-	service := service.NewService(repo, jwtManager)
+	publisher := eventbus.NewFromEnv()
+	service := service.NewService(service.Deps{
+		Repo:       repo,
+		JWTManager: jwtManager,
+		Publisher:  publisher,
+		PageTokens: pageTokens,
+		Search:     searchBackend,
+		Watcher:    watcher,
+		Blobs:      blobstore.NewFromEnv(),
+		Attributes: attributeRegistry,
+		Nicknames:  nicknamePolicy,
+		SMS:        sms.NewFromEnv(),
+		Mail:       mail.NewFromEnv(),
+	}, user.NewConfigFromEnv())
+
+	// Init erasure purger
+	s.MustInit(erasure.NewPurgerFromEnv(repo, publisher, erasure.Cleaner{
+		Name: "tokens",
+		Clean: func(ctx context.Context, user *model.User) error {
+			return jwtManager.EraseUserTokens(ctx, user.Id)
+		},
+	}))
+
+	// Browser session cookies
+	sessionCookies := grpcmiddl.NewSessionCookies(grpcmiddl.NewSessionCookieConfigFromEnv(), jwtManager.RefreshTokenDuration())
 
 	// Register APIs
 	userAPI := api.NewUserAPI(service)
-	authAPI := api.NewAuthAPI(service)
+	authAPI := api.NewAuthAPI(service, sessionCookies)
+	securityAPI := api.NewSecurityAPI(service)
 
 	// grpc server
 	grpcServer := grpc.New(
 		grpcmiddl.WithErrorInterceptor(), //error interceptor must be the last one
 		grpcmiddl.WithLoggingInterceptor(),
-		grpcmiddl.WithAuthInterceptor(jwtManager),
+		grpcmiddl.WithClientIPInterceptor(grpcmiddl.NewClientIPResolverFromEnv()),
+		grpcmiddl.WithAuthInterceptor(jwtManager, auth.NewPrincipalMapperFromEnv()),
+		grpcmiddl.WithCSRFInterceptor(sessionCookies),
 	)
 	userapi.RegisterUserAPIServer(grpcServer.Server(), userAPI)
 	userapi.RegisterAuthAPIServer(grpcServer.Server(), authAPI)
+	userapi.RegisterSecurityAPIServer(grpcServer.Server(), securityAPI)
 
 	//grpcServer must init in the end
 	s.MustInit(grpcServer)
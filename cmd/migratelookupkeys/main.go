@@ -0,0 +1,78 @@
+// Command migratelookupkeys deduplicates users by their normalized email and nickname and stores the lookup keys of all users.
+//
+//	migratelookupkeys [-dry-run] [-report report.json]
+//
+// It connects to MongoDB with the configuration of the service, and must be run with the same USER_EMAIL_FOLD_GMAIL.
+// Users that are deactivated or lose their nickname are printed.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nsaltun/user-service-grpc/internal/migration"
+	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/db/mongohandler"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/logging"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the duplicates without changing users")
+	reportFile := flag.String("report", "", "write the report as JSON to this file")
+	flag.Parse()
+
+	logging.InitSlog()
+
+	report, err := run(*dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migration failed:", err)
+		os.Exit(1)
+	}
+
+	if *reportFile != "" {
+		if err := writeReport(*reportFile, report); err != nil {
+			fmt.Fprintln(os.Stderr, "writing report failed:", err)
+			os.Exit(1)
+		}
+	}
+	for _, duplicate := range report.DeactivatedUsers {
+		fmt.Printf("user %s deactivated, email %s is kept by user %s\n", duplicate.UserID, duplicate.Key, duplicate.KeptBy)
+	}
+	for _, duplicate := range report.ClearedNickNames {
+		fmt.Printf("user %s loses its nickname, nickname %s is kept by user %s\n", duplicate.UserID, duplicate.Key, duplicate.KeptBy)
+	}
+	mode := "updated"
+	if report.DryRun {
+		mode = "to update (dry run)"
+	}
+	fmt.Printf("%d users, %d duplicate emails, %d duplicate nicknames, %d lookup keys %s\n",
+		report.Users, len(report.DeactivatedUsers), len(report.ClearedNickNames), report.KeysUpdated, mode)
+}
+
+// run migrates the users collection, the indexes of the repository are created first
+func run(dryRun bool) (*migration.LookupKeysReport, error) {
+	mongoWrapper := mongohandler.New()
+	if err := mongoWrapper.Init(); err != nil {
+		return nil, err
+	}
+	defer mongoWrapper.Disconnect()
+
+	userRepo := repository.NewUserRepo(mongoWrapper)
+	if err := userRepo.Init(); err != nil {
+		return nil, err
+	}
+
+	return migration.MigrateLookupKeys(context.Background(), userRepo, dryRun)
+}
+
+// writeReport writes the report with all duplicates
+func writeReport(path string, report *migration.LookupKeysReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -12,25 +12,34 @@ import (
 
 type authAPI struct {
 	pb.UnimplementedAuthAPIServer
-	service auth.AuthService
+	service        auth.AuthService
+	sessionCookies *middleware.SessionCookies
 }
 
-func NewAuthAPI(service auth.AuthService) pb.AuthAPIServer {
-	return &authAPI{service: service}
+func NewAuthAPI(service auth.AuthService, sessionCookies *middleware.SessionCookies) pb.AuthAPIServer {
+	return &authAPI{service: service, sessionCookies: sessionCookies}
 }
 
 func (a *authAPI) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
 	// Input validation
-	if req.GetEmail() == "" || req.GetPassword() == "" {
-		return nil, errwrap.NewError("email and password are required", codes.InvalidArgument.String()).
+	if (req.GetEmail() == "") == (req.GetPhone() == "") || req.GetPassword() == "" {
+		return nil, errwrap.NewError("email or phone, and password are required", codes.InvalidArgument.String()).
 			SetGrpcCode(codes.InvalidArgument)
 	}
 
-	accessToken, refreshToken, err := a.service.Login(ctx, req.GetEmail(), req.GetPassword())
+	accessToken, refreshToken, err := a.service.Login(ctx, req.GetEmail(), req.GetPhone(), req.GetPassword())
 	if err != nil {
 		return nil, err
 	}
 
+	// Browser clients keep the refresh token in an HttpOnly cookie instead of the body
+	if a.sessionCookies.Enabled(ctx) {
+		if err := a.sessionCookies.SetSession(ctx, refreshToken); err != nil {
+			return nil, errwrap.ErrInternal.SetMessage("failed to set session cookie").SetOriginError(err)
+		}
+		return &pb.LoginResponse{AccessToken: accessToken}, nil
+	}
+
 	return &pb.LoginResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -38,17 +47,32 @@ func (a *authAPI) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginRes
 }
 
 func (a *authAPI) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.RefreshResponse, error) {
+	cookieMode := a.sessionCookies.Enabled(ctx)
+
+	// Fall back to the session cookie when the body does not carry the refresh token
+	token := req.GetRefreshToken()
+	if token == "" && cookieMode {
+		token = a.sessionCookies.RefreshToken(ctx)
+	}
+
 	// Input validation
-	if req.GetRefreshToken() == "" {
+	if token == "" {
 		return nil, errwrap.NewError("refresh token is required", codes.InvalidArgument.String()).
 			SetGrpcCode(codes.InvalidArgument)
 	}
 
-	accessToken, refreshToken, err := a.service.Refresh(ctx, req.GetRefreshToken())
+	accessToken, refreshToken, err := a.service.Refresh(ctx, token)
 	if err != nil {
 		return nil, err
 	}
 
+	if cookieMode {
+		if err := a.sessionCookies.SetSession(ctx, refreshToken); err != nil {
+			return nil, errwrap.ErrInternal.SetMessage("failed to set session cookie").SetOriginError(err)
+		}
+		return &pb.RefreshResponse{AccessToken: accessToken}, nil
+	}
+
 	return &pb.RefreshResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -66,5 +90,11 @@ func (a *authAPI) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.Logout
 		return nil, err
 	}
 
+	if a.sessionCookies.Enabled(ctx) {
+		if err := a.sessionCookies.ClearSession(ctx); err != nil {
+			return nil, errwrap.ErrInternal.SetMessage("failed to clear session cookie").SetOriginError(err)
+		}
+	}
+
 	return &pb.LogoutResponse{}, nil
 }
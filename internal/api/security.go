@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/service/security"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	pb "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
+	"google.golang.org/grpc/codes"
+)
+
+type securityAPI struct {
+	pb.UnimplementedSecurityAPIServer
+	service security.SecurityEventService
+}
+
+func NewSecurityAPI(service security.SecurityEventService) pb.SecurityAPIServer {
+	return &securityAPI{service: service}
+}
+
+func (a *securityAPI) ListSecurityEvents(ctx context.Context, req *pb.ListSecurityEventsRequest) (*pb.ListSecurityEventsResponse, error) {
+	filter := &model.SecurityEventFilter{}
+	filter.SecurityEventFilterFromProto(req, req.GetParams())
+
+	if err := types.ValidatePaginationParams(filter.Pagination.Limit, filter.Pagination.Offset); err != nil {
+		return nil, err
+	}
+
+	if !filter.StartTime.IsZero() && !filter.EndTime.IsZero() && !filter.StartTime.Before(filter.EndTime) {
+		return nil, errwrap.NewError("start_time must be before end_time", codes.InvalidArgument.String()).
+			SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	result, err := a.service.ListSecurityEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -2,13 +2,22 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
 
 	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/search"
 	"github.com/nsaltun/user-service-grpc/internal/service/user"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	middleware "github.com/nsaltun/user-service-grpc/pkg/v1/middleware/grpc"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
 	pb "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type userAPI struct {
@@ -52,11 +61,17 @@ func (a *userAPI) UpdateUserById(ctx context.Context, req *pb.UpdateUserByIdRequ
 	user := &model.User{}
 	user.UserFromProto(req.GetUser())
 
+	expectedVersion, err := resolveExpectedVersion(ctx, req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	// Call service
-	updatedUser, err := a.service.UpdateUserById(ctx, req.GetId(), user)
+	updatedUser, err := a.service.UpdateUserById(ctx, req.GetId(), user, req.GetUpdateMask().GetPaths(), expectedVersion)
 	if err != nil {
 		return nil, err
 	}
+	setETag(ctx, updatedUser)
 
 	// Convert back to proto and return
 	return &pb.UpdateUserByIdResponse{
@@ -70,20 +85,63 @@ func (a *userAPI) DeleteUserById(ctx context.Context, req *pb.DeleteUserByIdRequ
 	}
 
 	// Call service
-	err := a.service.DeleteUser(ctx, req.GetId())
+	err := a.service.DeleteUser(ctx, req.GetId(), req.GetReason())
 	if err != nil {
 		return nil, err
 	}
 
 	return &pb.DeleteUserByIdResponse{}, nil
 }
+
+func (a *userAPI) RestoreUser(ctx context.Context, req *pb.RestoreUserRequest) (*pb.RestoreUserResponse, error) {
+	if req.GetId() == "" {
+		return nil, errwrap.NewError("user id is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	user, err := a.service.RestoreUser(ctx, req.GetId(), req.GetReason())
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, user)
+
+	return &pb.RestoreUserResponse{
+		User: user.UserToProto(),
+	}, nil
+}
+func (a *userAPI) RequestErasure(ctx context.Context, req *pb.RequestErasureRequest) (*pb.RequestErasureResponse, error) {
+	if req.GetId() == "" {
+		return nil, errwrap.NewError("user id is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	user, err := a.service.RequestErasure(ctx, req.GetId(), req.GetReason())
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, user)
+
+	return &pb.RequestErasureResponse{
+		User: user.UserToProto(),
+	}, nil
+}
+
 func (a *userAPI) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
 	filter := &model.UserFilter{}
 	filter.UserFilterFromProto(req.GetFilter(), req.GetParams())
+	if err := filter.ParseExpression(a.service.FilterFields(ctx)); err != nil {
+		return nil, err
+	}
+	if err := filter.SortFromProto(req.GetOrderBy()); err != nil {
+		return nil, err
+	}
 
 	if err := types.ValidatePaginationParams(filter.Pagination.Limit, filter.Pagination.Offset); err != nil {
 		return nil, err
 	}
+	if err := types.ValidatePageToken(filter.Pagination.PageToken, filter.Pagination.Offset); err != nil {
+		return nil, err
+	}
 
 	// Call service
 	result, err := a.service.ListUsersByFilter(ctx, filter)
@@ -93,3 +151,430 @@ func (a *userAPI) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.
 
 	return result, nil
 }
+
+func (a *userAPI) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	lookup := model.UserLookup{}
+	lookup.UserLookupFromProto(req)
+
+	if lookup.Id == "" && lookup.Email == "" && lookup.NickName == "" {
+		return nil, errwrap.NewError("one of id, email or nick_name is required", codes.InvalidArgument.String()).
+			SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	user, err := a.service.GetUser(ctx, lookup)
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, user)
+
+	return &pb.GetUserResponse{
+		User: user.UserToProto(),
+	}, nil
+}
+
+func (a *userAPI) GetMe(ctx context.Context, req *pb.GetMeRequest) (*pb.GetMeResponse, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, errwrap.ErrUnauthenticated.SetMessage("unauthorized")
+	}
+
+	// Call service
+	user, err := a.service.GetMe(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, user)
+
+	return &pb.GetMeResponse{
+		User: user.UserToProto(),
+	}, nil
+}
+
+func (a *userAPI) UpdateMe(ctx context.Context, req *pb.UpdateMeRequest) (*pb.UpdateMeResponse, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, errwrap.ErrUnauthenticated.SetMessage("unauthorized")
+	}
+
+	if req.GetUser() == nil {
+		return nil, errwrap.NewError("user update data is required", codes.InvalidArgument.String()).
+			SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Convert proto to model
+	user := &model.User{}
+	user.UserFromProto(req.GetUser())
+
+	expectedVersion, err := resolveExpectedVersion(ctx, req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Call service
+	updatedUser, err := a.service.UpdateMe(ctx, userID, user, req.GetUpdateMask().GetPaths(), expectedVersion, req.GetCurrentPassword())
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, updatedUser)
+
+	return &pb.UpdateMeResponse{
+		User: updatedUser.UserToProto(),
+	}, nil
+}
+
+func (a *userAPI) DeleteMe(ctx context.Context, req *pb.DeleteMeRequest) (*pb.DeleteMeResponse, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, errwrap.ErrUnauthenticated.SetMessage("unauthorized")
+	}
+
+	// Call service
+	if err := a.service.DeleteMe(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return &pb.DeleteMeResponse{}, nil
+}
+
+func (a *userAPI) SearchUsers(ctx context.Context, req *pb.SearchUsersRequest) (*pb.SearchUsersResponse, error) {
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = search.DefaultLimit
+	}
+	if limit < 1 || limit > search.MaxLimit {
+		return nil, errwrap.NewError(fmt.Sprintf("limit must be between 1 and %d", search.MaxLimit), codes.InvalidArgument.String()).
+			SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	hits, err := a.service.SearchUsers(ctx, req.GetQuery(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.UserSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, hit.ToProto())
+	}
+
+	return &pb.SearchUsersResponse{
+		Results: results,
+	}, nil
+}
+
+func (a *userAPI) ExportUsers(req *pb.ExportUsersRequest, stream pb.UserAPI_ExportUsersServer) error {
+	filter := &model.UserFilter{}
+	filter.UserFilterFromProto(req.GetFilter(), nil)
+	if err := filter.ParseExpression(a.service.FilterFields(stream.Context())); err != nil {
+		return err
+	}
+
+	// Call service, Send blocks while the client is not reading which holds back the database cursor
+	return a.service.ExportUsers(stream.Context(), filter, req.GetResumeToken(), func(user *model.User, resumeToken string) error {
+		return stream.Send(&pb.ExportUsersResponse{
+			User:        user.UserToProto(),
+			ResumeToken: resumeToken,
+		})
+	})
+}
+
+func (a *userAPI) WatchUsers(req *pb.WatchUsersRequest, stream pb.UserAPI_WatchUsersServer) error {
+	// Call service, it returns when the client cancels the stream
+	return a.service.WatchUsers(stream.Context(), req.GetResumeToken(), func(change *model.UserChange) error {
+		return stream.Send(&pb.WatchUsersResponse{Change: change.ToProto()})
+	})
+}
+
+func (a *userAPI) ImportUsers(stream pb.UserAPI_ImportUsersServer) error {
+	req, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if req.GetOptions() == nil {
+		return errwrap.NewError("the first message must carry the import options", codes.InvalidArgument.String()).
+			SetGrpcCode(codes.InvalidArgument)
+	}
+	options := model.ImportOptions{}
+	options.ImportOptionsFromProto(req.GetOptions())
+
+	// Call service, the input is parsed while it is received
+	report, err := a.service.ImportUsers(stream.Context(), &importStreamReader{stream: stream}, options)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(report.ToProto())
+}
+
+// importStreamReader reads the chunks of an ImportUsers stream as one input
+type importStreamReader struct {
+	stream pb.UserAPI_ImportUsersServer
+	chunk  []byte
+}
+
+func (r *importStreamReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		req, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		if req.GetOptions() != nil {
+			return 0, errwrap.NewError("import options can only be sent once", codes.InvalidArgument.String()).
+				SetGrpcCode(codes.InvalidArgument)
+		}
+		r.chunk = req.GetChunk()
+	}
+
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+func (a *userAPI) BatchGetUsers(ctx context.Context, req *pb.BatchGetUsersRequest) (*pb.BatchGetUsersResponse, error) {
+	// Call service
+	results, err := a.service.BatchGetUsers(ctx, req.GetIds())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.BatchGetUsersResponse{Results: batchResultsToProto(results)}, nil
+}
+
+func (a *userAPI) BatchUpdateUserStatus(ctx context.Context, req *pb.BatchUpdateUserStatusRequest) (*pb.BatchUpdateUserStatusResponse, error) {
+	// Call service
+	results, err := a.service.BatchUpdateUserStatus(ctx, req.GetIds(), model.UserStatus(req.GetStatus()), req.GetAllOrNothing())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.BatchUpdateUserStatusResponse{Results: batchResultsToProto(results)}, nil
+}
+
+func (a *userAPI) BatchDeleteUsers(ctx context.Context, req *pb.BatchDeleteUsersRequest) (*pb.BatchDeleteUsersResponse, error) {
+	// Call service
+	results, err := a.service.BatchDeleteUsers(ctx, req.GetIds(), req.GetReason(), req.GetAllOrNothing())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.BatchDeleteUsersResponse{Results: batchResultsToProto(results)}, nil
+}
+
+// ExportMyData streams the data export of the authenticated user
+func (a *userAPI) ExportMyData(req *pb.ExportMyDataRequest, stream pb.UserAPI_ExportMyDataServer) error {
+	userID, ok := middleware.GetUserID(stream.Context())
+	if !ok {
+		return errwrap.ErrUnauthenticated.SetMessage("unauthorized")
+	}
+
+	// Call service
+	export, err := a.service.ExportUserData(stream.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return sendDataExport(stream, export)
+}
+
+func (a *userAPI) ExportUserData(req *pb.ExportUserDataRequest, stream pb.UserAPI_ExportUserDataServer) error {
+	if req.GetId() == "" {
+		return errwrap.NewError("user id is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	export, err := a.service.ExportUserData(stream.Context(), req.GetId())
+	if err != nil {
+		return err
+	}
+	if !req.GetStore() {
+		return sendDataExport(stream, export)
+	}
+
+	key, err := a.service.StoreUserDataExport(stream.Context(), export)
+	if err != nil {
+		return err
+	}
+	return stream.Send(&pb.ExportDataResponse{Payload: &pb.ExportDataResponse_BlobKey{BlobKey: key}})
+}
+
+func (a *userAPI) SendPhoneVerification(ctx context.Context, req *pb.SendPhoneVerificationRequest) (*pb.SendPhoneVerificationResponse, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, errwrap.ErrUnauthenticated.SetMessage("unauthorized")
+	}
+
+	// Call service
+	expireTime, err := a.service.SendPhoneVerification(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.SendPhoneVerificationResponse{
+		ExpireTime: timestamppb.New(expireTime),
+	}, nil
+}
+
+func (a *userAPI) VerifyPhone(ctx context.Context, req *pb.VerifyPhoneRequest) (*pb.VerifyPhoneResponse, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, errwrap.ErrUnauthenticated.SetMessage("unauthorized")
+	}
+	if req.GetCode() == "" {
+		return nil, errwrap.NewError("code is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	user, err := a.service.VerifyPhone(ctx, userID, req.GetCode())
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, user)
+
+	return &pb.VerifyPhoneResponse{
+		User: user.UserToProto(),
+	}, nil
+}
+
+func (a *userAPI) RequestEmailChange(ctx context.Context, req *pb.RequestEmailChangeRequest) (*pb.RequestEmailChangeResponse, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, errwrap.ErrUnauthenticated.SetMessage("unauthorized")
+	}
+	if req.GetNewEmail() == "" {
+		return nil, errwrap.NewError("new_email is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+	if req.GetPassword() == "" {
+		return nil, errwrap.NewError("password is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	expireTime, err := a.service.RequestEmailChange(ctx, userID, req.GetNewEmail(), req.GetPassword(), req.GetRevokeSessions())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RequestEmailChangeResponse{
+		ExpireTime: timestamppb.New(expireTime),
+	}, nil
+}
+
+func (a *userAPI) ConfirmEmailChange(ctx context.Context, req *pb.ConfirmEmailChangeRequest) (*pb.ConfirmEmailChangeResponse, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, errwrap.ErrUnauthenticated.SetMessage("unauthorized")
+	}
+	if req.GetToken() == "" {
+		return nil, errwrap.NewError("token is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	user, err := a.service.ConfirmEmailChange(ctx, userID, req.GetToken())
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, user)
+
+	return &pb.ConfirmEmailChangeResponse{
+		User: user.UserToProto(),
+	}, nil
+}
+
+func (a *userAPI) RevertEmailChange(ctx context.Context, req *pb.RevertEmailChangeRequest) (*pb.RevertEmailChangeResponse, error) {
+	if req.GetToken() == "" {
+		return nil, errwrap.NewError("token is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	if err := a.service.RevertEmailChange(ctx, req.GetToken()); err != nil {
+		return nil, err
+	}
+
+	return &pb.RevertEmailChangeResponse{}, nil
+}
+
+func (a *userAPI) AcceptInvite(ctx context.Context, req *pb.AcceptInviteRequest) (*pb.AcceptInviteResponse, error) {
+	if req.GetToken() == "" {
+		return nil, errwrap.NewError("token is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+	if req.GetPassword() == "" {
+		return nil, errwrap.NewError("password is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	if err := a.service.AcceptInvite(ctx, req.GetToken(), req.GetPassword()); err != nil {
+		return nil, err
+	}
+
+	return &pb.AcceptInviteResponse{}, nil
+}
+
+func (a *userAPI) CheckNicknameAvailability(ctx context.Context, req *pb.CheckNicknameAvailabilityRequest) (*pb.CheckNicknameAvailabilityResponse, error) {
+	if strings.TrimSpace(req.GetNickName()) == "" {
+		return nil, errwrap.NewError("nick_name is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// Call service
+	check, err := a.service.CheckNicknameAvailability(ctx, req.GetNickName())
+	if err != nil {
+		return nil, err
+	}
+
+	return check.ToProto(), nil
+}
+
+// dataExportChunkSize is the size of the JSON chunks sent per message
+const dataExportChunkSize = 64 * 1024
+
+// dataExportStream is the server stream of ExportMyData and ExportUserData
+type dataExportStream interface {
+	Send(*pb.ExportDataResponse) error
+}
+
+// sendDataExport streams the export as JSON in chunks
+func sendDataExport(stream dataExportStream, export *model.UserDataExport) error {
+	data, err := json.Marshal(export)
+	if err != nil {
+		return errwrap.ErrInternal.SetMessage("failed to marshal data export").SetOriginError(err)
+	}
+
+	for len(data) > 0 {
+		n := min(len(data), dataExportChunkSize)
+		if err := stream.Send(&pb.ExportDataResponse{Payload: &pb.ExportDataResponse_Chunk{Chunk: data[:n]}}); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// batchResultsToProto converts the results of a batch, mapping item errors to statuses like the error interceptor
+func batchResultsToProto(results []*model.UserBatchResult) []*pb.BatchUserResult {
+	pbResults := make([]*pb.BatchUserResult, 0, len(results))
+	for _, result := range results {
+		pbResult := &pb.BatchUserResult{Id: result.Id, Status: status.New(codes.OK, "").Proto()}
+		if result.Err != nil {
+			pbResult.Status = middleware.ToStatus(result.Err).Proto()
+		}
+		if result.User != nil {
+			pbResult.User = result.User.UserToProto()
+		}
+		pbResults = append(pbResults, pbResult)
+	}
+	return pbResults
+}
+
+// resolveExpectedVersion returns the version an update is based on.
+// The expected_version field takes precedence over an If-Match etag in the metadata.
+func resolveExpectedVersion(ctx context.Context, field *int32) (*int32, error) {
+	if field != nil {
+		return field, nil
+	}
+	return middleware.IfMatchVersion(ctx)
+}
+
+// setETag sends the user version as etag, so clients can use it for a conditional update
+func setETag(ctx context.Context, user *model.User) {
+	if err := middleware.SetETag(ctx, user.Version); err != nil {
+		slog.WarnContext(ctx, "failed to set etag header", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,229 @@
+package attributes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/filter"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	configKeySchemaDir = "USER_ATTRIBUTE_SCHEMA_DIR"
+
+	// MaxSize limits the JSON encoded custom attributes of a user
+	MaxSize = 16 * 1024
+)
+
+// namePattern restricts namespaces and filterable properties to names that are safe document field and filter paths
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// Registry holds the JSON schema of every attribute namespace.
+// Custom attributes are only accepted in registered namespaces and must match the schema of their namespace.
+type Registry struct {
+	stack.AbstractProvider
+	dir     string
+	schemas map[string]*jsonschema.Schema
+	fields  filter.Schema
+}
+
+// NewRegistryFromEnv creates a registry loading the schemas from USER_ATTRIBUTE_SCHEMA_DIR on Init
+func NewRegistryFromEnv() *Registry {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault(configKeySchemaDir, "")
+
+	return NewRegistry(vi.GetString(configKeySchemaDir))
+}
+
+// NewRegistry creates a registry loading the schemas from dir on Init, an empty dir registers no namespace.
+// Every <namespace>.json file in dir is the schema of that namespace.
+func NewRegistry(dir string) *Registry {
+	return &Registry{
+		dir:     dir,
+		schemas: map[string]*jsonschema.Schema{},
+		fields:  filter.Schema{},
+	}
+}
+
+// Init registers the schemas of the schema directory
+func (r *Registry) Init() error {
+	if r.dir == "" {
+		slog.Info("No user attribute schema directory configured, custom attributes are rejected.")
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read attribute schema: %w", err)
+		}
+		if err := r.Register(strings.TrimSuffix(filepath.Base(file), ".json"), data); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("User attribute schemas registered.", slog.Any("namespaces", r.Namespaces()))
+	return nil
+}
+
+// Register compiles the JSON schema of a namespace. It must be called before the registry is used.
+//
+// Top level properties of type string, boolean, number or integer become filterable as
+// custom_attributes.<namespace>.<property>.
+func (r *Registry) Register(namespace string, schema []byte) error {
+	if !namePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid attribute namespace %q, expected lower case letters, digits and underscores", namespace)
+	}
+	if _, ok := r.schemas[namespace]; ok {
+		return fmt.Errorf("attribute namespace %s is already registered", namespace)
+	}
+
+	url := "attributes/" + namespace + ".json"
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	if err := compiler.AddResource(url, bytes.NewReader(schema)); err != nil {
+		return fmt.Errorf("invalid schema of attribute namespace %s: %w", namespace, err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return fmt.Errorf("invalid schema of attribute namespace %s: %w", namespace, err)
+	}
+
+	var properties struct {
+		Properties map[string]struct {
+			Type any `json:"type"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(schema, &properties); err != nil {
+		return fmt.Errorf("invalid schema of attribute namespace %s: %w", namespace, err)
+	}
+	for name, property := range properties.Properties {
+		var fieldType filter.FieldType
+		switch property.Type {
+		case "string":
+			fieldType = filter.String
+		case "boolean":
+			fieldType = filter.Bool
+		case "number", "integer":
+			fieldType = filter.Number
+		default:
+			continue
+		}
+		if !namePattern.MatchString(name) {
+			continue
+		}
+		path := model.UserField_CustomAttributes + "." + namespace + "." + name
+		r.fields[path] = filter.Field{Path: path, Type: fieldType}
+	}
+
+	r.schemas[namespace] = compiled
+	return nil
+}
+
+// Namespaces returns the registered namespaces in order
+func (r *Registry) Namespaces() []string {
+	return slices.Sorted(maps.Keys(r.schemas))
+}
+
+// FilterFields returns the filterable attribute fields keyed by their path
+func (r *Registry) FilterFields() filter.Schema {
+	return r.fields
+}
+
+// Validate checks the given namespaces of the attributes, or all of them if none are given, and the total size.
+// Errors are returned as InvalidArgument.
+func (r *Registry) Validate(attributes model.UserAttributes, namespaces ...string) error {
+	if len(namespaces) == 0 {
+		namespaces = slices.Collect(maps.Keys(attributes))
+	}
+	for _, namespace := range namespaces {
+		value, ok := attributes[namespace]
+		if !ok {
+			continue
+		}
+		if err := r.validateNamespace(namespace, value); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return invalidAttributes(err.Error())
+	}
+	if len(data) > MaxSize {
+		return invalidAttributes(fmt.Sprintf("custom_attributes must not exceed %d bytes", MaxSize))
+	}
+	return nil
+}
+
+// validateNamespace checks the attributes of a single namespace against its schema
+func (r *Registry) validateNamespace(namespace string, value any) error {
+	schema, ok := r.schemas[namespace]
+	if !ok {
+		return invalidAttributes("unknown attribute namespace " + namespace)
+	}
+	if _, ok := value.(map[string]any); !ok {
+		return invalidAttributes(fmt.Sprintf("custom_attributes.%s must be an object", namespace))
+	}
+	if key, ok := invalidKey(value); ok {
+		return invalidAttributes(fmt.Sprintf("invalid key %q in custom_attributes.%s, keys cannot contain dots or start with $", key, namespace))
+	}
+
+	if err := schema.Validate(value); err != nil {
+		validationErr, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return invalidAttributes(err.Error())
+		}
+		// The first leaf names the offending value
+		for len(validationErr.Causes) > 0 {
+			validationErr = validationErr.Causes[0]
+		}
+		location := strings.ReplaceAll(validationErr.InstanceLocation, "/", ".")
+		return invalidAttributes(fmt.Sprintf("custom_attributes.%s%s: %s", namespace, location, validationErr.Message))
+	}
+	return nil
+}
+
+// invalidKey finds a key that cannot be stored as a document field or addressed by a filter path
+func invalidKey(value any) (string, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			if key == "" || strings.HasPrefix(key, "$") || strings.Contains(key, ".") {
+				return key, true
+			}
+			if key, ok := invalidKey(nested); ok {
+				return key, true
+			}
+		}
+	case []any:
+		for _, nested := range v {
+			if key, ok := invalidKey(nested); ok {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+func invalidAttributes(message string) error {
+	return errwrap.NewError(message, codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+}
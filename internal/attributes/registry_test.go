@@ -0,0 +1,84 @@
+package attributes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const marketingSchema = `{
+	"type": "object",
+	"properties": {
+		"newsletter": {"type": "boolean"},
+		"score": {"type": "integer", "minimum": 0},
+		"channel": {"enum": ["email", "sms"]},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	},
+	"additionalProperties": false
+}`
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "marketing.json"), []byte(marketingSchema), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "profile.json"), []byte(`{"type": "object"}`), 0o600))
+
+	registry := NewRegistry(dir)
+	require.NoError(t, registry.Init())
+	return registry
+}
+
+func TestRegistryInit(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	assert.Equal(t, []string{"marketing", "profile"}, registry.Namespaces())
+	assert.Equal(t, filter.Schema{
+		"custom_attributes.marketing.newsletter": {Path: "custom_attributes.marketing.newsletter", Type: filter.Bool},
+		"custom_attributes.marketing.score":      {Path: "custom_attributes.marketing.score", Type: filter.Number},
+	}, registry.FilterFields())
+}
+
+func TestRegistryValidate(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	tests := []struct {
+		name       string
+		attributes model.UserAttributes
+		namespaces []string
+		err        string
+	}{
+		{"valid", model.UserAttributes{"marketing": map[string]any{"newsletter": true, "score": 3.0, "tags": []any{"a"}}}, nil, ""},
+		{"unknown namespace", model.UserAttributes{"billing": map[string]any{}}, nil, "unknown attribute namespace billing"},
+		{"not an object", model.UserAttributes{"profile": "x"}, nil, "custom_attributes.profile must be an object"},
+		{"schema mismatch", model.UserAttributes{"marketing": map[string]any{"score": -1.0}}, nil, "custom_attributes.marketing.score"},
+		{"additional property", model.UserAttributes{"marketing": map[string]any{"phone": "1"}}, nil, "custom_attributes.marketing"},
+		{"dotted key", model.UserAttributes{"profile": map[string]any{"a.b": 1.0}}, nil, `invalid key "a.b"`},
+		{"operator key", model.UserAttributes{"profile": map[string]any{"x": []any{map[string]any{"$gt": 1.0}}}}, nil, `invalid key "$gt"`},
+		{"only given namespaces", model.UserAttributes{"billing": map[string]any{}, "profile": map[string]any{}}, []string{"profile"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.Validate(tt.attributes, tt.namespaces...)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestRegistryRejectsInvalidSchemas(t *testing.T) {
+	registry := NewRegistry("")
+
+	assert.Error(t, registry.Register("Marketing", []byte(`{}`)))
+	assert.Error(t, registry.Register("marketing", []byte(`{"type": 1}`)))
+	require.NoError(t, registry.Register("marketing", []byte(`{}`)))
+	assert.Error(t, registry.Register("marketing", []byte(`{}`)))
+}
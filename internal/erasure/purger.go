@@ -0,0 +1,229 @@
+package erasure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/eventbus"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
+	"github.com/spf13/viper"
+)
+
+const (
+	configKeyRetention     = "ERASURE_RETENTION"
+	configKeyPurgeInterval = "ERASURE_PURGE_INTERVAL"
+
+	// purgeBatchSize is the number of users purged per query
+	purgeBatchSize = 100
+)
+
+// errErasureCancelled is returned by Purge if the user was restored before it was deleted
+var errErasureCancelled = errors.New("erasure cancelled")
+
+// Cleaner removes the personal data of a user from a store outside of the user document and security events.
+// It must be idempotent, a purge interrupted halfway is repeated.
+type Cleaner struct {
+	Name  string
+	Clean func(ctx context.Context, user *model.User) error
+}
+
+// Purger erases the users whose erasure was requested longer than the retention period ago.
+//
+// It runs in the background from Init until Close. A purge cleans the stores of the cleaners,
+// anonymizes the security events, deletes the user document and writes the tombstone, in this
+// order, so an interrupted purge is completed by the next run. The user document is only deleted
+// if the user is still inactive and waiting for its erasure, otherwise no tombstone is written.
+type Purger struct {
+	stack.AbstractProvider
+	repo      repository.Repository
+	publisher eventbus.Publisher
+	cleaners  []Cleaner
+	retention time.Duration
+	interval  time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewPurgerFromEnv creates a purger configured by ERASURE_RETENTION (default 30 days) and ERASURE_PURGE_INTERVAL (default 1h)
+func NewPurgerFromEnv(repo repository.Repository, publisher eventbus.Publisher, cleaners ...Cleaner) *Purger {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault(configKeyRetention, "720h")
+	vi.SetDefault(configKeyPurgeInterval, "1h")
+
+	return NewPurger(repo, publisher, vi.GetDuration(configKeyRetention), vi.GetDuration(configKeyPurgeInterval), cleaners...)
+}
+
+// NewPurger creates a purger that erases users retention after their erasure request, checking every interval
+func NewPurger(repo repository.Repository, publisher eventbus.Publisher, retention, interval time.Duration, cleaners ...Cleaner) *Purger {
+	return &Purger{
+		repo:      repo,
+		publisher: publisher,
+		cleaners:  cleaners,
+		retention: retention,
+		interval:  interval,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Init starts purging in the background
+func (p *Purger) Init() error {
+	if p.retention < 0 {
+		return fmt.Errorf("%s must not be negative", configKeyRetention)
+	}
+	if p.interval <= 0 {
+		return fmt.Errorf("%s must be positive", configKeyPurgeInterval)
+	}
+
+	p.wg.Add(1)
+	go p.run()
+	slog.Info("Erasure purger started.", slog.Duration("retention", p.retention), slog.Duration("interval", p.interval))
+	return nil
+}
+
+// Close stops purging and waits for a running purge to finish
+func (p *Purger) Close() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+func (p *Purger) run() {
+	defer p.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-p.stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := p.PurgeDue(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "erasure purge failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDue erases all users that are due and returns their number.
+// A user that fails to be erased is skipped and retried on the next run.
+func (p *Purger) PurgeDue(ctx context.Context) (int, error) {
+	requestedBefore := time.Now().UTC().Add(-p.retention)
+
+	var purged int
+	failed := map[string]bool{}
+	for ctx.Err() == nil {
+		users, err := p.repo.ListUsersDueForErasure(ctx, requestedBefore, int64(purgeBatchSize+len(failed)))
+		if err != nil {
+			return purged, err
+		}
+
+		var progress bool
+		for _, user := range users {
+			if failed[user.Id] {
+				continue
+			}
+			progress = true
+			err := p.Purge(ctx, user)
+			if errors.Is(err, errErasureCancelled) {
+				slog.InfoContext(ctx, "erasure cancelled, the user was restored", slog.String("user_id", user.Id))
+				continue
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to erase user", slog.String("user_id", user.Id), slog.Any("error", err))
+				failed[user.Id] = true
+				continue
+			}
+			purged++
+		}
+		if !progress {
+			break
+		}
+	}
+
+	if purged > 0 {
+		slog.InfoContext(ctx, "users erased", slog.Int("count", purged))
+	}
+	return purged, ctx.Err()
+}
+
+// Purge erases a single user, whose erasure must have been requested
+func (p *Purger) Purge(ctx context.Context, user *model.User) error {
+	if user.ErasureRequest == nil {
+		return fmt.Errorf("erasure of user %s was not requested", user.Id)
+	}
+
+	cleaned := make([]string, 0, len(p.cleaners)+2)
+	for _, cleaner := range p.cleaners {
+		if err := cleaner.Clean(ctx, user); err != nil {
+			return fmt.Errorf("failed to clean %s: %w", cleaner.Name, err)
+		}
+		cleaned = append(cleaned, cleaner.Name)
+	}
+
+	if err := p.repo.AnonymizeUserEvents(ctx, user.Id, user.Identifiers()); err != nil {
+		return err
+	}
+	cleaned = append(cleaned, "security_events", "users")
+
+	// The user is only deleted if it was not restored since it was listed
+	deleted, err := p.repo.DeleteUserPendingErasure(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errErasureCancelled
+	}
+
+	tombstone := &model.ErasureTombstone{
+		UserID:      user.Id,
+		RequestedBy: user.ErasureRequest.By,
+		RequestTime: user.ErasureRequest.Time,
+		Reason:      user.ErasureRequest.Reason,
+		ErasedAt:    time.Now().UTC(),
+		Cleaned:     cleaned,
+	}
+	if err := p.repo.SaveTombstone(ctx, tombstone); err != nil {
+		return err
+	}
+
+	p.publish(ctx, &model.UserLifecycleEvent{
+		Type:       model.UserLifecycle_Erased,
+		UserID:     user.Id,
+		By:         user.ErasureRequest.By,
+		Reason:     user.ErasureRequest.Reason,
+		OccurredAt: tombstone.ErasedAt,
+	})
+	return nil
+}
+
+// publish sends the lifecycle event to the event bus if one is configured
+func (p *Purger) publish(ctx context.Context, event *model.UserLifecycleEvent) {
+	if p.publisher == nil {
+		return
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to marshal lifecycle event", slog.Any("error", err), slog.Any("type", event.Type))
+		return
+	}
+	if err := p.publisher.Publish(ctx, event.Topic(), data); err != nil {
+		slog.WarnContext(ctx, "failed to publish lifecycle event", slog.Any("error", err), slog.Any("type", event.Type))
+	}
+}
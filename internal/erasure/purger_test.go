@@ -0,0 +1,134 @@
+package erasure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepo implements the repository methods used by the purger over users in memory
+type fakeRepo struct {
+	repository.Repository
+	users      []*model.User
+	tombstones []*model.ErasureTombstone
+	anonymized map[string][]string
+}
+
+func (f *fakeRepo) ListUsersDueForErasure(ctx context.Context, requestedBefore time.Time, limit int64) ([]*model.User, error) {
+	var users []*model.User
+	for _, user := range f.users {
+		if user.ErasureRequest.Time.Before(requestedBefore) && int64(len(users)) < limit {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeRepo) AnonymizeUserEvents(ctx context.Context, userID string, identifiers []string) error {
+	f.anonymized[userID] = identifiers
+	return nil
+}
+
+func (f *fakeRepo) SaveTombstone(ctx context.Context, tombstone *model.ErasureTombstone) error {
+	f.tombstones = append(f.tombstones, tombstone)
+	return nil
+}
+
+func (f *fakeRepo) DeleteUserPendingErasure(ctx context.Context, id string) (bool, error) {
+	for i, user := range f.users {
+		if user.Id == id && user.ErasureRequest != nil && user.Status == model.UserStatus_Inactive {
+			f.users = append(f.users[:i], f.users[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakePublisher records the published topics
+type fakePublisher struct {
+	topics []string
+}
+
+func (f *fakePublisher) Publish(ctx context.Context, topic string, data []byte) error {
+	f.topics = append(f.topics, topic)
+	return nil
+}
+
+func erasureRequested(id string, age time.Duration) *model.User {
+	return &model.User{
+		Id:             id,
+		Email:          id + "@example.com",
+		NickName:       id,
+		Status:         model.UserStatus_Inactive,
+		ErasureRequest: &model.UserStatusChange{By: "admin", Time: time.Now().UTC().Add(-age), Reason: "gdpr"},
+	}
+}
+
+func TestPurgeDue(t *testing.T) {
+	repo := &fakeRepo{
+		users: []*model.User{
+			erasureRequested("due", 48*time.Hour),
+			erasureRequested("failing", 48*time.Hour),
+			erasureRequested("recent", time.Hour),
+		},
+		anonymized: map[string][]string{},
+	}
+	publisher := &fakePublisher{}
+	var cleaned []string
+	cleaner := Cleaner{Name: "tokens", Clean: func(ctx context.Context, user *model.User) error {
+		if user.Id == "failing" {
+			return errors.New("store unavailable")
+		}
+		cleaned = append(cleaned, user.Id)
+		return nil
+	}}
+	purger := NewPurger(repo, publisher, 24*time.Hour, time.Hour, cleaner)
+
+	purged, err := purger.PurgeDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	assert.Equal(t, []string{"due"}, cleaned)
+	assert.Equal(t, []string{"due@example.com", "due"}, repo.anonymized["due"])
+	require.Len(t, repo.tombstones, 1)
+	assert.Equal(t, "due", repo.tombstones[0].UserID)
+	assert.Equal(t, "gdpr", repo.tombstones[0].Reason)
+	assert.Equal(t, []string{"tokens", "security_events", "users"}, repo.tombstones[0].Cleaned)
+	assert.Equal(t, []string{"user.lifecycle.erased"}, publisher.topics)
+
+	// The failing user is kept for the next run
+	require.Len(t, repo.users, 2)
+	assert.Equal(t, "failing", repo.users[0].Id)
+	assert.Equal(t, "recent", repo.users[1].Id)
+}
+
+func TestPurgeRequiresErasureRequest(t *testing.T) {
+	repo := &fakeRepo{users: []*model.User{{Id: "active"}}, anonymized: map[string][]string{}}
+	purger := NewPurger(repo, nil, 0, time.Hour)
+
+	assert.Error(t, purger.Purge(context.Background(), repo.users[0]))
+	assert.Len(t, repo.users, 1)
+}
+
+func TestPurgeSkipsRestoredUser(t *testing.T) {
+	user := erasureRequested("restored", 48*time.Hour)
+	repo := &fakeRepo{users: []*model.User{user}, anonymized: map[string][]string{}}
+	publisher := &fakePublisher{}
+	purger := NewPurger(repo, publisher, 24*time.Hour, time.Hour)
+
+	// The user is restored after it was listed for the purge
+	listed := *user
+	user.Status = model.UserStatus_Active
+	user.ErasureRequest = nil
+
+	assert.ErrorIs(t, purger.Purge(context.Background(), &listed), errErasureCancelled)
+	assert.Len(t, repo.users, 1)
+	assert.Empty(t, repo.tombstones)
+	assert.Empty(t, publisher.topics)
+}
@@ -0,0 +1,177 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"google.golang.org/grpc/codes"
+)
+
+// maxLineLength limits a single JSONL line
+const maxLineLength = 64 * 1024
+
+// columns are the user fields an import may set, named after their proto fields
+var columns = map[string]func(u *model.User, value string){
+	model.UserField_FirstName: func(u *model.User, v string) { u.FirstName = v },
+	model.UserField_LastName:  func(u *model.User, v string) { u.LastName = v },
+	model.UserField_NickName:  func(u *model.User, v string) { u.NickName = v },
+	model.UserField_Email:     func(u *model.User, v string) { u.Email = v },
+	model.UserField_Country:   func(u *model.User, v string) { u.Country = v },
+	model.UserField_Password:  func(u *model.User, v string) { u.Password = v },
+}
+
+// RowReader reads the rows of an import input
+type RowReader interface {
+	// Next returns the next row, or io.EOF after the last one.
+	// Rows that cannot be decoded are returned with Err set, other errors end the import.
+	Next() (*model.UserImportRow, error)
+}
+
+// NewRowReader creates a reader of rows in the given format
+func NewRowReader(r io.Reader, format model.ImportFormat) (RowReader, error) {
+	switch format {
+	case model.ImportFormat_CSV:
+		return newCSVReader(r), nil
+	case model.ImportFormat_JSONL:
+		return newJSONLReader(r), nil
+	default:
+		return nil, errwrap.NewError("import format is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+}
+
+// invalidRow returns a row that failed to decode
+func invalidRow(line int, msg string) *model.UserImportRow {
+	return &model.UserImportRow{
+		Line: line,
+		Err:  errwrap.NewError(msg, codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument),
+	}
+}
+
+// csvReader reads CSV with a header row naming the columns
+type csvReader struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCSVReader(r io.Reader) *csvReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // checked against the header per row
+	reader.TrimLeadingSpace = true
+	return &csvReader{reader: reader}
+}
+
+func (r *csvReader) Next() (*model.UserImportRow, error) {
+	if r.header == nil {
+		if err := r.readHeader(); err != nil {
+			return nil, err
+		}
+	}
+
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return invalidRow(parseErr.StartLine, "malformed csv: "+parseErr.Err.Error()), nil
+		}
+		return nil, err
+	}
+	line, _ := r.reader.FieldPos(0)
+	if len(record) != len(r.header) {
+		return invalidRow(line, fmt.Sprintf("expected %d columns, got %d", len(r.header), len(record))), nil
+	}
+
+	user := &model.User{}
+	for i, value := range record {
+		columns[r.header[i]](user, strings.TrimSpace(value))
+	}
+	return &model.UserImportRow{Line: line, User: user}, nil
+}
+
+// readHeader reads and validates the header row
+func (r *csvReader) readHeader() error {
+	header, err := r.reader.Read()
+	if err == io.EOF {
+		return errwrap.NewError("csv header row is missing", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+	if err != nil {
+		return errwrap.NewError("malformed csv header: "+err.Error(), codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	seen := map[string]bool{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if i == 0 {
+			column = strings.TrimPrefix(column, "\ufeff") // byte order mark of spreadsheet exports
+		}
+		if _, ok := columns[column]; !ok {
+			return errwrap.NewError("unknown csv column "+column, codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		}
+		if seen[column] {
+			return errwrap.NewError("duplicate csv column "+column, codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+	if !seen[model.UserField_Email] {
+		return errwrap.NewError("csv column email is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	r.header = header
+	return nil
+}
+
+// jsonlReader reads one JSON object per line, blank lines are skipped
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineLength)
+	return &jsonlReader{scanner: scanner}
+}
+
+func (r *jsonlReader) Next() (*model.UserImportRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var fields map[string]string
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return invalidRow(r.line, "malformed json: expected an object of string fields"), nil
+		}
+		user := &model.User{}
+		for name, value := range fields {
+			set, ok := columns[name]
+			if !ok {
+				return invalidRow(r.line, "unknown field "+name), nil
+			}
+			set(user, strings.TrimSpace(value))
+		}
+		return &model.UserImportRow{Line: r.line, User: user}, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, errwrap.NewError(fmt.Sprintf("line %d is longer than %d bytes", r.line+1, maxLineLength), codes.InvalidArgument.String()).
+				SetGrpcCode(codes.InvalidArgument)
+		}
+		return nil, err
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll returns all rows of the input
+func readAll(t *testing.T, input string, format model.ImportFormat) ([]*model.UserImportRow, error) {
+	t.Helper()
+	reader, err := NewRowReader(strings.NewReader(input), format)
+	require.NoError(t, err)
+
+	var rows []*model.UserImportRow
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	input := "\ufeffEmail, first_name,last_name,password\n" +
+		"ahmet@example.com,Ahmet,Yılmaz,secret\n" +
+		"\n" +
+		"mehmet@example.com,Mehmet\n" +
+		"\"ayse@example.com\",\"Ayşe, Nur\",Kaya,\n"
+
+	rows, err := readAll(t, input, model.ImportFormat_CSV)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, &model.User{Email: "ahmet@example.com", FirstName: "Ahmet", LastName: "Yılmaz", Password: "secret"}, rows[0].User)
+
+	assert.Equal(t, 4, rows[1].Line)
+	assert.ErrorContains(t, rows[1].Err, "expected 4 columns, got 2")
+
+	assert.Equal(t, 5, rows[2].Line)
+	assert.Equal(t, &model.User{Email: "ayse@example.com", FirstName: "Ayşe, Nur", LastName: "Kaya"}, rows[2].User)
+}
+
+func TestCSVReaderHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		err    string
+	}{
+		{"unknown column", "email,status\n", "unknown csv column status"},
+		{"duplicate column", "email,email\n", "duplicate csv column email"},
+		{"email missing", "first_name\n", "csv column email is required"},
+		{"empty input", "", "csv header row is missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readAll(t, tt.header, model.ImportFormat_CSV)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestJSONLReader(t *testing.T) {
+	input := `{"email":"ahmet@example.com","first_name":"Ahmet","nick_name":" ay "}` + "\n" +
+		"\n" +
+		`{"email":"mehmet@example.com","status":"ACTIVE"}` + "\n" +
+		`not json` + "\n" +
+		`{"email":"ayse@example.com","country":"TR"}`
+
+	rows, err := readAll(t, input, model.ImportFormat_JSONL)
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, 1, rows[0].Line)
+	assert.Equal(t, &model.User{Email: "ahmet@example.com", FirstName: "Ahmet", NickName: "ay"}, rows[0].User)
+
+	assert.Equal(t, 3, rows[1].Line)
+	assert.ErrorContains(t, rows[1].Err, "unknown field status")
+
+	assert.Equal(t, 4, rows[2].Line)
+	assert.ErrorContains(t, rows[2].Err, "malformed json")
+
+	assert.Equal(t, 5, rows[3].Line)
+	assert.Equal(t, &model.User{Email: "ayse@example.com", Country: "TR"}, rows[3].User)
+}
+
+func TestNewRowReaderRequiresFormat(t *testing.T) {
+	_, err := NewRowReader(strings.NewReader(""), model.ImportFormat_Unspecified)
+	assert.Error(t, err)
+}
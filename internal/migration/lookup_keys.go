@@ -0,0 +1,187 @@
+// Package migration holds one-off data migrations of the users collection.
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// LookupKeysPrincipal records the migration as the deactivating principal of duplicates
+const LookupKeysPrincipal = "service:lookup-key-migration"
+
+// Duplicate is a user whose email or nickname has the same lookup key as the user that kept it
+type Duplicate struct {
+	UserID string `json:"user_id"`
+	KeptBy string `json:"kept_by"`
+	Key    string `json:"key"`
+}
+
+// LookupKeysReport is the outcome of MigrateLookupKeys
+type LookupKeysReport struct {
+	Users int `json:"users"`
+	// DeactivatedUsers share their email with another user, they are deactivated
+	DeactivatedUsers []Duplicate `json:"deactivated_users"`
+	// ClearedNickNames share their nickname with another user, the nickname is removed
+	ClearedNickNames []Duplicate `json:"cleared_nick_names"`
+	// KeysUpdated is the number of users whose lookup keys were missing or outdated
+	KeysUpdated int  `json:"keys_updated"`
+	DryRun      bool `json:"dry_run"`
+}
+
+// userRef is a user of a group with the same lookup key
+type userRef struct {
+	id          string
+	active      bool
+	nickNameKey string
+}
+
+// MigrateLookupKeys deduplicates users by the lookup keys of their emails and nicknames, then stores the keys of every user.
+// It runs again when USER_EMAIL_FOLD_GMAIL changes.
+//
+// Of users with the same email key, the first active one in creation order keeps the email, or the first one if none is active.
+// The others are deactivated and marked as duplicates, they hold no lookup keys until they are restored.
+// Of the remaining users with the same nickname key, the same rule decides who keeps the nickname, the others lose it.
+// With dryRun the report is computed but nothing is written.
+func MigrateLookupKeys(ctx context.Context, repo repository.UserRepo, dryRun bool) (*LookupKeysReport, error) {
+	keys := repo.KeyNormalizer()
+	report := &LookupKeysReport{DryRun: dryRun}
+
+	// Group the users by their keys
+	var users []userRef // in creation order
+	var emailKeys, nickNameKeys []string
+	emailGroups, nickNameGroups := map[string][]userRef{}, map[string][]userRef{}
+	err := repo.StreamUsers(ctx, bson.M{}, model.UserDefaultSort, nil, func(user *model.User, _ []bson.RawValue) error {
+		report.Users++
+		keys.Refresh(user)
+		if user.LookupKeys.DuplicateOf != "" {
+			return nil // deduplicated before
+		}
+
+		ref := userRef{id: user.Id, active: user.Status == model.UserStatus_Active, nickNameKey: user.LookupKeys.NickName}
+		users = append(users, ref)
+		if _, ok := emailGroups[user.LookupKeys.Email]; !ok {
+			emailKeys = append(emailKeys, user.LookupKeys.Email)
+		}
+		emailGroups[user.LookupKeys.Email] = append(emailGroups[user.LookupKeys.Email], ref)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deactivated := map[string]bool{}
+	for _, key := range emailKeys {
+		for _, duplicate := range duplicates(emailGroups[key], key) {
+			deactivated[duplicate.UserID] = true
+			report.DeactivatedUsers = append(report.DeactivatedUsers, duplicate)
+		}
+	}
+
+	// Deactivated users lose their nickname key as well, so they are left out here
+	for _, ref := range users {
+		if ref.nickNameKey == "" || deactivated[ref.id] {
+			continue
+		}
+		if _, ok := nickNameGroups[ref.nickNameKey]; !ok {
+			nickNameKeys = append(nickNameKeys, ref.nickNameKey)
+		}
+		nickNameGroups[ref.nickNameKey] = append(nickNameGroups[ref.nickNameKey], ref)
+	}
+	for _, key := range nickNameKeys {
+		report.ClearedNickNames = append(report.ClearedNickNames, duplicates(nickNameGroups[key], key)...)
+	}
+
+	if !dryRun {
+		if err := resolveDuplicates(ctx, repo, report); err != nil {
+			return nil, err
+		}
+	}
+
+	// Store the keys of all users, duplicates are resolved so they are unique now
+	err = repo.StreamUsers(ctx, bson.M{}, model.UserDefaultSort, nil, func(user *model.User, _ []bson.RawValue) error {
+		stored := user.LookupKeys
+		keys.Refresh(user)
+		if stored != nil && *stored == *user.LookupKeys {
+			return nil
+		}
+		report.KeysUpdated++
+		if dryRun {
+			return nil
+		}
+		return repo.SetLookupKeys(ctx, user.Id, user.LookupKeys)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "lookup keys migrated",
+		slog.Int("users", report.Users), slog.Int("deactivated_users", len(report.DeactivatedUsers)),
+		slog.Int("cleared_nick_names", len(report.ClearedNickNames)), slog.Int("keys_updated", report.KeysUpdated),
+		slog.Bool("dry_run", dryRun))
+	return report, nil
+}
+
+// duplicates returns all users of a group with the same key but the one that keeps it,
+// which is the first active user in creation order, or the first user if none is active
+func duplicates(group []userRef, key string) []Duplicate {
+	if len(group) < 2 {
+		return nil
+	}
+	kept := group[0]
+	for _, ref := range group {
+		if ref.active {
+			kept = ref
+			break
+		}
+	}
+
+	var result []Duplicate
+	for _, ref := range group {
+		if ref.id != kept.id {
+			result = append(result, Duplicate{UserID: ref.id, KeptBy: kept.id, Key: key})
+		}
+	}
+	return result
+}
+
+// resolveDuplicates deactivates the users with duplicate emails and removes duplicate nicknames
+func resolveDuplicates(ctx context.Context, repo repository.UserRepo, report *LookupKeysReport) error {
+	for _, duplicate := range report.DeactivatedUsers {
+		user, err := repo.GetUserById(ctx, duplicate.UserID)
+		if err != nil {
+			return err
+		}
+		if user.Status != model.UserStatus_Inactive {
+			user.Status = model.UserStatus_Inactive
+			user.Deactivation = &model.UserStatusChange{
+				By:     LookupKeysPrincipal,
+				Time:   time.Now().UTC(),
+				Reason: fmt.Sprintf("duplicate email of user %s", duplicate.KeptBy),
+			}
+		}
+		user.LookupKeys = &model.UserLookupKeys{DuplicateOf: duplicate.KeptBy}
+		user.Meta.Update()
+		if err := repo.UpdateUser(ctx, user); err != nil {
+			return err
+		}
+	}
+
+	for _, duplicate := range report.ClearedNickNames {
+		user, err := repo.GetUserById(ctx, duplicate.UserID)
+		if err != nil {
+			return err
+		}
+		user.NickName = ""
+		user.Meta.Update()
+		if err := repo.UpdateUser(ctx, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package migration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fakeRepo keeps users in creation order in memory
+type fakeRepo struct {
+	repository.UserRepo
+	keys  model.UserKeyNormalizer
+	users []*model.User
+}
+
+func (f *fakeRepo) KeyNormalizer() model.UserKeyNormalizer {
+	return f.keys
+}
+
+func (f *fakeRepo) StreamUsers(ctx context.Context, filterCriteria bson.M, sort types.Sort, after []bson.RawValue, fn func(user *model.User, key []bson.RawValue) error) error {
+	for _, user := range f.users {
+		copied := *user
+		if err := fn(&copied, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepo) GetUserById(ctx context.Context, id string) (*model.User, error) {
+	copied := *f.find(id)
+	return &copied, nil
+}
+
+func (f *fakeRepo) UpdateUser(ctx context.Context, user *model.User) error {
+	f.keys.Refresh(user)
+	*f.find(user.Id) = *user
+	return nil
+}
+
+func (f *fakeRepo) SetLookupKeys(ctx context.Context, id string, keys *model.UserLookupKeys) error {
+	f.find(id).LookupKeys = keys
+	return nil
+}
+
+func (f *fakeRepo) find(id string) *model.User {
+	for _, user := range f.users {
+		if user.Id == id {
+			return user
+		}
+	}
+	return nil
+}
+
+func newUser(id, email, nickName string, status model.UserStatus) *model.User {
+	return &model.User{Id: id, Email: email, NickName: nickName, Status: status}
+}
+
+func TestMigrateLookupKeys(t *testing.T) {
+	repo := &fakeRepo{
+		keys: model.UserKeyNormalizer{FoldGmail: true},
+		users: []*model.User{
+			newUser("inactive", "ahmet@example.com", "", model.UserStatus_Inactive),
+			newUser("kept", "Ahmet@Example.com", "ahmet", model.UserStatus_Active),
+			newUser("duplicate", "AHMET@example.com", "mehmet", model.UserStatus_Active),
+			newUser("gmail", "ay.se+news@googlemail.com", "Mehmet", model.UserStatus_Active),
+			newUser("nickname", "ayse@example.com", "ＭＥＨＭＥＴ", model.UserStatus_Active),
+		},
+	}
+	ctx := context.Background()
+
+	dryRun, err := MigrateLookupKeys(ctx, repo, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(dryRun.DeactivatedUsers))
+	assert.Nil(t, repo.find("kept").LookupKeys)
+
+	report, err := MigrateLookupKeys(ctx, repo, false)
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Users)
+	assert.Equal(t, []Duplicate{
+		{UserID: "inactive", KeptBy: "kept", Key: "ahmet@example.com"},
+		{UserID: "duplicate", KeptBy: "kept", Key: "ahmet@example.com"},
+	}, report.DeactivatedUsers)
+	// The nickname of the deactivated duplicate does not count
+	assert.Equal(t, []Duplicate{{UserID: "nickname", KeptBy: "gmail", Key: "mehmet"}}, report.ClearedNickNames)
+
+	duplicate := repo.find("duplicate")
+	assert.Equal(t, model.UserStatus_Inactive, duplicate.Status)
+	assert.Equal(t, LookupKeysPrincipal, duplicate.Deactivation.By)
+	assert.Equal(t, &model.UserLookupKeys{DuplicateOf: "kept"}, duplicate.LookupKeys)
+	assert.Equal(t, &model.UserLookupKeys{Email: "ahmet@example.com", NickName: "ahmet"}, repo.find("kept").LookupKeys)
+	assert.Equal(t, &model.UserLookupKeys{Email: "ayse@gmail.com", NickName: "mehmet"}, repo.find("gmail").LookupKeys)
+	assert.Empty(t, repo.find("nickname").NickName)
+
+	// A second run finds nothing to do
+	again, err := MigrateLookupKeys(ctx, repo, false)
+	require.NoError(t, err)
+	assert.Empty(t, again.DeactivatedUsers)
+	assert.Empty(t, again.ClearedNickNames)
+	assert.Zero(t, again.KeysUpdated)
+}
+
+func TestRestoredDuplicateGetsKeys(t *testing.T) {
+	keys := model.UserKeyNormalizer{}
+	user := newUser("duplicate", "Ahmet@Example.com", "", model.UserStatus_Inactive)
+	user.LookupKeys = &model.UserLookupKeys{DuplicateOf: "kept"}
+
+	keys.Refresh(user)
+	assert.Empty(t, user.LookupKeys.Email)
+
+	user.Status = model.UserStatus_Active
+	keys.Refresh(user)
+	assert.Equal(t, &model.UserLookupKeys{Email: "ahmet@example.com"}, user.LookupKeys)
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+)
+
+// DataExportVersion is the version of the data export format, it changes when fields are removed or change meaning
+const DataExportVersion = 1
+
+// UserDataExport is everything stored about a user, as handed out for a right of access request.
+// The password hash and token hashes are left out.
+type UserDataExport struct {
+	Version            int                          `json:"version"`
+	GeneratedAt        time.Time                    `json:"generated_at"`
+	User               *User                        `json:"user"` // includes the deactivation, restoration and erasure records
+	Sessions           []*ExportedSession           `json:"sessions"`
+	TokenInvalidations []*ExportedTokenInvalidation `json:"token_invalidations"`
+	SecurityEvents     []*SecurityEvent             `json:"security_events"`
+}
+
+// ExportedSession is an issued opaque token of the user
+type ExportedSession struct {
+	TokenID   string    `json:"token_id,omitempty"`
+	DeviceID  string    `json:"device_id,omitempty"`
+	TokenType string    `json:"token_type"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExportedTokenInvalidation is a revocation of tokens of the user
+type ExportedTokenInvalidation struct {
+	DeviceID      string    `json:"device_id,omitempty"`
+	TokenType     string    `json:"token_type,omitempty"`
+	InvalidatedAt time.Time `json:"invalidated_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// BlobKey is the key of the export in the blob store
+func (e *UserDataExport) BlobKey() string {
+	return "data-exports/" + e.User.Id + "/" + e.GeneratedAt.Format("20060102T150405Z") + ".json"
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// ErasureTombstone proves that the personal data of a user was erased.
+// It only keeps the id of the user and the details of the erasure request.
+type ErasureTombstone struct {
+	UserID      string    `bson:"_id"`
+	RequestedBy string    `bson:"requested_by"`
+	RequestTime time.Time `bson:"request_time"`
+	Reason      string    `bson:"reason,omitempty"`
+	ErasedAt    time.Time `bson:"erased_at"`
+	Cleaned     []string  `bson:"cleaned"` // stores the data of the user was removed from
+}
@@ -0,0 +1,25 @@
+package model
+
+import pbuser "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
+
+type NicknameAvailability int
+
+const (
+	NicknameAvailability_Unspecified NicknameAvailability = 0 //Default
+	NicknameAvailability_Available   NicknameAvailability = 1 //Available
+	NicknameAvailability_Taken       NicknameAvailability = 2 //Used by another user, whatever its status
+	NicknameAvailability_NotAllowed  NicknameAvailability = 3 //Reserved or containing a blocked word
+)
+
+// NicknameCheck is the outcome of a nickname availability check
+type NicknameCheck struct {
+	Availability NicknameAvailability
+	Suggestions  []string // available alternatives, only for taken nicknames
+}
+
+func (c *NicknameCheck) ToProto() *pbuser.CheckNicknameAvailabilityResponse {
+	return &pbuser.CheckNicknameAvailabilityResponse{
+		Availability: pbuser.NicknameAvailability(c.Availability),
+		Suggestions:  c.Suggestions,
+	}
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	pbuser "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
+	pbtypes "github.com/nsaltun/user-service-grpc/proto/gen/go/shared/types/v1"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type SecurityEventType string
+
+const (
+	SecurityEvent_LoginSucceeded       SecurityEventType = "login_succeeded"
+	SecurityEvent_LoginFailed          SecurityEventType = "login_failed"
+	SecurityEvent_TokenRefreshed       SecurityEventType = "token_refreshed"
+	SecurityEvent_RefreshReuseDetected SecurityEventType = "refresh_reuse_detected"
+	SecurityEvent_LogoutAll            SecurityEventType = "logout_all"
+	SecurityEvent_PasswordChanged      SecurityEventType = "password_changed"
+	SecurityEvent_SessionRevoked       SecurityEventType = "session_revoked"
+	SecurityEvent_EmailChanged         SecurityEventType = "email_changed"
+	SecurityEvent_EmailChangeReverted  SecurityEventType = "email_change_reverted"
+)
+
+// securityEventTypeToProto maps stored event types to their proto enum values
+var securityEventTypeToProto = map[SecurityEventType]pbuser.SecurityEventType{
+	SecurityEvent_LoginSucceeded:       pbuser.SecurityEventType_SECURITY_EVENT_TYPE_LOGIN_SUCCEEDED,
+	SecurityEvent_LoginFailed:          pbuser.SecurityEventType_SECURITY_EVENT_TYPE_LOGIN_FAILED,
+	SecurityEvent_TokenRefreshed:       pbuser.SecurityEventType_SECURITY_EVENT_TYPE_TOKEN_REFRESHED,
+	SecurityEvent_RefreshReuseDetected: pbuser.SecurityEventType_SECURITY_EVENT_TYPE_REFRESH_REUSE_DETECTED,
+	SecurityEvent_LogoutAll:            pbuser.SecurityEventType_SECURITY_EVENT_TYPE_LOGOUT_ALL,
+	SecurityEvent_PasswordChanged:      pbuser.SecurityEventType_SECURITY_EVENT_TYPE_PASSWORD_CHANGED,
+	SecurityEvent_SessionRevoked:       pbuser.SecurityEventType_SECURITY_EVENT_TYPE_SESSION_REVOKED,
+	SecurityEvent_EmailChanged:         pbuser.SecurityEventType_SECURITY_EVENT_TYPE_EMAIL_CHANGED,
+	SecurityEvent_EmailChangeReverted:  pbuser.SecurityEventType_SECURITY_EVENT_TYPE_EMAIL_CHANGE_REVERTED,
+}
+
+// SecurityEvent is a typed record of authentication activity
+type SecurityEvent struct {
+	Id         string            `bson:"_id" json:"id"`
+	Type       SecurityEventType `bson:"type" json:"type"`
+	UserID     string            `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Identifier string            `bson:"identifier,omitempty" json:"identifier,omitempty"`
+	DeviceID   string            `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	IPAddress  string            `bson:"ip_address,omitempty" json:"ip_address,omitempty"`
+	UserAgent  string            `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	Reason     string            `bson:"reason,omitempty" json:"reason,omitempty"`
+	OccurredAt time.Time         `bson:"occurred_at" json:"occurred_at"`
+	ExpiresAt  time.Time         `bson:"expires_at" json:"-"` // Used by the TTL index for retention
+}
+
+type SecurityEventFilter struct {
+	UserID     string              `bson:"user_id" json:"user_id"`
+	Types      []SecurityEventType `bson:"type" json:"types"`
+	StartTime  time.Time           `json:"start_time"`
+	EndTime    time.Time           `json:"end_time"`
+	Pagination types.PaginationReq `json:"pagination"` //bson tag is not used for pagination
+}
+
+func (e *SecurityEvent) ToProto() *pbuser.SecurityEvent {
+	return &pbuser.SecurityEvent{
+		Id:         e.Id,
+		Type:       securityEventTypeToProto[e.Type],
+		UserId:     e.UserID,
+		Identifier: e.Identifier,
+		DeviceId:   e.DeviceID,
+		IpAddress:  e.IPAddress,
+		UserAgent:  e.UserAgent,
+		Reason:     e.Reason,
+		OccurredAt: timestamppb.New(e.OccurredAt),
+	}
+}
+
+// ToBson converts a SecurityEventFilter into a MongoDB filter
+func (f *SecurityEventFilter) ToBson() bson.M {
+	mongoFilter := bson.M{}
+
+	if f.UserID != "" {
+		mongoFilter["user_id"] = f.UserID
+	}
+	if len(f.Types) > 0 {
+		mongoFilter["type"] = bson.M{"$in": f.Types}
+	}
+
+	occurredAt := bson.M{}
+	if !f.StartTime.IsZero() {
+		occurredAt["$gte"] = f.StartTime
+	}
+	if !f.EndTime.IsZero() {
+		occurredAt["$lt"] = f.EndTime
+	}
+	if len(occurredAt) > 0 {
+		mongoFilter["occurred_at"] = occurredAt
+	}
+
+	return mongoFilter
+}
+
+func (f *SecurityEventFilter) SecurityEventFilterFromProto(req *pbuser.ListSecurityEventsRequest, pbPagination *pbtypes.List) {
+	f.UserID = req.GetUserId()
+	for _, t := range req.GetTypes() {
+		for eventType, pbType := range securityEventTypeToProto {
+			if pbType == t {
+				f.Types = append(f.Types, eventType)
+			}
+		}
+	}
+	if req.GetStartTime() != nil {
+		f.StartTime = req.GetStartTime().AsTime()
+	}
+	if req.GetEndTime() != nil {
+		f.EndTime = req.GetEndTime().AsTime()
+	}
+	f.Pagination = types.NewPaginationReq(pbPagination.GetOffset(), pbPagination.GetLimit())
+}
@@ -1,10 +1,21 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/filter"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
 	pbuser "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
 	pbtypes "github.com/nsaltun/user-service-grpc/proto/gen/go/shared/types/v1"
 	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
 )
 
 type UserStatus int
@@ -15,16 +26,106 @@ const (
 	UserStatus_Inactive    UserStatus = 2 //Inactive
 )
 
+// Updatable user fields, named after their proto fields
+const (
+	UserField_FirstName = "first_name"
+	UserField_LastName  = "last_name"
+	UserField_NickName  = "nick_name"
+	UserField_Email     = "email"
+	UserField_Country   = "country"
+	UserField_Status    = "status"
+	UserField_Password  = "password"
+	UserField_Phone     = "phone"
+)
+
+var (
+	// UserDefaultSort lists users in creation order, _id makes the order total for keyset pagination
+	UserDefaultSort = types.Sort{{Field: "createdAt"}, {Field: "_id"}}
+
+	// UserOrderByFields maps the sortable order_by fields to their document fields
+	UserOrderByFields = map[string]string{
+		"create_time": "createdAt",
+		"update_time": "updatedAt",
+		"last_name":   "last_name",
+		"country":     "country",
+		"email":       "email",
+	}
+
+	// UserFilterSchema whitelists the fields of filter expressions
+	UserFilterSchema = filter.Schema{
+		"id":          {Path: "_id", Type: filter.String},
+		"first_name":  {Path: "first_name", Type: filter.String},
+		"last_name":   {Path: "last_name", Type: filter.String},
+		"nick_name":   {Path: "nick_name", Type: filter.String},
+		"country":     {Path: "country", Type: filter.String},
+		"status":      {Path: "status", Type: filter.Enum, Values: map[string]any{"ACTIVE": UserStatus_Active, "INACTIVE": UserStatus_Inactive}},
+		"create_time": {Path: "createdAt", Type: filter.Timestamp},
+		"update_time": {Path: "updatedAt", Type: filter.Timestamp},
+	}
+
+	// UserPrivateFilterSchema are the fields of filter expressions reserved for admins and internal services,
+	// so that other users cannot enumerate emails and phone numbers
+	UserPrivateFilterSchema = filter.Schema{
+		"email": {Path: "email", Type: filter.String},
+		"phone": {Path: "phone", Type: filter.String},
+	}
+
+	// UserSortIndexes are the indexes backing the supported sort orders, following the
+	// equality, sort, range rule. Every sort ends with _id, lists are filtered by status by default.
+	UserSortIndexes = []types.Sort{
+		{{Field: "createdAt"}, {Field: "_id"}},
+		{{Field: "updatedAt"}, {Field: "_id"}}, // watching users by polling
+		{{Field: "status"}, {Field: "createdAt"}, {Field: "_id"}},
+		{{Field: "status"}, {Field: "updatedAt"}, {Field: "_id"}},
+		{{Field: "status"}, {Field: "last_name"}, {Field: "_id"}},
+		{{Field: "status"}, {Field: "country"}, {Field: "_id"}},
+		{{Field: "status"}, {Field: "country"}, {Field: "last_name"}, {Field: "_id"}},
+		{{Field: "status"}, {Field: "email"}, {Field: "_id"}},
+	}
+)
+
 type User struct {
 	Id         string           `bson:"_id" json:"id"`
 	FirstName  string           `bson:"first_name" json:"first_name"`
 	LastName   string           `bson:"last_name" json:"last_name"`
 	Email      string           `bson:"email" json:"email"`
 	NickName   string           `bson:"nick_name" json:"nick_name"`
-	Password   string           `bson:"password" json:"password"`
+	Password   string           `bson:"password" json:"-"` // the hash is never marshaled to JSON
 	Country    string           `bson:"country" json:"country"`
 	Status     UserStatus       `bson:"status" json:"status"`
 	types.Meta `bson:",inline"` // Embed Meta fields directly
+
+	// Phone is in E.164 format, it can be used to log in once it is verified
+	Phone             string             `bson:"phone,omitempty" json:"phone,omitempty"`
+	PhoneVerified     bool               `bson:"phone_verified,omitempty" json:"phone_verified,omitempty"`
+	PhoneVerification *PhoneVerification `bson:"phone_verification,omitempty" json:"-"`
+
+	// EmailChange is set while a new email waits to be verified, EmailRevert after the change is confirmed
+	EmailChange *EmailChange `bson:"email_change,omitempty" json:"-"`
+	EmailRevert *EmailRevert `bson:"email_revert,omitempty" json:"-"`
+
+	// Last deactivation and restoration of the user
+	Deactivation *UserStatusChange `bson:"deactivation,omitempty" json:"deactivation,omitempty"`
+	Restoration  *UserStatusChange `bson:"restoration,omitempty" json:"restoration,omitempty"`
+
+	// ErasureRequest is set while the user waits to be purged
+	ErasureRequest *UserStatusChange `bson:"erasure_request,omitempty" json:"erasure_request,omitempty"`
+
+	// CustomAttributes are validated against the JSON schema of their namespace by the service
+	CustomAttributes UserAttributes `bson:"custom_attributes,omitempty" json:"custom_attributes,omitempty"`
+
+	// LookupKeys are maintained by the repository on every write
+	LookupKeys *UserLookupKeys `bson:"lookup_keys,omitempty" json:"-"`
+
+	// SearchKeys are maintained by the repository on every write
+	SearchKeys *UserSearchKeys `bson:"search_keys,omitempty" json:"-"`
+
+	// Invite is set for users imported without password
+	Invite *UserInvite `bson:"invite,omitempty" json:"-"`
+
+	// Roles are granted in addition to the user role every user has, e.g. admin.
+	// They are assigned in the database by operators and carried in access tokens.
+	Roles []string `bson:"roles,omitempty" json:"-"`
 }
 
 type UserFilter struct {
@@ -34,7 +135,33 @@ type UserFilter struct {
 	FirstName  string              `bson:"first_name" json:"first_name"`
 	LastName   string              `bson:"last_name" json:"last_name"`
 	Country    string              `bson:"country" json:"country"`
+	Expression string              `bson:"-" json:"expression"` // AIP-160 filter expression
+	Sort       types.Sort          `json:"sort"`
 	Pagination types.PaginationReq `json:"pagination"` //bson tag is not used for pagination
+
+	expression *filter.Filter // compiled Expression
+}
+
+// UserLookup identifies a single user by exactly one of its unique keys
+type UserLookup struct {
+	Id       string
+	Email    string
+	NickName string
+}
+
+// WithoutPrivateFields returns a copy of the user for callers other than the user itself, admins and services.
+// The phone, the pending email change and the status change records with the ids of their authors are left out.
+func (u *User) WithoutPrivateFields() *User {
+	public := *u
+	public.Phone = ""
+	public.PhoneVerified = false
+	public.PhoneVerification = nil
+	public.EmailChange = nil
+	public.EmailRevert = nil
+	public.Deactivation = nil
+	public.Restoration = nil
+	public.ErasureRequest = nil
+	return &public
 }
 
 func (u *User) UserToProto() *pbuser.User {
@@ -45,24 +172,30 @@ func (u *User) UserToProto() *pbuser.User {
 		Email:     u.Email,
 		NickName:  u.NickName,
 		//Please notice that password is not included in the proto
-		Country: u.Country,
-		Status:  pbuser.UserStatus(u.Status),
-		Meta:    u.Meta.ToProto(),
+		Country:          u.Country,
+		Status:           pbuser.UserStatus(u.Status),
+		Meta:             u.Meta.ToProto(),
+		Deactivation:     u.Deactivation.ToProto(),
+		Restoration:      u.Restoration.ToProto(),
+		ErasureRequest:   u.ErasureRequest.ToProto(),
+		CustomAttributes: u.CustomAttributes.ToProto(),
+		Phone:            u.Phone,
+		PhoneVerified:    u.PhoneVerified,
+		PendingEmail:     u.PendingEmail(),
 	}
 }
 
 // ParseUserFilter converts a UserFilter into a MongoDB filter
 func (f *UserFilter) ToBson() bson.M {
-	//TODO: Sorting might be added as well
 	mongoFilter := bson.M{}
 
 	// Use exact matches for fields to utilize indexes
 	if f.FirstName != "" {
 		// Use prefix match instead of full regex if possible
-		mongoFilter["first_name"] = bson.M{"$regex": "^" + f.FirstName, "$options": "i"} // Prefix matching
+		mongoFilter["first_name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(f.FirstName), "$options": "i"} // Prefix matching
 	}
 	if f.LastName != "" {
-		mongoFilter["last_name"] = bson.M{"$regex": "^" + f.LastName, "$options": "i"}
+		mongoFilter["last_name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(f.LastName), "$options": "i"}
 	}
 	if f.NickName != "" {
 		mongoFilter["nick_name"] = f.NickName // Exact match
@@ -73,11 +206,14 @@ func (f *UserFilter) ToBson() bson.M {
 	if f.Country != "" {
 		mongoFilter["country"] = f.Country // Exact match for country code
 	}
-	if f.Status == 0 {
+	if f.defaultsToActive() {
 		mongoFilter["status"] = UserStatus_Active // Set active as default
 	} else if f.Status > 0 {
 		mongoFilter["status"] = f.Status //Set value coming from userFilter
 	}
+	if f.expression != nil {
+		mongoFilter["$and"] = bson.A{f.expression.ToBson()}
+	}
 
 	return mongoFilter
 }
@@ -91,14 +227,118 @@ func (u *User) UserFromProto(pbUser *pbuser.User) {
 	u.Country = pbUser.Country
 	u.Password = pbUser.Password
 	u.Status = UserStatus(pbUser.Status)
+	u.Phone = pbUser.GetPhone()
+	u.CustomAttributes = UserAttributesFromProto(pbUser.GetCustomAttributes())
+}
+
+func (l *UserLookup) UserLookupFromProto(req *pbuser.GetUserRequest) {
+	l.Id = req.GetId()
+	l.Email = req.GetEmail()
+	l.NickName = req.GetNickName()
 }
 
 func (u *UserFilter) UserFilterFromProto(pbFilter *pbuser.UserFilter, pbPagination *pbtypes.List) {
-	u.Status = UserStatus(pbFilter.Status)
-	u.Email = pbFilter.Email
-	u.NickName = pbFilter.NickName
-	u.FirstName = pbFilter.FirstName
-	u.LastName = pbFilter.LastName
-	u.Country = pbFilter.Country
+	u.Status = UserStatus(pbFilter.GetStatus())
+	u.Email = pbFilter.GetEmail()
+	u.NickName = pbFilter.GetNickName()
+	u.FirstName = pbFilter.GetFirstName()
+	u.LastName = pbFilter.GetLastName()
+	u.Country = pbFilter.GetCountry()
+	u.Expression = pbFilter.GetExpression()
+	u.Sort = UserDefaultSort
 	u.Pagination = types.NewPaginationReq(pbPagination.GetOffset(), pbPagination.GetLimit())
+	u.Pagination.PageToken = pbPagination.GetPageToken()
+	u.Pagination.SkipTotal = pbPagination.GetSkipTotal()
+}
+
+// SortFromProto sets the sort order of the filter from order_by.
+// Unknown fields and orders that are not backed by an index are rejected.
+func (u *UserFilter) SortFromProto(orderBy []*pbtypes.OrderBy) error {
+	if len(orderBy) == 0 {
+		u.Sort = UserDefaultSort
+		return nil
+	}
+	if len(orderBy) > types.MaxOrderByFields {
+		return errwrap.NewError(fmt.Sprintf("at most %d order_by fields are allowed", types.MaxOrderByFields), codes.InvalidArgument.String()).
+			SetGrpcCode(codes.InvalidArgument)
+	}
+
+	sort := make(types.Sort, 0, len(orderBy)+1)
+	for _, o := range orderBy {
+		field, ok := UserOrderByFields[o.GetField()]
+		if !ok {
+			return errwrap.NewError("unsupported order_by field "+o.GetField(), codes.InvalidArgument.String()).
+				SetGrpcCode(codes.InvalidArgument)
+		}
+		if slices.ContainsFunc(sort, func(f types.SortField) bool { return f.Field == field }) {
+			return errwrap.NewError("duplicate order_by field "+o.GetField(), codes.InvalidArgument.String()).
+				SetGrpcCode(codes.InvalidArgument)
+		}
+		sort = append(sort, types.SortField{Field: field, Desc: o.GetDesc()})
+	}
+	// _id breaks ties in the direction of the last field
+	sort = append(sort, types.SortField{Field: "_id", Desc: sort[len(sort)-1].Desc})
+
+	equality := u.EqualityFields()
+	if !slices.ContainsFunc(UserSortIndexes, func(index types.Sort) bool { return sort.CoveredBy(index, equality) }) {
+		return errwrap.NewError("order_by combination is not supported with this filter", codes.InvalidArgument.String()).
+			SetGrpcCode(codes.InvalidArgument)
+	}
+
+	u.Sort = sort
+	return nil
+}
+
+// ParseExpression compiles the filter expression against UserFilterSchema and the further fields
+// available to the caller, it must be called before ToBson
+func (u *UserFilter) ParseExpression(fields filter.Schema) error {
+	if u.Expression == "" {
+		return nil
+	}
+
+	schema := UserFilterSchema
+	if len(fields) > 0 {
+		schema = make(filter.Schema, len(UserFilterSchema)+len(fields))
+		maps.Copy(schema, UserFilterSchema)
+		maps.Copy(schema, fields)
+	}
+	expression, err := filter.Parse(u.Expression, schema)
+	if err != nil {
+		return err
+	}
+	u.expression = expression
+	return nil
+}
+
+// defaultsToActive reports whether only active users are listed because status is not filtered otherwise
+func (u *UserFilter) defaultsToActive() bool {
+	return u.Status == UserStatus_Unspecified && (u.expression == nil || !u.expression.References("status"))
+}
+
+// EqualityFields returns the document fields the filter matches exactly, see ToBson
+func (u *UserFilter) EqualityFields() []string {
+	var fields []string
+	if u.Status != UserStatus_Unspecified || u.defaultsToActive() {
+		fields = append(fields, "status")
+	}
+	if u.NickName != "" {
+		fields = append(fields, "nick_name")
+	}
+	if u.Email != "" {
+		fields = append(fields, "email")
+	}
+	if u.Country != "" {
+		fields = append(fields, "country")
+	}
+	return fields
+}
+
+// Fingerprint identifies the filter and order of a list query, page tokens are bound to it
+func (u *UserFilter) Fingerprint() string {
+	query := *u
+	query.Pagination = types.PaginationReq{}
+	// Marshalling a struct of plain fields cannot fail
+	data, _ := json.Marshal(query)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
 }
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// UserField_CustomAttributes is the update mask path of all custom attributes,
+// a single namespace is addressed by custom_attributes.<namespace>
+const UserField_CustomAttributes = "custom_attributes"
+
+// UserAttributes holds the custom attributes of a user keyed by namespace
+type UserAttributes map[string]any
+
+// ToProto converts the attributes to a Struct, nil if there are none
+func (a UserAttributes) ToProto() *structpb.Struct {
+	if len(a) == 0 {
+		return nil
+	}
+
+	attributes, err := structpb.NewStruct(a)
+	if err != nil {
+		// Attributes are validated JSON values, only a document edited by hand gets here
+		slog.Warn("failed to convert custom attributes", slog.Any("error", err))
+		return nil
+	}
+	return attributes
+}
+
+// UserAttributesFromProto converts a Struct to attributes, nil if there are none
+func UserAttributesFromProto(attributes *structpb.Struct) UserAttributes {
+	if len(attributes.GetFields()) == 0 {
+		return nil
+	}
+	return attributes.AsMap()
+}
+
+// UnmarshalBSONValue decodes the attributes into plain maps and slices, like JSON values
+func (a *UserAttributes) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	var attributes bson.M
+	if err := (bson.RawValue{Type: t, Value: data}).Unmarshal(&attributes); err != nil {
+		return err
+	}
+	*a = normalizeAttributes(attributes)
+	return nil
+}
+
+// normalizeAttributes converts the documents and arrays decoded from MongoDB to plain maps and slices
+func normalizeAttributes(a map[string]any) map[string]any {
+	normalized := make(map[string]any, len(a))
+	for key, value := range a {
+		normalized[key] = normalizeAttributeValue(value)
+	}
+	return normalized
+}
+
+func normalizeAttributeValue(value any) any {
+	switch v := value.(type) {
+	case primitive.D:
+		m := make(map[string]any, len(v))
+		for _, e := range v {
+			m[e.Key] = normalizeAttributeValue(e.Value)
+		}
+		return m
+	case primitive.M:
+		return normalizeAttributes(v)
+	case map[string]any:
+		return normalizeAttributes(v)
+	case primitive.A:
+		return normalizeAttributeValue([]any(v))
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = normalizeAttributeValue(e)
+		}
+		return s
+	}
+	return value
+}
@@ -0,0 +1,9 @@
+package model
+
+// UserBatchResult is the outcome of a batch operation for a single user.
+// User is only set on success of operations that return the user.
+type UserBatchResult struct {
+	Id   string
+	User *User
+	Err  error
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	pbuser "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type UserChangeType int
+
+const (
+	UserChange_Unspecified UserChangeType = 0
+	UserChange_Created     UserChangeType = 1
+	UserChange_Updated     UserChangeType = 2
+	UserChange_Deactivated UserChangeType = 3 // the user was left inactive by the change
+	UserChange_Deleted     UserChangeType = 4
+)
+
+// UserChange is a change of a user as emitted by WatchUsers
+type UserChange struct {
+	Type        UserChangeType
+	User        *User
+	ChangeTime  time.Time
+	ResumeToken string
+}
+
+// NewUserChange classifies the change of a user by its state after the change.
+// The password is stripped from the user.
+func NewUserChange(user *User, created bool, resumeToken string) *UserChange {
+	changeType := UserChange_Updated
+	switch {
+	case created:
+		changeType = UserChange_Created
+	case user.Status == UserStatus_Inactive:
+		changeType = UserChange_Deactivated
+	}
+
+	user.Password = ""
+	return &UserChange{
+		Type:        changeType,
+		User:        user,
+		ChangeTime:  user.UpdatedAt,
+		ResumeToken: resumeToken,
+	}
+}
+
+// NewUserDeletion is the change of a deleted user, only the id of the user is known
+func NewUserDeletion(id string, changeTime time.Time, resumeToken string) *UserChange {
+	return &UserChange{
+		Type:        UserChange_Deleted,
+		User:        &User{Id: id},
+		ChangeTime:  changeTime,
+		ResumeToken: resumeToken,
+	}
+}
+
+func (c *UserChange) ToProto() *pbuser.UserChange {
+	return &pbuser.UserChange{
+		Type:        pbuser.UserChangeType(c.Type),
+		User:        c.User.UserToProto(),
+		ChangeTime:  timestamppb.New(c.ChangeTime),
+		ResumeToken: c.ResumeToken,
+	}
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// EmailChange is a requested change of the email of a user, the new address is only set once it is verified
+type EmailChange struct {
+	NewEmail       string    `bson:"new_email"`
+	TokenHash      string    `bson:"token_hash"` // only the hash of the sent token is stored
+	RequestedAt    time.Time `bson:"requested_at"`
+	ExpireTime     time.Time `bson:"expire_time"`
+	RevokeSessions bool      `bson:"revoke_sessions"` // revoke all tokens of the user once the change is confirmed
+}
+
+// EmailRevert lets the previous address undo a confirmed email change until it expires
+type EmailRevert struct {
+	PreviousEmail string    `bson:"previous_email"`
+	TokenHash     string    `bson:"token_hash"` // only the hash of the sent token is stored
+	ExpireTime    time.Time `bson:"expire_time"`
+}
+
+// PendingEmail returns the new email waiting to be verified, empty if there is none or it expired
+func (u *User) PendingEmail() string {
+	if u.EmailChange == nil || time.Now().After(u.EmailChange.ExpireTime) {
+		return ""
+	}
+	return u.EmailChange.NewEmail
+}
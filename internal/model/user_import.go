@@ -0,0 +1,102 @@
+package model
+
+import (
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	pbuser "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
+	"google.golang.org/grpc/codes"
+)
+
+type ImportFormat int
+
+const (
+	ImportFormat_Unspecified ImportFormat = 0
+	ImportFormat_CSV         ImportFormat = 1
+	ImportFormat_JSONL       ImportFormat = 2
+)
+
+// ImportOptions configure an import of users
+type ImportOptions struct {
+	Format ImportFormat
+	DryRun bool // validate the rows without writing any user
+}
+
+// UserInvite lets a user imported without password set one, only the hash of the token is stored
+type UserInvite struct {
+	TokenHash  string    `bson:"token_hash"`
+	ExpireTime time.Time `bson:"expire_time"`
+}
+
+// UserImportRow is a row read from an import input.
+// Err is set instead of User if the row could not be decoded.
+type UserImportRow struct {
+	Line int
+	User *User
+	Err  error
+}
+
+// UserImportResult is the outcome of a single row of an import
+type UserImportResult struct {
+	Line        int
+	Email       string
+	Id          string
+	InviteToken string
+	Err         error
+}
+
+// UserImportReport is the outcome of an import, with a result for every row in input order
+type UserImportReport struct {
+	DryRun  bool
+	Results []*UserImportResult
+}
+
+// Failed counts the rows that were not imported
+func (r *UserImportReport) Failed() int {
+	var failed int
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (o *ImportOptions) ImportOptionsFromProto(pbOptions *pbuser.ImportOptions) {
+	o.Format = ImportFormat(pbOptions.GetFormat())
+	o.DryRun = pbOptions.GetDryRun()
+}
+
+func (r *UserImportReport) ToProto() *pbuser.ImportUsersResponse {
+	failed := r.Failed()
+	resp := &pbuser.ImportUsersResponse{
+		Total:     int32(len(r.Results)),
+		Succeeded: int32(len(r.Results) - failed),
+		Failed:    int32(failed),
+		DryRun:    r.DryRun,
+		Results:   make([]*pbuser.ImportRowResult, 0, len(r.Results)),
+	}
+	for _, result := range r.Results {
+		resp.Results = append(resp.Results, result.ToProto())
+	}
+	return resp
+}
+
+func (r *UserImportResult) ToProto() *pbuser.ImportRowResult {
+	pbResult := &pbuser.ImportRowResult{
+		Line:        int32(r.Line),
+		Email:       r.Email,
+		Id:          r.Id,
+		InviteToken: r.InviteToken,
+	}
+	if r.Err != nil {
+		// Errors of the service carry a gRPC code, anything else is reported as internal
+		code, message := codes.Internal, "internal error"
+		if e, ok := r.Err.(errwrap.IError); ok {
+			code, message = e.GrpcCode(), e.Message()
+		}
+		pbResult.ErrorCode = code.String()
+		pbResult.ErrorMessage = message
+	}
+	return pbResult
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	pbuser "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UserLifecycleTopicPrefix is prepended to the lifecycle event type to build the event bus topic
+const UserLifecycleTopicPrefix = "user.lifecycle."
+
+type UserLifecycleEventType string
+
+const (
+	UserLifecycle_Deactivated UserLifecycleEventType = "deactivated"
+	UserLifecycle_Restored    UserLifecycleEventType = "restored"
+	UserLifecycle_Erased      UserLifecycleEventType = "erased"
+)
+
+// UserStatusChange records who changed the status of a user, when and why
+type UserStatusChange struct {
+	By     string    `bson:"by" json:"by"` // user id of the caller or "service:" and the service principal
+	Time   time.Time `bson:"time" json:"time"`
+	Reason string    `bson:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// UserLifecycleEvent is published on the event bus when a user is deactivated or restored
+type UserLifecycleEvent struct {
+	Type       UserLifecycleEventType `json:"type"`
+	UserID     string                 `json:"user_id"`
+	By         string                 `json:"by"`
+	Reason     string                 `json:"reason,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+// Topic returns the event bus topic of the event
+func (e *UserLifecycleEvent) Topic() string {
+	return UserLifecycleTopicPrefix + string(e.Type)
+}
+
+func (c *UserStatusChange) ToProto() *pbuser.StatusChange {
+	if c == nil {
+		return nil
+	}
+	return &pbuser.StatusChange{
+		By:     c.By,
+		Time:   timestamppb.New(c.Time),
+		Reason: c.Reason,
+	}
+}
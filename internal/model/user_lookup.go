@@ -0,0 +1,67 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/textnorm"
+)
+
+// UserLookupKeys are the normalized email and nickname of a user.
+// They are maintained by the repository on every write and back the unique indexes and lookups,
+// so that emails and nicknames differing only in case or Unicode form belong to a single user.
+type UserLookupKeys struct {
+	Email    string `bson:"email,omitempty"`
+	NickName string `bson:"nick_name,omitempty"`
+
+	// DuplicateOf is the id of the user that kept the email when duplicates were deduplicated.
+	// A deactivated duplicate holds no keys, restoring it fails while the other user has them.
+	DuplicateOf string `bson:"duplicate_of,omitempty"`
+}
+
+// gmailDomains are the domains of Gmail addresses, they are folded to the first one
+var gmailDomains = []string{"gmail.com", "googlemail.com"}
+
+// UserKeyNormalizer computes the lookup keys of users
+type UserKeyNormalizer struct {
+	// FoldGmail ignores dots and "+" suffixes in the local part of Gmail addresses, as Gmail delivers them to the same mailbox
+	FoldGmail bool
+}
+
+// Email returns the lookup key of an email, e.g. "Ahmet.Yilmaz+news@GoogleMail.com"
+// becomes "ahmetyilmaz@gmail.com" with FoldGmail and "ahmet.yilmaz+news@googlemail.com" without.
+func (n UserKeyNormalizer) Email(email string) string {
+	key := textnorm.Key(email)
+	if !n.FoldGmail {
+		return key
+	}
+
+	at := strings.LastIndex(key, "@")
+	if at < 0 {
+		return key
+	}
+	local, domain := key[:at], key[at+1:]
+	for _, gmailDomain := range gmailDomains {
+		if domain == gmailDomain {
+			local, _, _ = strings.Cut(local, "+")
+			return strings.ReplaceAll(local, ".", "") + "@" + gmailDomains[0]
+		}
+	}
+	return key
+}
+
+// NickName returns the lookup key of a nickname, empty for no nickname
+func (n UserKeyNormalizer) NickName(nickName string) string {
+	return textnorm.Key(nickName)
+}
+
+// Refresh recomputes the lookup keys of the user from its current email and nickname
+func (n UserKeyNormalizer) Refresh(u *User) {
+	if u.LookupKeys != nil && u.LookupKeys.DuplicateOf != "" && u.Status == UserStatus_Inactive {
+		u.LookupKeys = &UserLookupKeys{DuplicateOf: u.LookupKeys.DuplicateOf}
+		return
+	}
+	u.LookupKeys = &UserLookupKeys{
+		Email:    n.Email(u.Email),
+		NickName: n.NickName(u.NickName),
+	}
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// PhoneVerification is the pending SMS verification of the phone of a user
+type PhoneVerification struct {
+	CodeHash   string    `bson:"code_hash"` // only the hash of the sent code is stored
+	SentAt     time.Time `bson:"sent_at"`
+	ExpireTime time.Time `bson:"expire_time"`
+	Attempts   int       `bson:"attempts"` // failed attempts to enter the code
+}
+
+// Identifiers returns the email, nickname and phone of the user, which name the user in failed logins
+func (u *User) Identifiers() []string {
+	identifiers := []string{u.Email}
+	if u.NickName != "" {
+		identifiers = append(identifiers, u.NickName)
+	}
+	if u.Phone != "" {
+		identifiers = append(identifiers, u.Phone)
+	}
+	return identifiers
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/textnorm"
+	pbuser "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
+)
+
+// SearchMinTermLength is the shortest search term, shorter words are not indexed as prefixes
+const SearchMinTermLength = 2
+
+// UserSearchKeys holds the normalized, prefix expanded search terms of a user.
+// They are stored with the user document and backed by a text index.
+type UserSearchKeys struct {
+	Names    string `bson:"names"`     // edge grams of first and last name
+	NickName string `bson:"nick_name"` // edge grams of the nickname
+}
+
+// UserSearchHit is a user matching a search query
+type UserSearchHit struct {
+	User       *User
+	Score      float64
+	Highlights []UserHighlight
+}
+
+// UserHighlight marks the matched parts of a user field
+type UserHighlight struct {
+	Field  string
+	Ranges []TextRange
+}
+
+// TextRange is a half-open range of character (rune) offsets
+type TextRange struct {
+	Start int
+	End   int
+}
+
+// searchableFields are the user fields matched by a search, with their values
+func (u *User) searchableFields() []struct{ name, value string } {
+	return []struct{ name, value string }{
+		{UserField_FirstName, u.FirstName},
+		{UserField_LastName, u.LastName},
+		{UserField_NickName, u.NickName},
+	}
+}
+
+// RefreshSearchKeys recomputes the search keys from the current field values
+func (u *User) RefreshSearchKeys() {
+	names := append(textnorm.Words(u.FirstName), textnorm.Words(u.LastName)...)
+	u.SearchKeys = &UserSearchKeys{
+		Names:    strings.Join(textnorm.EdgeGrams(names, SearchMinTermLength), " "),
+		NickName: strings.Join(textnorm.EdgeGrams(textnorm.Words(u.NickName), SearchMinTermLength), " "),
+	}
+}
+
+// SearchTerms returns the normalized terms of a search query
+func SearchTerms(query string) []string {
+	var terms []string
+	for _, word := range textnorm.Words(query) {
+		if len([]rune(word)) >= SearchMinTermLength {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// MatchSearch returns the highlights of a user for the search terms
+// and reports whether every term matches the start of a word of the searchable fields.
+func (u *User) MatchSearch(terms []string) ([]UserHighlight, bool) {
+	matched := make(map[string]bool, len(terms))
+	var highlights []UserHighlight
+	for _, field := range u.searchableFields() {
+		var ranges []TextRange
+		for _, term := range terms {
+			for _, r := range prefixMatches(field.value, term) {
+				ranges = append(ranges, r)
+				matched[term] = true
+			}
+		}
+		if len(ranges) > 0 {
+			highlights = append(highlights, UserHighlight{Field: field.name, Ranges: ranges})
+		}
+	}
+	return highlights, len(matched) == len(terms)
+}
+
+// prefixMatches returns the ranges of the words in text whose normalized form starts with term.
+// Ranges are in runes of the original text, so accents and case are preserved for display.
+func prefixMatches(text string, term string) []TextRange {
+	var ranges []TextRange
+	original := []rune(text)
+	termLength := len([]rune(term))
+
+	for start := 0; start < len(original); {
+		if !isWordRune(original[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(original) && isWordRune(original[end]) {
+			end++
+		}
+
+		word := original[start:end]
+		if strings.HasPrefix(textnorm.Normalize(string(word)), term) {
+			// Cover as many original runes as needed to spell the normalized term
+			covered, n := 0, 0
+			for n < len(word) && covered < termLength {
+				covered += len([]rune(textnorm.Normalize(string(word[n]))))
+				n++
+			}
+			// Keep combining marks of the last covered letter
+			for n < len(word) && unicode.Is(unicode.Mn, word[n]) {
+				n++
+			}
+			ranges = append(ranges, TextRange{Start: start, End: start + n})
+		}
+		start = end
+	}
+	return ranges
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+func (h *UserSearchHit) ToProto() *pbuser.UserSearchResult {
+	highlights := make([]*pbuser.Highlight, 0, len(h.Highlights))
+	for _, highlight := range h.Highlights {
+		ranges := make([]*pbuser.TextRange, 0, len(highlight.Ranges))
+		for _, r := range highlight.Ranges {
+			ranges = append(ranges, &pbuser.TextRange{Start: int32(r.Start), End: int32(r.End)})
+		}
+		highlights = append(highlights, &pbuser.Highlight{Field: highlight.Field, Ranges: ranges})
+	}
+
+	return &pbuser.UserSearchResult{
+		User:       h.User.UserToProto(),
+		Score:      h.Score,
+		Highlights: highlights,
+	}
+}
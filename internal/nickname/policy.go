@@ -0,0 +1,171 @@
+// Package nickname decides which nicknames users can choose.
+package nickname
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"strings"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/textnorm"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	configKeyReservedFile  = "NICKNAME_RESERVED_FILE"
+	configKeyBlocklistFile = "NICKNAME_BLOCKLIST_FILE"
+)
+
+// DefaultReserved are nicknames that could be mistaken for the service or its staff
+var DefaultReserved = []string{
+	"abuse", "admin", "administrator", "anonymous", "api", "everyone", "help", "helpdesk", "hostmaster",
+	"moderator", "noreply", "null", "official", "postmaster", "root", "security", "staff", "support",
+	"sysadmin", "system", "undefined", "webmaster",
+}
+
+// leetFolds maps digits commonly used in place of letters
+var leetFolds = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t")
+
+// Policy rejects reserved nicknames and nicknames containing a blocked word, e.g. profanity.
+// Nicknames are compared folded: case, accents, separators and digits used as letters are ignored,
+// so "Ad_m1n" is the reserved "admin".
+type Policy struct {
+	stack.AbstractProvider
+	reservedFile  string
+	blocklistFile string
+	reserved      map[string]bool
+	blocked       []string
+}
+
+// NewPolicyFromEnv creates a policy loading additional reserved nicknames from NICKNAME_RESERVED_FILE
+// and blocked words from NICKNAME_BLOCKLIST_FILE on Init
+func NewPolicyFromEnv() *Policy {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault(configKeyReservedFile, "")
+	vi.SetDefault(configKeyBlocklistFile, "")
+
+	return NewPolicy(vi.GetString(configKeyReservedFile), vi.GetString(configKeyBlocklistFile))
+}
+
+// NewPolicy creates a policy with the DefaultReserved nicknames, loading more from the files on Init.
+// The files have one word per line, empty lines and lines starting with # are skipped. Empty file names are ignored.
+func NewPolicy(reservedFile, blocklistFile string) *Policy {
+	p := &Policy{
+		reservedFile:  reservedFile,
+		blocklistFile: blocklistFile,
+		reserved:      map[string]bool{},
+	}
+	p.Reserve(DefaultReserved...)
+	return p
+}
+
+// Init loads the reserved nicknames and blocked words of the files
+func (p *Policy) Init() error {
+	reserved, err := readWords(p.reservedFile)
+	if err != nil {
+		return fmt.Errorf("failed to read reserved nicknames: %w", err)
+	}
+	blocked, err := readWords(p.blocklistFile)
+	if err != nil {
+		return fmt.Errorf("failed to read nickname blocklist: %w", err)
+	}
+	p.Reserve(reserved...)
+	p.Block(blocked...)
+
+	slog.Info("Nickname policy loaded.", slog.Int("reserved", len(p.reserved)), slog.Int("blocked", len(p.blocked)))
+	return nil
+}
+
+// Reserve rejects the nicknames. It must be called before the policy is used.
+func (p *Policy) Reserve(nickNames ...string) {
+	for _, nickName := range nickNames {
+		if folded := fold(nickName); folded != "" {
+			p.reserved[folded] = true
+		}
+	}
+}
+
+// Block rejects nicknames containing the words. It must be called before the policy is used.
+func (p *Policy) Block(words ...string) {
+	for _, word := range words {
+		if folded := fold(word); folded != "" {
+			p.blocked = append(p.blocked, folded)
+		}
+	}
+}
+
+// Allowed reports whether the nickname is neither reserved nor contains a blocked word
+func (p *Policy) Allowed(nickName string) bool {
+	folded := fold(nickName)
+	if p.reserved[folded] {
+		return false
+	}
+	for _, word := range p.blocked {
+		if strings.Contains(folded, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate rejects nicknames that are not allowed, an empty nickname is valid
+func (p *Policy) Validate(nickName string) error {
+	if nickName == "" || p.Allowed(nickName) {
+		return nil
+	}
+	return errwrap.NewError("nickname is not allowed", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+}
+
+// Suggestions returns up to count distinct nicknames made of the nickname and a random number,
+// whether they are allowed or taken is not checked
+func Suggestions(nickName string, count int) []string {
+	base := strings.TrimSpace(nickName)
+	seen := map[string]bool{}
+	var suggestions []string
+	for attempt := 0; len(suggestions) < count && attempt < count*4; attempt++ {
+		// Longer numbers once the short ones had their turn
+		limit := 100
+		for range attempt / count {
+			limit *= 10
+		}
+		suggestion := fmt.Sprintf("%s%d", base, rand.IntN(limit))
+		if !seen[suggestion] {
+			seen[suggestion] = true
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
+
+// fold reduces a nickname to its lowercase letters and digits without accents, with digits used as letters replaced
+func fold(s string) string {
+	return strings.Join(textnorm.Words(leetFolds.Replace(s)), "")
+}
+
+// readWords returns the words of a file, one per line, none for an empty path
+func readWords(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	return words, scanner.Err()
+}
@@ -0,0 +1,38 @@
+package nickname
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	dir := t.TempDir()
+	reservedFile := filepath.Join(dir, "reserved.txt")
+	blocklistFile := filepath.Join(dir, "blocklist.txt")
+	require.NoError(t, os.WriteFile(reservedFile, []byte("# brand names\nacme\n\n"), 0o600))
+	require.NoError(t, os.WriteFile(blocklistFile, []byte("darn\n"), 0o600))
+
+	policy := NewPolicy(reservedFile, blocklistFile)
+	require.NoError(t, policy.Init())
+
+	for _, nickName := range []string{"admin", "Ad_m1n", "ADMİN", "acme", "d4rn", "xXdarnXx"} {
+		assert.False(t, policy.Allowed(nickName), nickName)
+	}
+	for _, nickName := range []string{"admiral", "acmefan", "ahmet"} {
+		assert.True(t, policy.Allowed(nickName), nickName)
+	}
+	assert.Error(t, policy.Validate("root"))
+	assert.NoError(t, policy.Validate(""))
+}
+
+func TestSuggestions(t *testing.T) {
+	suggestions := Suggestions(" ahmet ", 5)
+	assert.Len(t, suggestions, 5)
+	for _, suggestion := range suggestions {
+		assert.Regexp(t, `^ahmet\d+$`, suggestion)
+	}
+}
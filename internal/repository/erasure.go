@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/db/mongohandler"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ErasureRepo interface {
+	stack.Provider
+	SaveTombstone(ctx context.Context, tombstone *model.ErasureTombstone) error
+}
+
+type erasureRepository struct {
+	stack.AbstractProvider
+	collection *mongo.Collection
+}
+
+func NewErasureRepo(mongoWrapper *mongohandler.MongoDBWrapper) ErasureRepo {
+	return &erasureRepository{collection: mongoWrapper.Database.Collection("erasure_tombstones")}
+}
+
+// SaveTombstone stores the tombstone of a user, replacing the one of an interrupted earlier purge
+func (r *erasureRepository) SaveTombstone(ctx context.Context, tombstone *model.ErasureTombstone) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": tombstone.UserID}, tombstone, options.Replace().SetUpsert(true))
+	if err != nil {
+		slog.ErrorContext(ctx, "mongo save erasure tombstone error", slog.Any("error", err))
+		return errwrap.ErrInternal.SetMessage("internal error").SetOriginError(err)
+	}
+	return nil
+}
@@ -2,14 +2,31 @@ package repository
 
 type Repository interface {
 	UserRepo
+	SecurityEventRepo
+	ErasureRepo
+	Transactor
 }
 
 type repository struct {
 	UserRepo
+	SecurityEventRepo
+	ErasureRepo
+	Transactor
 }
 
-func New(userRepo UserRepo) Repository {
+func New(userRepo UserRepo, securityEventRepo SecurityEventRepo, erasureRepo ErasureRepo, transactor Transactor) Repository {
 	return &repository{
 		userRepo,
+		securityEventRepo,
+		erasureRepo,
+		transactor,
 	}
 }
+
+// Init is a no-op, every repository is initialized on its own by the stack
+func (r *repository) Init() error {
+	return nil
+}
+
+// Close is a no-op, every repository is closed on its own by the stack
+func (r *repository) Close() {}
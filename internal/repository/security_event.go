@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/db/mongohandler"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+)
+
+type SecurityEventRepo interface {
+	stack.Provider
+	CreateSecurityEvent(ctx context.Context, event *model.SecurityEvent) error
+	ListSecurityEvents(ctx context.Context, filterCriteria bson.M, filter types.PaginationReq) ([]*model.SecurityEvent, int64, error)
+	AnonymizeUserEvents(ctx context.Context, userID string, identifiers []string) error
+	ListUserSecurityEvents(ctx context.Context, userID string, identifiers []string) ([]*model.SecurityEvent, error)
+}
+
+type securityEventRepository struct {
+	stack.AbstractProvider
+	collection *mongo.Collection
+}
+
+func NewSecurityEventRepo(mongoWrapper *mongohandler.MongoDBWrapper) SecurityEventRepo {
+	return &securityEventRepository{collection: mongoWrapper.Database.Collection("security_events")}
+}
+
+// Init mongo collection (indexes etc.)
+func (r *securityEventRepository) Init() error {
+	return r.createIndexes()
+}
+
+// createIndexes creates indexes specific to the SecurityEvent collection
+//
+// Creating TTL index on `expires_at` for retention and query indexes for `user_id` and `type` ordered by time.
+func (r *securityEventRepository) createIndexes() error {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},    // TTL index for retention
+			Options: options.Index().SetExpireAfterSeconds(0), // Expire immediately after expires_at
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "occurred_at", Value: -1}}, // Events of a user, newest first
+		},
+		{
+			Keys: bson.D{{Key: "type", Value: 1}, {Key: "occurred_at", Value: -1}}, // Events of a type, newest first
+		},
+		{
+			Keys: bson.D{{Key: "occurred_at", Value: -1}}, // All events, newest first
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, indexModels)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating indexes for security_events collection", slog.Any("error", err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Indexes created successfully for security_events collection.")
+	return nil
+}
+
+func (r *securityEventRepository) CreateSecurityEvent(ctx context.Context, event *model.SecurityEvent) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	if err != nil {
+		slog.ErrorContext(ctx, "mongo create security event error", slog.Any("error", err), slog.Any("type", event.Type))
+		return errwrap.ErrInternal.SetMessage("internal error").SetOriginError(err)
+	}
+
+	return nil
+}
+
+func (r *securityEventRepository) ListSecurityEvents(ctx context.Context, filterCriteria bson.M, filter types.PaginationReq) ([]*model.SecurityEvent, int64, error) {
+	// Get total count
+	total, err := r.collection.CountDocuments(ctx, filterCriteria)
+	if err != nil {
+		slog.WarnContext(ctx, "mongo list security events count error", slog.Any("error", err), slog.Any("filterCriteria", filterCriteria), slog.Any("pagination", filter))
+		return nil, 0, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	// Newest events first
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "occurred_at", Value: -1}})
+	findOptions.SetLimit(filter.Limit)
+	findOptions.SetSkip(filter.Offset)
+
+	cursor, err := r.collection.Find(ctx, filterCriteria, findOptions)
+	if err != nil {
+		slog.WarnContext(ctx, "mongo list security events find error", slog.Any("error", err), slog.Any("filterCriteria", filterCriteria), slog.Any("pagination", filter))
+		return nil, 0, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []*model.SecurityEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		slog.WarnContext(ctx, "mongo list security events decode error", slog.Any("error", err), slog.Any("filterCriteria", filterCriteria), slog.Any("pagination", filter))
+		return nil, 0, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	return events, total, nil
+}
+
+// AnonymizeUserEvents removes the personal data from the events of a user and from events naming one of
+// its identifiers, like failed logins. The events are kept with their type, time and user id for auditing.
+func (r *securityEventRepository) AnonymizeUserEvents(ctx context.Context, userID string, identifiers []string) error {
+	update := bson.M{"$unset": bson.M{"identifier": "", "device_id": "", "ip_address": "", "user_agent": ""}}
+
+	if _, err := r.collection.UpdateMany(ctx, userEventsFilter(userID, identifiers), update); err != nil {
+		slog.ErrorContext(ctx, "mongo anonymize security events error", slog.Any("error", err))
+		return errwrap.ErrInternal.SetMessage("internal error").SetOriginError(err)
+	}
+	return nil
+}
+
+// ListUserSecurityEvents returns all events of a user and the events naming one of its identifiers, newest first.
+// The events are bounded by their retention, so they are not paginated.
+func (r *securityEventRepository) ListUserSecurityEvents(ctx context.Context, userID string, identifiers []string) ([]*model.SecurityEvent, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, userEventsFilter(userID, identifiers), findOptions)
+	if err != nil {
+		slog.WarnContext(ctx, "mongo list user security events find error", slog.Any("error", err))
+		return nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+	defer cursor.Close(ctx)
+
+	events := []*model.SecurityEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		slog.WarnContext(ctx, "mongo list user security events decode error", slog.Any("error", err))
+		return nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+	return events, nil
+}
+
+// userEventsFilter matches the events of a user and the events naming one of its identifiers, like failed logins
+func userEventsFilter(userID string, identifiers []string) bson.M {
+	or := bson.A{bson.M{"user_id": userID}}
+	if len(identifiers) > 0 {
+		or = append(or, bson.M{"identifier": bson.M{"$in": identifiers}})
+	}
+	return bson.M{"$or": or}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/db/mongohandler"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+)
+
+// Transactor runs repository operations atomically
+type Transactor interface {
+	// WithTransaction runs fn in a transaction. Repository calls made by fn must use the context passed to it.
+	// An error returned by fn aborts the transaction and is returned as is.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type mongoTransactor struct {
+	mongoWrapper *mongohandler.MongoDBWrapper
+}
+
+func NewTransactor(mongoWrapper *mongohandler.MongoDBWrapper) Transactor {
+	return &mongoTransactor{mongoWrapper: mongoWrapper}
+}
+
+// WithTransaction fails with FailedPrecondition on a standalone server, which only reports the missing
+// transaction support on the first operation of fn.
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	supported, err := t.mongoWrapper.SupportsTransactions(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "mongo hello error", slog.Any("error", err))
+		return errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+	if !supported {
+		return errwrap.NewError("transactions are not supported by the database, it must be a replica set", codes.FailedPrecondition.String()).
+			SetGrpcCode(codes.FailedPrecondition)
+	}
+
+	var fnErr error
+	err = t.mongoWrapper.WithTransaction(ctx, func(ctx context.Context) error {
+		fnErr = fn(ctx)
+		return fnErr
+	})
+	if fnErr != nil && errors.Is(err, fnErr) {
+		// Errors of fn are already mapped by the repository
+		return err
+	}
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		// A failed commit
+		slog.WarnContext(ctx, "mongo transaction error", slog.Any("error", err))
+		return errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+	return err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"time"
 
@@ -10,28 +11,50 @@ import (
 	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type UserRepo interface {
 	stack.Provider
 	CreateUser(ctx context.Context, user *model.User) error
+	CreateUsers(ctx context.Context, users []*model.User) ([]error, error)
+	FindUsersByEmailOrNickName(ctx context.Context, emails []string, nickNames []string) ([]*model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
 	GetUserById(ctx context.Context, id string) (*model.User, error)
+	GetUserByNickName(ctx context.Context, nickName string) (*model.User, error)
+	GetUserByPhone(ctx context.Context, phone string) (*model.User, error)
+	GetUserByEmailRevertToken(ctx context.Context, tokenHash string) (*model.User, error)
+	GetUserByInviteToken(ctx context.Context, tokenHash string) (*model.User, error)
+	KeyNormalizer() model.UserKeyNormalizer
+	SetLookupKeys(ctx context.Context, id string, keys *model.UserLookupKeys) error
+	GetUsersByIds(ctx context.Context, ids []string) ([]*model.User, error)
+	ListUsersDueForErasure(ctx context.Context, requestedBefore time.Time, limit int64) ([]*model.User, error)
+	DeleteUserPendingErasure(ctx context.Context, id string) (bool, error)
 	UpdateUser(ctx context.Context, user *model.User) error
-	ListUsers(ctx context.Context, filterCriteria bson.M, filter types.PaginationReq) ([]*model.User, int64, error)
+	ListUsers(ctx context.Context, filterCriteria bson.M, sort types.Sort, filter types.PaginationReq) ([]*model.User, int64, []bson.RawValue, error)
+	StreamUsers(ctx context.Context, filterCriteria bson.M, sort types.Sort, after []bson.RawValue, fn func(user *model.User, key []bson.RawValue) error) error
 }
 
 type userRepository struct {
 	stack.AbstractProvider
 	collection *mongo.Collection
+	keys       model.UserKeyNormalizer
 }
 
 func NewUserRepo(mongoWrapper *mongohandler.MongoDBWrapper) UserRepo {
-	return &userRepository{collection: mongoWrapper.Database.Collection("users")}
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault("USER_EMAIL_FOLD_GMAIL", false)
+
+	return &userRepository{
+		collection: mongoWrapper.Database.Collection("users"),
+		keys:       model.UserKeyNormalizer{FoldGmail: vi.GetBool("USER_EMAIL_FOLD_GMAIL")},
+	}
 }
 
 // Init mongo collection (indexes etc.)
@@ -39,9 +62,14 @@ func (r *userRepository) Init() error {
 	return r.createIndexes()
 }
 
+// legacyIndexes are dropped on start. nick_name_1 was a full unique index, which does not allow clearing nicknames.
+// phone_unique_set made phones unique before they were verified, so a user could hold the number of another one.
+var legacyIndexes = []string{"nick_name_1", "phone_unique_set"}
+
 // createIndexes creates indexes specific to the User collection
 //
-// Creating index for `email`(unique) and `nickName`(unique) and `country`.
+// Creating index for `email`(unique), `nickName` (unique when set), `phone` (unique when verified) and `country`,
+// the normalized lookup keys of email and nickname (unique when set), and the compound indexes of model.UserSortIndexes for sorted and keyset paginated lists.
 func (r *userRepository) createIndexes() error {
 	// Define index models
 	indexModels := []mongo.IndexModel{
@@ -54,14 +82,70 @@ func (r *userRepository) createIndexes() error {
 			Options: options.Index(),                    // Background creation
 		},
 		{
-			Keys:    bson.D{{Key: "nick_name", Value: 1}}, // Ascending index on nickName
-			Options: options.Index().SetUnique(true),      // Unique constraint
+			Keys: bson.D{{Key: "nick_name", Value: 1}}, // Ascending index on nickName
+			// Unique constraint only for set nicknames, so several users can have a cleared one
+			Options: options.Index().
+				SetName("nick_name_unique_set").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"nick_name": bson.M{"$gt": ""}}),
+		},
+		{
+			Keys: bson.D{{Key: "phone", Value: 1}}, // Ascending index on phone
+			// Unique constraint only for verified phones, a number can be entered by several users until one verifies it
+			Options: options.Index().
+				SetName("phone_unique_verified").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"phone_verified": true}),
+		},
+		{
+			Keys: bson.D{{Key: "lookup_keys.email", Value: 1}}, // Case insensitive email uniqueness and lookups
+			// Users stored before the keys were introduced have none until the lookup key migration ran
+			Options: options.Index().
+				SetName("lookup_keys_email_unique_set").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"lookup_keys.email": bson.M{"$gt": ""}}),
+		},
+		{
+			Keys: bson.D{{Key: "lookup_keys.nick_name", Value: 1}}, // Case insensitive nickname uniqueness and lookups
+			Options: options.Index().
+				SetName("lookup_keys_nick_name_unique_set").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"lookup_keys.nick_name": bson.M{"$gt": ""}}),
+		},
+		{
+			Keys: bson.D{{Key: "email_revert.token_hash", Value: 1}}, // Email changes that can be reverted
+			Options: options.Index().
+				SetPartialFilterExpression(bson.M{"email_revert": bson.M{"$exists": true}}),
 		},
+		{
+			Keys: bson.D{{Key: "invite.token_hash", Value: 1}}, // Imported users that can accept their invite
+			Options: options.Index().
+				SetPartialFilterExpression(bson.M{"invite": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "erasure_request.time", Value: 1}}, // Users waiting to be purged
+			Options: options.Index().
+				SetPartialFilterExpression(bson.M{"erasure_request": bson.M{"$exists": true}}),
+		},
+	}
+
+	// Indexes backing the supported sort orders of ListUsers
+	for _, index := range model.UserSortIndexes {
+		indexModels = append(indexModels, mongo.IndexModel{Keys: index.ToBson(), Options: options.Index()})
 	}
 
 	// Create indexes
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+
+	// Drop the legacy indexes, they are missing on fresh databases
+	for _, name := range legacyIndexes {
+		if _, err := r.collection.Indexes().DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+			slog.ErrorContext(ctx, "Error dropping legacy index", slog.String("index", name), slog.Any("error", err))
+			return err
+		}
+	}
+
 	_, err := r.collection.Indexes().CreateMany(ctx, indexModels)
 	if err != nil {
 		slog.ErrorContext(ctx, "Error creating indexes for users collection", slog.Any("error", err))
@@ -74,12 +158,14 @@ func (r *userRepository) createIndexes() error {
 
 // Create a new user
 func (r *userRepository) CreateUser(ctx context.Context, user *model.User) error {
+	user.RefreshSearchKeys()
+	r.keys.Refresh(user)
 	_, err := r.collection.InsertOne(ctx, user)
 
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
-			slog.InfoContext(ctx, "already exists with the same nickname or email.", slog.Any("error", err))
-			return errwrap.ErrConflict.SetMessage("already exists with the same nickname or email")
+			slog.InfoContext(ctx, "already exists with the same nickname, email or phone.", slog.Any("error", err))
+			return errwrap.ErrConflict.SetMessage("already exists with the same nickname, email or phone")
 		}
 		slog.ErrorContext(ctx, "mongo create user error", slog.Any("error", err), slog.Any("user", user))
 		return errwrap.ErrInternal.SetMessage("internal error").SetOriginError(err)
@@ -88,10 +174,80 @@ func (r *userRepository) CreateUser(ctx context.Context, user *model.User) error
 	return nil
 }
 
+// CreateUsers inserts users in a single unordered batch, so a failed user does not stop the others.
+// The returned slice holds the error of each user by index, nil if it was inserted.
+// The error is only set if the batch as a whole failed.
+func (r *userRepository) CreateUsers(ctx context.Context, users []*model.User) ([]error, error) {
+	docs := make([]any, 0, len(users))
+	for _, user := range users {
+		user.RefreshSearchKeys()
+		r.keys.Refresh(user)
+		docs = append(docs, user)
+	}
+
+	errs := make([]error, len(users))
+	_, err := r.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return errs, nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		slog.ErrorContext(ctx, "mongo create users error", slog.Any("error", err), slog.Int("count", len(users)))
+		return nil, errwrap.ErrInternal.SetMessage("internal error").SetOriginError(err)
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Index < 0 || writeErr.Index >= len(errs) {
+			continue
+		}
+		if mongo.IsDuplicateKeyError(writeErr.WriteError) {
+			errs[writeErr.Index] = errwrap.ErrConflict.SetMessage("already exists with the same nickname or email")
+			continue
+		}
+		slog.ErrorContext(ctx, "mongo create users write error", slog.Any("error", writeErr))
+		errs[writeErr.Index] = errwrap.ErrInternal.SetMessage("internal error").SetOriginError(writeErr)
+	}
+	return errs, nil
+}
+
+// FindUsersByEmailOrNickName returns the users having one of the emails or nicknames, compared by their lookup keys
+func (r *userRepository) FindUsersByEmailOrNickName(ctx context.Context, emails []string, nickNames []string) ([]*model.User, error) {
+	emailKeys := make([]string, 0, len(emails))
+	for _, email := range emails {
+		emailKeys = append(emailKeys, r.keys.Email(email))
+	}
+	or := bson.A{bson.M{"lookup_keys.email": bson.M{"$in": emailKeys}}, withoutLookupKeys(bson.M{"email": bson.M{"$in": emails}})}
+	if len(nickNames) > 0 {
+		nickNameKeys := make([]string, 0, len(nickNames))
+		for _, nickName := range nickNames {
+			nickNameKeys = append(nickNameKeys, r.keys.NickName(nickName))
+		}
+		or = append(or,
+			bson.M{"lookup_keys.nick_name": bson.M{"$in": nickNameKeys}},
+			withoutLookupKeys(bson.M{"nick_name": bson.M{"$in": nickNames}}))
+	}
+
+	findOptions := options.Find().SetProjection(bson.M{"email": 1, "nick_name": 1, "lookup_keys": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"$or": or}, findOptions)
+	if err != nil {
+		slog.WarnContext(ctx, "mongo find users by email or nickname error", slog.Any("error", err))
+		return nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	var users []*model.User
+	if err := cursor.All(ctx, &users); err != nil {
+		slog.WarnContext(ctx, "mongo find users by email or nickname decode error", slog.Any("error", err))
+		return nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+	return users, nil
+}
+
+// GetUserByEmail returns the user having the email, ignoring case and Unicode form
 func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
 
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	query := bson.M{"$or": bson.A{bson.M{"lookup_keys.email": r.keys.Email(email)}, withoutLookupKeys(bson.M{"email": email})}}
+	err := r.collection.FindOne(ctx, query).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errwrap.NewError("user not found", codes.NotFound.String()).
@@ -120,16 +276,132 @@ func (r *userRepository) GetUserById(ctx context.Context, id string) (*model.Use
 	return &user, nil
 }
 
+// GetUserByNickName returns the user having the nickname, ignoring case and Unicode form
+func (r *userRepository) GetUserByNickName(ctx context.Context, nickName string) (*model.User, error) {
+	var user model.User
+
+	query := bson.M{"$or": bson.A{bson.M{"lookup_keys.nick_name": r.keys.NickName(nickName)}, withoutLookupKeys(bson.M{"nick_name": nickName})}}
+	err := r.collection.FindOne(ctx, query).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errwrap.NewError("user not found", codes.NotFound.String()).
+				SetGrpcCode(codes.NotFound)
+		}
+		return nil, errwrap.NewError("database error", codes.Internal.String()).
+			SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	return &user, nil
+}
+
+// GetUserByPhone returns the user having verified the phone in E.164 format.
+// Unverified phones are not unique, so they do not identify a user.
+func (r *userRepository) GetUserByPhone(ctx context.Context, phone string) (*model.User, error) {
+	var user model.User
+
+	err := r.collection.FindOne(ctx, bson.M{"phone": phone, "phone_verified": true}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errwrap.NewError("user not found", codes.NotFound.String()).
+				SetGrpcCode(codes.NotFound)
+		}
+		return nil, errwrap.NewError("database error", codes.Internal.String()).
+			SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	return &user, nil
+}
+
+// GetUserByEmailRevertToken returns the user whose email change can be reverted with the token of the hash
+func (r *userRepository) GetUserByEmailRevertToken(ctx context.Context, tokenHash string) (*model.User, error) {
+	var user model.User
+
+	err := r.collection.FindOne(ctx, bson.M{"email_revert.token_hash": tokenHash}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errwrap.NewError("user not found", codes.NotFound.String()).
+				SetGrpcCode(codes.NotFound)
+		}
+		return nil, errwrap.NewError("database error", codes.Internal.String()).
+			SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	return &user, nil
+}
+
+// GetUserByInviteToken returns the imported user that can set its password with the invite token of the hash
+func (r *userRepository) GetUserByInviteToken(ctx context.Context, tokenHash string) (*model.User, error) {
+	var user model.User
+
+	err := r.collection.FindOne(ctx, bson.M{"invite.token_hash": tokenHash}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errwrap.NewError("user not found", codes.NotFound.String()).
+				SetGrpcCode(codes.NotFound)
+		}
+		return nil, errwrap.NewError("database error", codes.Internal.String()).
+			SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	return &user, nil
+}
+
+// withoutLookupKeys restricts an exact match to users stored before the lookup keys were introduced,
+// until the lookup key migration ran
+func withoutLookupKeys(query bson.M) bson.M {
+	query["lookup_keys"] = bson.M{"$exists": false}
+	return query
+}
+
+// KeyNormalizer returns the normalizer of the lookup keys, configured with USER_EMAIL_FOLD_GMAIL
+func (r *userRepository) KeyNormalizer() model.UserKeyNormalizer {
+	return r.keys
+}
+
+// SetLookupKeys only sets the lookup keys of the user, its version is kept
+func (r *userRepository) SetLookupKeys(ctx context.Context, id string, keys *model.UserLookupKeys) error {
+	_, err := r.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"lookup_keys": keys}})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errwrap.NewError("email or nickname already exists", codes.AlreadyExists.String()).
+				SetGrpcCode(codes.AlreadyExists).SetOriginError(err)
+		}
+		return errwrap.NewError("database error", codes.Internal.String()).
+			SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+	return nil
+}
+
+// GetUsersByIds returns the users with the given ids in no particular order, unknown ids are left out
+func (r *userRepository) GetUsersByIds(ctx context.Context, ids []string) ([]*model.User, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		slog.WarnContext(ctx, "mongo get users by ids error", slog.Any("error", err))
+		return nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	var users []*model.User
+	if err := cursor.All(ctx, &users); err != nil {
+		slog.WarnContext(ctx, "mongo get users by ids decode error", slog.Any("error", err))
+		return nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+	return users, nil
+}
+
+// UpdateUser replaces the user if it was not modified since it was read.
+// user.Version must already be incremented by Meta.Update, the stored document is expected to have the previous version.
 func (r *userRepository) UpdateUser(ctx context.Context, user *model.User) error {
+	user.RefreshSearchKeys()
+	r.keys.Refresh(user)
 	result, err := r.collection.ReplaceOne(
 		ctx,
-		bson.M{"_id": user.Id},
+		bson.M{"_id": user.Id, "version": user.Version - 1},
 		user,
 	)
 
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
-			return errwrap.NewError("email or nickname already exists", codes.AlreadyExists.String()).
+			return errwrap.NewError("email, nickname or phone already exists", codes.AlreadyExists.String()).
 				SetGrpcCode(codes.AlreadyExists).SetOriginError(err)
 		}
 		return errwrap.NewError("database error", codes.Internal.String()).
@@ -137,43 +409,184 @@ func (r *userRepository) UpdateUser(ctx context.Context, user *model.User) error
 	}
 
 	if result.MatchedCount == 0 {
-		return errwrap.NewError("user not found", codes.NotFound.String()).
-			SetGrpcCode(codes.NotFound)
+		// Distinguish a missing user from a concurrent modification
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": user.Id}, options.Count().SetLimit(1))
+		if err != nil {
+			return errwrap.NewError("database error", codes.Internal.String()).
+				SetGrpcCode(codes.Internal).SetOriginError(err)
+		}
+		if count == 0 {
+			return errwrap.NewError("user not found", codes.NotFound.String()).
+				SetGrpcCode(codes.NotFound)
+		}
+		return errwrap.NewError("user was modified concurrently, retry with the latest version", codes.Aborted.String()).
+			SetGrpcCode(codes.Aborted)
 	}
 
 	return nil
 }
 
-func (r *userRepository) ListUsers(ctx context.Context, filterCriteria bson.M, filter types.PaginationReq) ([]*model.User, int64, error) {
+// ListUsers returns a page of users matching filterCriteria in the given sort order.
+//
+// With filter.After set, the page starts after that sort key (keyset pagination), otherwise filter.Offset is skipped.
+// One more document than the limit is fetched to tell whether there is a next page,
+// in that case the sort key of the last returned user is returned as nextKey.
+// The total is only counted if filter.SkipTotal is not set.
+func (r *userRepository) ListUsers(ctx context.Context, filterCriteria bson.M, sort types.Sort, filter types.PaginationReq) ([]*model.User, int64, []bson.RawValue, error) {
 	// Get total count
-	total, err := r.collection.CountDocuments(ctx, filterCriteria)
-	if err != nil {
-		slog.WarnContext(ctx, "mongo list users count error", slog.Any("error", err), slog.Any("filterCriteria", filterCriteria), slog.Any("pagination", filter))
-		return nil, 0, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	var total int64
+	if !filter.SkipTotal {
+		var err error
+		total, err = r.collection.CountDocuments(ctx, filterCriteria)
+		if err != nil {
+			slog.WarnContext(ctx, "mongo list users count error", slog.Any("error", err), slog.Any("filterCriteria", filterCriteria), slog.Any("pagination", filter))
+			return nil, 0, nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+		}
+	}
+
+	query := filterCriteria
+	if filter.After != nil {
+		query = bson.M{"$and": bson.A{filterCriteria, sort.After(filter.After)}}
 	}
 
 	// Create find options for pagination
 	findOptions := options.Find()
-	findOptions.SetLimit(filter.Limit)
-	findOptions.SetSkip(filter.Offset)
+	findOptions.SetSort(sort.ToBson())
+	findOptions.SetLimit(filter.Limit + 1)
+	if filter.After == nil {
+		findOptions.SetSkip(filter.Offset)
+	}
 
 	// Execute find with filter
-	cursor, err := r.collection.Find(ctx, filterCriteria, findOptions)
+	cursor, err := r.collection.Find(ctx, query, findOptions)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, 0, errwrap.ErrNotFound.SetMessage("user record not found")
+			return nil, 0, nil, errwrap.ErrNotFound.SetMessage("user record not found")
 		}
 		slog.WarnContext(ctx, "mongo list users find error", slog.Any("error", err), slog.Any("filterCriteria", filterCriteria), slog.Any("pagination", filter))
-		return nil, 0, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+		return nil, 0, nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
 	}
 	defer cursor.Close(ctx)
 
 	// Decode results
+	var docs []bson.Raw
+	if err := cursor.All(ctx, &docs); err != nil {
+		slog.WarnContext(ctx, "mongo list users decode error", slog.Any("error", err), slog.Any("filterCriteria", filterCriteria), slog.Any("pagination", filter))
+		return nil, 0, nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	var nextKey []bson.RawValue
+	if int64(len(docs)) > filter.Limit {
+		docs = docs[:filter.Limit]
+		if nextKey, err = sort.KeyOf(docs[len(docs)-1]); err != nil {
+			slog.WarnContext(ctx, "mongo list users sort key error", slog.Any("error", err))
+			return nil, 0, nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+		}
+	}
+
+	users := make([]*model.User, 0, len(docs))
+	for _, doc := range docs {
+		var user model.User
+		if err := bson.Unmarshal(doc, &user); err != nil {
+			slog.WarnContext(ctx, "mongo list users decode error", slog.Any("error", err))
+			return nil, 0, nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+		}
+		users = append(users, &user)
+	}
+
+	return users, total, nextKey, nil
+}
+
+// streamBatchSize is the number of users fetched per round trip while streaming
+const streamBatchSize = 500
+
+// StreamUsers calls fn for every user matching filterCriteria in the given sort order, starting after the sort key after.
+// Users are read from the cursor one batch at a time, so a slow fn slows down reading instead of buffering.
+// Iteration stops at the first error returned by fn. The key passed to fn is only valid until fn returns.
+func (r *userRepository) StreamUsers(ctx context.Context, filterCriteria bson.M, sort types.Sort, after []bson.RawValue, fn func(user *model.User, key []bson.RawValue) error) error {
+	query := filterCriteria
+	if after != nil {
+		query = bson.M{"$and": bson.A{filterCriteria, sort.After(after)}}
+	}
+
+	findOptions := options.Find().SetSort(sort.ToBson()).SetBatchSize(streamBatchSize)
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		slog.WarnContext(ctx, "mongo stream users find error", slog.Any("error", err), slog.Any("filterCriteria", filterCriteria))
+		return errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user model.User
+		if err := cursor.Decode(&user); err != nil {
+			slog.WarnContext(ctx, "mongo stream users decode error", slog.Any("error", err))
+			return errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+		}
+		key, err := sort.KeyOf(cursor.Current)
+		if err != nil {
+			slog.WarnContext(ctx, "mongo stream users sort key error", slog.Any("error", err))
+			return errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+		}
+		if err := fn(&user, key); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		slog.WarnContext(ctx, "mongo stream users cursor error", slog.Any("error", err))
+		return errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	return nil
+}
+
+// ListUsersDueForErasure returns deactivated users whose erasure was requested before the given time, oldest request first
+func (r *userRepository) ListUsersDueForErasure(ctx context.Context, requestedBefore time.Time, limit int64) ([]*model.User, error) {
+	query := bson.M{
+		"erasure_request.time": bson.M{"$lte": requestedBefore},
+		"status":               model.UserStatus_Inactive,
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "erasure_request.time", Value: 1}}).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		slog.WarnContext(ctx, "mongo list users due for erasure error", slog.Any("error", err))
+		return nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
 	var users []*model.User
 	if err := cursor.All(ctx, &users); err != nil {
-		slog.WarnContext(ctx, "mongo list users decode error", slog.Any("error", err), slog.Any("filterCriteria", filterCriteria), slog.Any("pagination", filter))
-		return nil, 0, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+		slog.WarnContext(ctx, "mongo list users due for erasure decode error", slog.Any("error", err))
+		return nil, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+	return users, nil
+}
+
+// DeleteUserPendingErasure removes the user document if the user still waits for its erasure.
+// It reports false if the user is missing, or was restored in the meantime.
+func (r *userRepository) DeleteUserPendingErasure(ctx context.Context, id string) (bool, error) {
+	filter := bson.M{
+		"_id":             id,
+		"erasure_request": bson.M{"$exists": true},
+		"status":          model.UserStatus_Inactive,
+	}
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		slog.WarnContext(ctx, "mongo delete user error", slog.Any("error", err))
+		return false, errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
 	}
+	return result.DeletedCount > 0, nil
+}
 
-	return users, total, nil
+// isIndexNotFound reports whether a drop index error is caused by a missing index or collection
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		// 27: IndexNotFound, 26: NamespaceNotFound
+		return cmdErr.Code == 27 || cmdErr.Code == 26
+	}
+	return false
 }
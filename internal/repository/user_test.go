@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"google.golang.org/grpc/codes"
+)
+
+func TestUpdateUserOptimisticLock(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name     string
+		matched  int32
+		stored   int32 // documents with the id when nothing matched
+		wantCode codes.Code
+	}{
+		{name: "current version", matched: 1, wantCode: codes.OK},
+		{name: "stale version", stored: 1, wantCode: codes.Aborted},
+		{name: "deleted user", stored: 0, wantCode: codes.NotFound},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			repo := &userRepository{collection: mt.Coll}
+			ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+			var count []bson.D
+			if tt.stored > 0 {
+				count = append(count, bson.D{{Key: "n", Value: tt.stored}})
+			}
+			mt.AddMockResponses(
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: tt.matched}, bson.E{Key: "nModified", Value: tt.matched}),
+				mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, count...),
+			)
+
+			// The user was read at version 2 and updated once
+			user := &model.User{Id: "user-1", FirstName: "Ahmet", Meta: types.Meta{Version: 2}}
+			user.Meta.Update()
+			err := repo.UpdateUser(context.Background(), user)
+
+			replace := mt.GetStartedEvent()
+			require.Equal(mt, "update", replace.CommandName)
+			filter := replace.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("q").Document()
+			assert.Equal(mt, "user-1", filter.Lookup("_id").StringValue())
+			assert.Equal(mt, int32(2), filter.Lookup("version").Int32(), "the replace matches the version that was read")
+
+			if tt.matched == 0 {
+				// Counted to tell a concurrent modification from a missing user
+				assert.Equal(mt, "aggregate", mt.GetStartedEvent().CommandName)
+			}
+			if tt.wantCode == codes.OK {
+				require.NoError(mt, err)
+				return
+			}
+			var wrapped errwrap.IError
+			require.ErrorAs(mt, err, &wrapped)
+			assert.Equal(mt, tt.wantCode, wrapped.GrpcCode())
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
+)
+
+// fieldWeights rank matches like the weights of the Mongo text index
+var fieldWeights = map[string]float64{
+	model.UserField_FirstName: 10,
+	model.UserField_LastName:  10,
+	model.UserField_NickName:  5,
+}
+
+// memoryBackend keeps the indexed users in memory, it is meant for tests and local development
+type memoryBackend struct {
+	stack.AbstractProvider
+	mu    sync.RWMutex
+	users map[string]model.User
+}
+
+// NewMemoryBackend creates an empty in-memory search backend
+func NewMemoryBackend() Backend {
+	return &memoryBackend{users: map[string]model.User{}}
+}
+
+// Index stores a copy of the user
+func (b *memoryBackend) Index(ctx context.Context, user *model.User) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.users[user.Id] = *user
+	return nil
+}
+
+// Search scans all users, scoring each matched word prefix with the weight of its field
+func (b *memoryBackend) Search(ctx context.Context, terms []string, limit int) ([]*model.UserSearchHit, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var hits []*model.UserSearchHit
+	for _, user := range b.users {
+		if user.Status != model.UserStatus_Active {
+			continue
+		}
+		highlights, ok := user.MatchSearch(terms)
+		if !ok {
+			continue
+		}
+
+		var score float64
+		for _, h := range highlights {
+			score += fieldWeights[h.Field] * float64(len(h.Ranges))
+		}
+		hits = append(hits, &model.UserSearchHit{User: &user, Score: score, Highlights: highlights})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].User.Id < hits[j].User.Id
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
@@ -0,0 +1,64 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBackendSearch(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+
+	users := []*model.User{
+		{Id: "1", FirstName: "Ahmet", LastName: "Yılmaz", NickName: "ay", Status: model.UserStatus_Active},
+		{Id: "2", FirstName: "Ahmed", LastName: "Şahin", NickName: "yilmazfan", Status: model.UserStatus_Active},
+		{Id: "3", FirstName: "Mehmet", LastName: "Yılmaz", NickName: "mehmet", Status: model.UserStatus_Active},
+		{Id: "4", FirstName: "Ahmet", LastName: "Yılmaz", NickName: "gone", Status: model.UserStatus_Inactive},
+	}
+	for _, u := range users {
+		require.NoError(t, backend.Index(ctx, u))
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		ids   []string
+	}{
+		{"terms in any order and case, accent folded", "yilmaz AHM", []string{"1", "2"}},
+		{"diacritics in the query", "şah", []string{"2"}},
+		{"every term is required", "mehmet sahin", nil},
+		{"inactive users are not found", "gone", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := backend.Search(ctx, model.SearchTerms(tt.query), DefaultLimit)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, hit := range hits {
+				ids = append(ids, hit.User.Id)
+			}
+			assert.Equal(t, tt.ids, ids)
+		})
+	}
+}
+
+func TestMemoryBackendHighlights(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	require.NoError(t, backend.Index(ctx, &model.User{Id: "1", FirstName: "Ahmet", LastName: "Yılmaz", Status: model.UserStatus_Active}))
+
+	hits, err := backend.Search(ctx, model.SearchTerms("yil ahm"), DefaultLimit)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+
+	assert.Equal(t, []model.UserHighlight{
+		{Field: model.UserField_FirstName, Ranges: []model.TextRange{{Start: 0, End: 3}}},
+		{Field: model.UserField_LastName, Ranges: []model.TextRange{{Start: 0, End: 3}}},
+	}, hits[0].Highlights)
+}
@@ -0,0 +1,153 @@
+package search
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/db/mongohandler"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// searchIndexName is the name of the text index over the search keys
+	searchIndexName = "user_search_text"
+	// searchOverfetch multiplies the limit for the documents read per batch, as some text matches are filtered out
+	searchOverfetch = 3
+	// maxSearchScanned caps the documents read for a single search
+	maxSearchScanned = 1000
+)
+
+// mongoBackend searches the search keys stored with the user documents through a text index.
+// The repository keeps the search keys up to date, so Index has nothing to do.
+type mongoBackend struct {
+	stack.AbstractProvider
+	collection *mongo.Collection
+}
+
+// NewMongoBackend creates a search backend on the users collection
+func NewMongoBackend(mongoWrapper *mongohandler.MongoDBWrapper) Backend {
+	return &mongoBackend{collection: mongoWrapper.Database.Collection("users")}
+}
+
+// Init creates the text index and fills the search keys of users stored before search was introduced
+func (b *mongoBackend) Init() error {
+	if err := b.createIndexes(); err != nil {
+		return err
+	}
+	return b.backfill()
+}
+
+// createIndexes creates the text index over the search keys.
+//
+// Names weigh more than nicknames. The language is "none" to disable stemming and stop words, the keys are already normalized.
+func (b *mongoBackend) createIndexes() error {
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "search_keys.names", Value: "text"}, {Key: "search_keys.nick_name", Value: "text"}},
+		Options: options.Index().
+			SetName(searchIndexName).
+			SetDefaultLanguage("none").
+			SetWeights(bson.D{{Key: "search_keys.names", Value: 10}, {Key: "search_keys.nick_name", Value: 5}}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := b.collection.Indexes().CreateOne(ctx, index); err != nil {
+		slog.ErrorContext(ctx, "Error creating search index for users collection", slog.Any("error", err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Search index created successfully for users collection.")
+	return nil
+}
+
+// backfill computes the search keys of users without them
+func (b *mongoBackend) backfill() error {
+	ctx := context.Background()
+	cursor, err := b.collection.Find(ctx, bson.M{"search_keys": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var count int
+	for cursor.Next(ctx) {
+		var user model.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		user.RefreshSearchKeys()
+		if _, err := b.collection.UpdateByID(ctx, user.Id, bson.M{"$set": bson.M{"search_keys": user.SearchKeys}}); err != nil {
+			return err
+		}
+		count++
+	}
+	if count > 0 {
+		slog.InfoContext(ctx, "Search keys backfilled for users collection.", slog.Int("count", count))
+	}
+	return cursor.Err()
+}
+
+// Index is a no-op, the search keys are written together with the user
+func (b *mongoBackend) Index(ctx context.Context, user *model.User) error {
+	return nil
+}
+
+// Search runs a text search ranked by text score.
+// Every term is quoted as a phrase, which makes it required; as the keys are edge grams a term matches word prefixes.
+// Text matches are checked again with MatchSearch, so the cursor is read in over-fetched batches until limit users
+// matched or it is exhausted, scanning at most maxSearchScanned documents.
+func (b *mongoBackend) Search(ctx context.Context, terms []string, limit int) ([]*model.UserSearchHit, error) {
+	phrases := make([]string, 0, len(terms))
+	for _, term := range terms {
+		phrases = append(phrases, strconv.Quote(term))
+	}
+
+	query := bson.M{
+		"$text":  bson.M{"$search": strings.Join(phrases, " ")},
+		"status": model.UserStatus_Active,
+	}
+	findOptions := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}).
+		SetBatchSize(int32(min(limit*searchOverfetch, maxSearchScanned))).
+		SetLimit(maxSearchScanned)
+
+	cursor, err := b.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		slog.WarnContext(ctx, "mongo search users error", slog.Any("error", err))
+		return nil, errwrap.ErrInternal.SetMessage("database error").SetOriginError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var hits []*model.UserSearchHit
+	for len(hits) < limit && cursor.Next(ctx) {
+		var doc struct {
+			model.User `bson:",inline"`
+			Score      float64 `bson:"score"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			slog.WarnContext(ctx, "mongo search users decode error", slog.Any("error", err))
+			return nil, errwrap.ErrInternal.SetMessage("database error").SetOriginError(err)
+		}
+
+		highlights, ok := doc.User.MatchSearch(terms)
+		if !ok {
+			continue
+		}
+		user := doc.User
+		hits = append(hits, &model.UserSearchHit{User: &user, Score: doc.Score, Highlights: highlights})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, errwrap.ErrInternal.SetMessage("database error").SetOriginError(err)
+	}
+
+	return hits, nil
+}
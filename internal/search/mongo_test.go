@@ -0,0 +1,71 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMongoBackendSearch(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	// Mehmet is a text match of "ahm" that MatchSearch rejects, the term does not start a word
+	ahmet := userDoc(mt, "1", "Ahmet")
+	mehmet := userDoc(mt, "2", "Mehmet")
+	ahmed := userDoc(mt, "3", "Ahmed")
+
+	mt.Run("reads further batches until the limit", func(mt *mtest.T) {
+		backend := &mongoBackend{collection: mt.Coll}
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(1, ns, mtest.FirstBatch, mehmet, ahmet, mehmet),
+			mtest.CreateCursorResponse(1, ns, mtest.NextBatch, mehmet, ahmed, mehmet),
+			mtest.CreateSuccessResponse(), // killCursors
+		)
+
+		hits, err := backend.Search(context.Background(), model.SearchTerms("ahm"), 2)
+		require.NoError(mt, err)
+		assert.Equal(mt, []string{"1", "3"}, hitIds(hits))
+
+		find := mt.GetStartedEvent()
+		require.Equal(mt, "find", find.CommandName)
+		assert.Equal(mt, int32(2*searchOverfetch), find.Command.Lookup("batchSize").Int32())
+		assert.Equal(mt, int64(maxSearchScanned), find.Command.Lookup("limit").Int64())
+		assert.Equal(mt, "getMore", mt.GetStartedEvent().CommandName)
+	})
+
+	mt.Run("exhausted cursor", func(mt *mtest.T) {
+		backend := &mongoBackend{collection: mt.Coll}
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(1, ns, mtest.FirstBatch, ahmet, mehmet),
+			mtest.CreateCursorResponse(0, ns, mtest.NextBatch, mehmet),
+		)
+
+		hits, err := backend.Search(context.Background(), model.SearchTerms("ahm"), 2)
+		require.NoError(mt, err)
+		assert.Equal(mt, []string{"1"}, hitIds(hits))
+	})
+}
+
+// userDoc is an active user as returned by the text search
+func userDoc(mt *mtest.T, id string, firstName string) bson.D {
+	raw, err := bson.Marshal(model.User{Id: id, FirstName: firstName, Status: model.UserStatus_Active})
+	require.NoError(mt, err)
+	var doc bson.D
+	require.NoError(mt, bson.Unmarshal(raw, &doc))
+	return append(doc, bson.E{Key: "score", Value: 1.5})
+}
+
+func hitIds(hits []*model.UserSearchHit) []string {
+	var ids []string
+	for _, hit := range hits {
+		ids = append(ids, hit.User.Id)
+	}
+	return ids
+}
@@ -0,0 +1,27 @@
+package search
+
+import (
+	"context"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Backend finds users by name and nickname.
+//
+// Every term must match the start of a word of the first name, last name or nickname,
+// ignoring case and diacritics. Only active users are returned, ordered by relevance.
+type Backend interface {
+	stack.Provider
+
+	// Index adds or replaces the user in the search index
+	Index(ctx context.Context, user *model.User) error
+
+	// Search returns up to limit users matching all terms, terms must be normalized with model.SearchTerms
+	Search(ctx context.Context, terms []string, limit int) ([]*model.UserSearchHit, error)
+}
@@ -3,16 +3,21 @@ package auth
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"time"
 
+	"github.com/nsaltun/user-service-grpc/internal/model"
 	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/internal/service/security"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/phone"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 )
 
 type AuthService interface {
-	Login(ctx context.Context, email, password string) (string, string, error)
+	Login(ctx context.Context, email, phoneNumber, password string) (string, string, error)
 	Refresh(ctx context.Context, refreshToken string) (string, string, error)
 	Logout(ctx context.Context, userID string) error
 }
@@ -20,56 +25,128 @@ type AuthService interface {
 type auth_service struct {
 	repo       repository.Repository
 	jwtManager *auth.JWTManager
+	events     security.Recorder
 }
 
-func NewAuthService(repo repository.Repository, jwtManager *auth.JWTManager) AuthService {
+func NewAuthService(repo repository.Repository, jwtManager *auth.JWTManager, events security.Recorder) AuthService {
 	return &auth_service{
 		repo:       repo,
 		jwtManager: jwtManager,
+		events:     events,
 	}
 }
 
-func (s *auth_service) Login(ctx context.Context, email, password string) (string, string, error) {
-	// Get user by email
-	user, err := s.repo.GetUserByEmail(ctx, email)
+// Login authenticates the user by email or by verified phone, exactly one of them must be given
+func (s *auth_service) Login(ctx context.Context, email, phoneNumber, password string) (string, string, error) {
+	// Get user by email or phone
+	identifier, user, err := s.findLoginUser(ctx, email, phoneNumber)
 	if err != nil {
+		s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_LoginFailed, Identifier: identifier, Reason: "user not found"})
 		return "", "", errwrap.NewError("user not found", codes.NotFound.String()).SetGrpcCode(codes.NotFound)
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_LoginFailed, UserID: user.Id, Identifier: identifier, Reason: "invalid credentials"})
 		return "", "", errwrap.NewError("invalid credentials", codes.Unauthenticated.String()).SetGrpcCode(codes.Unauthenticated).SetOriginError(err)
 	}
 
+	// Deleted users and users waiting for their erasure must not log back in
+	if user.Status == model.UserStatus_Inactive {
+		s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_LoginFailed, UserID: user.Id, Identifier: identifier, Reason: "user is inactive"})
+		return "", "", errwrap.ErrPermissionDenied.SetMessage("user is inactive")
+	}
+
 	// Generate token pair
 	// TODO: Implement proper device ID management. For now, use a placeholder
 	deviceID := "default"
-	accessToken, refreshToken, err := s.jwtManager.GenerateTokenPair(ctx, user.Id, deviceID)
+	accessToken, refreshToken, err := s.jwtManager.GenerateTokenPair(ctx, user.Id, deviceID, user.Roles)
 	if err != nil {
 		return "", "", errwrap.ErrInternal.SetMessage("failed to generate tokens").SetOriginError(err)
 	}
 
+	s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_LoginSucceeded, UserID: user.Id, Identifier: identifier, DeviceID: deviceID})
 	return accessToken, refreshToken, nil
 }
 
+// findLoginUser returns the user by email, or by phone if no email is given, and the identifier it was found by.
+// An unverified phone is treated like an unknown one.
+func (s *auth_service) findLoginUser(ctx context.Context, email, phoneNumber string) (string, *model.User, error) {
+	if email != "" {
+		user, err := s.repo.GetUserByEmail(ctx, email)
+		return email, user, err
+	}
+
+	normalized, err := phone.Normalize(phoneNumber, "")
+	if err != nil {
+		return phoneNumber, nil, err
+	}
+	user, err := s.repo.GetUserByPhone(ctx, normalized)
+	if err != nil {
+		return normalized, nil, err
+	}
+	if !user.PhoneVerified {
+		return normalized, nil, errwrap.NewError("phone is not verified", codes.NotFound.String()).SetGrpcCode(codes.NotFound)
+	}
+	return normalized, user, nil
+}
+
 func (s *auth_service) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	// Identify the token owner up front, the token is rotated on success
+	claims, parseErr := s.jwtManager.ParseRefreshToken(ctx, refreshToken)
+
 	// Validate refresh token and get new token pair
-	accessToken, newRefreshToken, err := s.jwtManager.RefreshTokens(ctx, refreshToken)
+	accessToken, newRefreshToken, err := s.jwtManager.RefreshTokens(ctx, refreshToken, s.userRoles)
 	if err != nil {
 		if errors.Is(err, auth.ErrInvalidateTokenFailed) {
 			return "", "", errwrap.ErrInternal.SetMessage("error while refreshing token").SetOriginError(err)
 		}
+		// Only a rotated token presented again is reuse, tokens revoked by logout or on another device are not
+		if errors.Is(err, auth.ErrRefreshTokenReused) && parseErr == nil {
+			s.handleRefreshReuse(ctx, claims)
+		}
 		return "", "", errwrap.ErrUnauthenticated.SetMessage(err.Error()).SetOriginError(err)
 	}
 
+	if parseErr == nil {
+		s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_TokenRefreshed, UserID: claims.UserID, DeviceID: claims.DeviceID})
+	}
 	return accessToken, newRefreshToken, nil
 }
 
+// userRoles returns the current roles of a user for the tokens issued on refresh, inactive users get no tokens
+func (s *auth_service) userRoles(ctx context.Context, userID string) ([]string, error) {
+	user, err := s.repo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == model.UserStatus_Inactive {
+		return nil, errors.New("user is inactive")
+	}
+	return user.Roles, nil
+}
+
+// handleRefreshReuse revokes the session of a device whose already rotated refresh token is presented again
+func (s *auth_service) handleRefreshReuse(ctx context.Context, claims *auth.Claims) {
+	s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_RefreshReuseDetected, UserID: claims.UserID, DeviceID: claims.DeviceID})
+
+	now := time.Now()
+	for _, tokenType := range []string{auth.TokenTypeRefresh, auth.TokenTypeAccess} {
+		if err := s.jwtManager.InvalidateToken(ctx, claims.UserID, claims.DeviceID, tokenType, now); err != nil {
+			slog.WarnContext(ctx, "failed to revoke session after refresh token reuse", slog.Any("error", err), slog.String("user_id", claims.UserID))
+			return
+		}
+	}
+
+	s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_SessionRevoked, UserID: claims.UserID, DeviceID: claims.DeviceID, Reason: "refresh token reuse"})
+}
+
 func (s *auth_service) Logout(ctx context.Context, userID string) error {
 	// Invalidate all tokens for the user
 	if err := s.jwtManager.InvalidateUserTokens(ctx, userID); err != nil {
 		return errwrap.ErrInternal.SetMessage("failed to invalidate tokens").SetOriginError(err)
 	}
 
+	s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_LogoutAll, UserID: userID})
 	return nil
 }
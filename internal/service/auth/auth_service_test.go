@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/db/mongohandler"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+)
+
+// fakeRepo returns every user by id without roles, and user by email
+type fakeRepo struct {
+	repository.Repository
+	user *model.User
+}
+
+func (f *fakeRepo) GetUserById(ctx context.Context, id string) (*model.User, error) {
+	return &model.User{Id: id}, nil
+}
+
+func (f *fakeRepo) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	return f.user, nil
+}
+
+// fakeRecorder collects the types of the recorded security events
+type fakeRecorder struct {
+	types []model.SecurityEventType
+}
+
+func (f *fakeRecorder) Record(ctx context.Context, event *model.SecurityEvent) {
+	f.types = append(f.types, event.Type)
+}
+
+func TestRefreshReuseDetection(t *testing.T) {
+	t.Setenv("JWT_TOKEN_MODE", auth.TokenModeOpaque)
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	issuedAt := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+
+	mt.Run("token revoked by logout", func(mt *mtest.T) {
+		svc, events := newTestAuthService(mt)
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		require.NoError(mt, svc.Logout(context.Background(), "user-1"))
+
+		mt.AddMockResponses(
+			refreshTokenResponse(mt, "device-1", issuedAt, nil),
+			refreshTokenResponse(mt, "device-1", issuedAt, nil),
+			invalidationResponse(mt, auth.UserInvalidatedToken{UserID: "user-1", TokenType: auth.TokenTypeRefresh, InvalidatedAt: issuedAt.Add(time.Second)}),
+		)
+		_, _, err := svc.Refresh(context.Background(), "refresh-token")
+		assert.Error(mt, err)
+		assert.Equal(mt, []model.SecurityEventType{model.SecurityEvent_LogoutAll}, events.types, "a revoked token is not reuse")
+	})
+
+	mt.Run("refresh on two devices", func(mt *mtest.T) {
+		svc, events := newTestAuthService(mt)
+		for _, deviceID := range []string{"device-1", "device-2"} {
+			mt.ClearEvents()
+			mt.AddMockResponses(
+				refreshTokenResponse(mt, deviceID, issuedAt, nil),
+				refreshTokenResponse(mt, deviceID, issuedAt, nil),
+				noInvalidationResponse(mt),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+				mtest.CreateSuccessResponse(), // access token
+				mtest.CreateSuccessResponse(), // refresh token
+				mtest.CreateSuccessResponse(), // rotation
+			)
+			_, _, err := svc.Refresh(context.Background(), "refresh-token")
+			require.NoError(mt, err)
+
+			// The rotation on the first device only invalidates refresh tokens of that device
+			mt.GetStartedEvent()
+			mt.GetStartedEvent()
+			lookup := mt.GetStartedEvent()
+			require.Equal(mt, "find", lookup.CommandName)
+			arms, err := lookup.Command.Lookup("filter", "$or").Array().Values()
+			require.NoError(mt, err)
+			require.Len(mt, arms, 2)
+			assert.False(mt, arms[0].Document().Lookup("device_id", "$exists").Boolean(), "the user-wide arm skips device records")
+			assert.Equal(mt, deviceID, arms[1].Document().Lookup("device_id").StringValue())
+
+			mt.GetStartedEvent() // rotation of the opaque token
+			mt.GetStartedEvent() // access token
+			mt.GetStartedEvent() // refresh token
+			rotation := mt.GetStartedEvent()
+			require.Equal(mt, "insert", rotation.CommandName)
+			record := rotation.Command.Lookup("documents").Array().Index(0).Value().Document()
+			assert.Equal(mt, deviceID, record.Lookup("device_id").StringValue())
+			assert.Equal(mt, auth.InvalidationReasonRotated, record.Lookup("reason").StringValue())
+		}
+		assert.Equal(mt, []model.SecurityEventType{model.SecurityEvent_TokenRefreshed, model.SecurityEvent_TokenRefreshed}, events.types)
+	})
+
+	mt.Run("rotated token presented again", func(mt *mtest.T) {
+		svc, events := newTestAuthService(mt)
+		rotatedAt := issuedAt.Add(time.Second)
+		mt.AddMockResponses(
+			refreshTokenResponse(mt, "device-1", issuedAt, &rotatedAt),
+			refreshTokenResponse(mt, "device-1", issuedAt, &rotatedAt),
+			mtest.CreateSuccessResponse(), // refresh token revocation
+			mtest.CreateSuccessResponse(), // access token revocation
+		)
+		_, _, err := svc.Refresh(context.Background(), "refresh-token")
+		assert.Error(mt, err)
+		assert.Equal(mt, []model.SecurityEventType{model.SecurityEvent_RefreshReuseDetected, model.SecurityEvent_SessionRevoked}, events.types)
+	})
+}
+
+func TestLoginRejectsInactiveUser(t *testing.T) {
+	password, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &model.User{Id: "user-1", Email: "user@example.com", Password: string(password), Status: model.UserStatus_Inactive}
+	events := &fakeRecorder{}
+	svc := NewAuthService(&fakeRepo{user: user}, nil, events)
+
+	_, _, err = svc.Login(context.Background(), user.Email, "", "secret")
+	var wrapped errwrap.IError
+	require.ErrorAs(t, err, &wrapped)
+	assert.Equal(t, codes.PermissionDenied, wrapped.GrpcCode())
+	assert.Equal(t, []model.SecurityEventType{model.SecurityEvent_LoginFailed}, events.types)
+}
+
+// newTestAuthService returns the service with an opaque token manager storing its tokens in the mocked database
+func newTestAuthService(mt *mtest.T) (*auth_service, *fakeRecorder) {
+	events := &fakeRecorder{}
+	manager := auth.NewJWTManager(&mongohandler.MongoDBWrapper{Database: mt.DB})
+	return NewAuthService(&fakeRepo{}, manager, events).(*auth_service), events
+}
+
+// refreshTokenResponse is the reply of a lookup finding the opaque refresh token of user-1 on the device
+func refreshTokenResponse(mt *mtest.T, deviceID string, issuedAt time.Time, rotatedAt *time.Time) bson.D {
+	return documentResponse(mt, auth.OpaqueToken{
+		TokenHash: "hash",
+		UserID:    "user-1",
+		DeviceID:  deviceID,
+		TokenType: auth.TokenTypeRefresh,
+		IssuedAt:  issuedAt,
+		ExpiresAt: issuedAt.Add(time.Hour),
+		RotatedAt: rotatedAt,
+	})
+}
+
+// invalidationResponse is the reply of a lookup finding the invalidation record
+func invalidationResponse(mt *mtest.T, invalidation auth.UserInvalidatedToken) bson.D {
+	return documentResponse(mt, invalidation)
+}
+
+// noInvalidationResponse is the reply of a lookup finding no invalidation record
+func noInvalidationResponse(mt *mtest.T) bson.D {
+	return mtest.CreateCursorResponse(0, mt.DB.Name()+".tokens", mtest.FirstBatch)
+}
+
+func documentResponse(mt *mtest.T, v any) bson.D {
+	raw, err := bson.Marshal(v)
+	require.NoError(mt, err)
+	var doc bson.D
+	require.NoError(mt, bson.Unmarshal(raw, &doc))
+	return mtest.CreateCursorResponse(0, mt.DB.Name()+".tokens", mtest.FirstBatch, doc)
+}
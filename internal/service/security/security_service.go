@@ -0,0 +1,117 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/eventbus"
+	middleware "github.com/nsaltun/user-service-grpc/pkg/v1/middleware/grpc"
+	pb "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
+	typesv1 "github.com/nsaltun/user-service-grpc/proto/gen/go/shared/types/v1"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/metadata"
+)
+
+// TopicPrefix is prepended to the event type to build the event bus topic
+const TopicPrefix = "user.security."
+
+// Recorder records security events. Recording never fails the calling operation.
+type Recorder interface {
+	Record(ctx context.Context, event *model.SecurityEvent)
+}
+
+type SecurityEventService interface {
+	Recorder
+	ListSecurityEvents(ctx context.Context, filter *model.SecurityEventFilter) (*pb.ListSecurityEventsResponse, error)
+}
+
+type security struct {
+	repo      repository.Repository
+	publisher eventbus.Publisher
+	retention time.Duration
+}
+
+func NewSecurityEventService(repo repository.Repository, publisher eventbus.Publisher) SecurityEventService {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault("SECURITY_EVENT_RETENTION", "2160h") // 90 days
+
+	return &security{
+		repo:      repo,
+		publisher: publisher,
+		retention: vi.GetDuration("SECURITY_EVENT_RETENTION"),
+	}
+}
+
+// Record persists the event enriched with request details and publishes it to the event bus if one is configured
+func (s *security) Record(ctx context.Context, event *model.SecurityEvent) {
+	now := time.Now().UTC()
+	event.Id = uuid.NewString()
+	event.OccurredAt = now
+	event.ExpiresAt = now.Add(s.retention)
+	enrichFromContext(ctx, event)
+
+	if err := s.repo.CreateSecurityEvent(ctx, event); err != nil {
+		slog.WarnContext(ctx, "failed to persist security event", slog.Any("error", err), slog.Any("type", event.Type))
+	}
+
+	if s.publisher == nil {
+		return
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to marshal security event", slog.Any("error", err), slog.Any("type", event.Type))
+		return
+	}
+	if err := s.publisher.Publish(ctx, TopicPrefix+string(event.Type), data); err != nil {
+		slog.WarnContext(ctx, "failed to publish security event", slog.Any("error", err), slog.Any("type", event.Type))
+	}
+}
+
+func (s *security) ListSecurityEvents(ctx context.Context, filter *model.SecurityEventFilter) (*pb.ListSecurityEventsResponse, error) {
+	events, total, err := s.repo.ListSecurityEvents(ctx, filter.ToBson(), filter.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	pbEvents := make([]*pb.SecurityEvent, 0, len(events))
+	for _, event := range events {
+		pbEvents = append(pbEvents, event.ToProto())
+	}
+
+	return &pb.ListSecurityEventsResponse{
+		Events: pbEvents,
+		Params: &typesv1.Pagination{
+			TotalRecords:  total,
+			HasNext:       (filter.Pagination.Offset + filter.Pagination.Limit) < total,
+			HasPrevious:   filter.Pagination.Offset > 0,
+			CurrentLimit:  filter.Pagination.Limit,
+			CurrentOffset: filter.Pagination.Offset,
+		},
+	}, nil
+}
+
+// enrichFromContext fills device, client address and user agent from the request context
+func enrichFromContext(ctx context.Context, event *model.SecurityEvent) {
+	if event.DeviceID == "" {
+		if deviceID, ok := middleware.GetDeviceID(ctx); ok {
+			event.DeviceID = deviceID
+		}
+	}
+
+	event.IPAddress = middleware.GetClientIP(ctx)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, key := range []string{"grpcgateway-user-agent", "user-agent"} {
+		if values := md.Get(key); len(values) > 0 {
+			event.UserAgent = values[0]
+			break
+		}
+	}
+}
@@ -1,28 +1,69 @@
 package service
 
 import (
+	"github.com/nsaltun/user-service-grpc/internal/attributes"
+	"github.com/nsaltun/user-service-grpc/internal/nickname"
 	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/internal/search"
 	"github.com/nsaltun/user-service-grpc/internal/service/auth"
+	"github.com/nsaltun/user-service-grpc/internal/service/security"
 	"github.com/nsaltun/user-service-grpc/internal/service/user"
+	"github.com/nsaltun/user-service-grpc/internal/watch"
 	jwtauth "github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/blobstore"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/eventbus"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/mail"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/sms"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
 )
 
 type Service interface {
 	user.UserService
 	auth.AuthService
+	security.SecurityEventService
 }
 
 type service struct {
 	repo repository.Repository
 	user.UserService
 	auth.AuthService
+	security.SecurityEventService
 }
 
-func NewService(repo repository.Repository, jwtManager *jwtauth.JWTManager) Service {
+// Deps are the collaborators shared by the services
+type Deps struct {
+	Repo       repository.Repository
+	JWTManager *jwtauth.JWTManager
+	Publisher  eventbus.Publisher
+	PageTokens *types.PageTokenCodec
+	Search     search.Backend
+	Watcher    watch.Watcher
+	Blobs      blobstore.Store
+	Attributes *attributes.Registry
+	Nicknames  *nickname.Policy
+	SMS        sms.Sender
+	Mail       mail.Sender
+}
+
+func NewService(deps Deps, userConfig user.Config) Service {
 	svc := &service{
-		repo: repo,
+		repo: deps.Repo,
 	}
-	svc.UserService = user.NewUserService(repo)
-	svc.AuthService = auth.NewAuthService(repo, jwtManager)
+	svc.SecurityEventService = security.NewSecurityEventService(deps.Repo, deps.Publisher)
+	svc.UserService = user.NewUserService(user.Deps{
+		Repo:       deps.Repo,
+		Events:     svc.SecurityEventService,
+		Tokens:     deps.JWTManager,
+		PageTokens: deps.PageTokens,
+		Search:     deps.Search,
+		Watcher:    deps.Watcher,
+		Publisher:  deps.Publisher,
+		Blobs:      deps.Blobs,
+		Attributes: deps.Attributes,
+		Nicknames:  deps.Nicknames,
+		SMS:        deps.SMS,
+		Mail:       deps.Mail,
+	}, userConfig)
+	svc.AuthService = auth.NewAuthService(deps.Repo, deps.JWTManager, svc.SecurityEventService)
 	return svc
 }
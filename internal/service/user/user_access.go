@@ -0,0 +1,50 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	middleware "github.com/nsaltun/user-service-grpc/pkg/v1/middleware/grpc"
+)
+
+// isAdmin reports whether the caller has the admin role, from its access token
+// or from the roles configured for its service principal
+func isAdmin(ctx context.Context) bool {
+	return middleware.HasRole(ctx, auth.RoleAdmin)
+}
+
+// isAdminOrService reports whether the caller is an admin or an internal service principal
+func isAdminOrService(ctx context.Context) bool {
+	_, isService := middleware.GetServicePrincipal(ctx)
+	return isService || isAdmin(ctx)
+}
+
+// requireAdmin fails with PermissionDenied unless the caller is an admin
+func requireAdmin(ctx context.Context) error {
+	if !isAdmin(ctx) {
+		return errwrap.ErrPermissionDenied.SetMessage("admin role is required")
+	}
+	return nil
+}
+
+// requireSelfOrAdmin fails with PermissionDenied unless the caller is the user itself or an admin
+func requireSelfOrAdmin(ctx context.Context, id string) error {
+	if callerID, ok := middleware.GetUserID(ctx); ok && callerID == id {
+		return nil
+	}
+	return requireAdmin(ctx)
+}
+
+// visibleUser returns the user as the caller may see it. Private fields are only shown to the user itself,
+// admins and internal service principals.
+func visibleUser(ctx context.Context, user *model.User) *model.User {
+	if callerID, ok := middleware.GetUserID(ctx); ok && callerID == user.Id {
+		return user
+	}
+	if isAdminOrService(ctx) {
+		return user
+	}
+	return user.WithoutPrivateFields()
+}
@@ -0,0 +1,187 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// MaxBatchGetSize limits the ids of BatchGetUsers
+	MaxBatchGetSize = 1000
+	// MaxBatchWriteSize limits the ids of batch updates and deletes
+	MaxBatchWriteSize = 500
+)
+
+// batchItemError aborts the transaction of an all-or-nothing batch, the error itself is in the item result.
+// It wraps the item error with a single Unwrap, which the driver follows to find a TransientTransactionError
+// label and retry the transaction.
+type batchItemError struct {
+	err error
+}
+
+func (e *batchItemError) Error() string {
+	return "batch item failed: " + e.err.Error()
+}
+
+func (e *batchItemError) Unwrap() error {
+	return e.err
+}
+
+// afterCommitKey holds the side effects of a transaction of batchWrite, they run once it is committed
+type afterCommitKey struct{}
+
+// afterCommit runs fn right away, or after the commit within a transaction of batchWrite. Side effects of
+// an aborted or retried transaction are dropped. fn gets the context the transaction was started with.
+func afterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if pending, ok := ctx.Value(afterCommitKey{}).(*[]func(ctx context.Context)); ok {
+		*pending = append(*pending, fn)
+		return
+	}
+	fn(ctx)
+}
+
+// BatchGetUsers returns a result for every id in request order, NotFound for unknown ids.
+// Like the other batch operations it is reserved for admins.
+func (s *user) BatchGetUsers(ctx context.Context, ids []string) ([]*model.UserBatchResult, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateBatchIds(ids, MaxBatchGetSize); err != nil {
+		return nil, err
+	}
+
+	users, err := s.repo.GetUsersByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]*model.User, len(users))
+	for _, user := range users {
+		byId[user.Id] = user
+	}
+
+	results := make([]*model.UserBatchResult, 0, len(ids))
+	for _, id := range ids {
+		result := &model.UserBatchResult{Id: id, User: byId[id]}
+		if result.User == nil {
+			result.Err = errwrap.NewError("user not found", codes.NotFound.String()).SetGrpcCode(codes.NotFound)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// BatchUpdateUserStatus sets the status of every user like UpdateUserById with a status update mask
+func (s *user) BatchUpdateUserStatus(ctx context.Context, ids []string, status model.UserStatus, allOrNothing bool) ([]*model.UserBatchResult, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateBatchIds(ids, MaxBatchWriteSize); err != nil {
+		return nil, err
+	}
+	if status == model.UserStatus_Unspecified {
+		return nil, errwrap.NewError("status cannot be unspecified", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	update := &model.User{Status: status}
+	return s.batchWrite(ctx, ids, allOrNothing, func(ctx context.Context, id string) (*model.User, error) {
+		return s.UpdateUserById(ctx, id, update, []string{model.UserField_Status}, nil)
+	})
+}
+
+// BatchDeleteUsers soft deletes every user like DeleteUser
+func (s *user) BatchDeleteUsers(ctx context.Context, ids []string, reason string, allOrNothing bool) ([]*model.UserBatchResult, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateBatchIds(ids, MaxBatchWriteSize); err != nil {
+		return nil, err
+	}
+
+	return s.batchWrite(ctx, ids, allOrNothing, func(ctx context.Context, id string) (*model.User, error) {
+		return nil, s.DeleteUser(ctx, id, reason)
+	})
+}
+
+// batchWrite applies fn to every id and collects the results in request order.
+//
+// Without allOrNothing every id is handled on its own. With allOrNothing all ids are handled in a
+// transaction, which is aborted at the first failed id. That id keeps its error, the others are Aborted.
+// The search index, security events and lifecycle events of the users are only updated after the commit.
+func (s *user) batchWrite(ctx context.Context, ids []string, allOrNothing bool, fn func(ctx context.Context, id string) (*model.User, error)) ([]*model.UserBatchResult, error) {
+	if !allOrNothing {
+		results := make([]*model.UserBatchResult, 0, len(ids))
+		for _, id := range ids {
+			user, err := fn(ctx, id)
+			results = append(results, &model.UserBatchResult{Id: id, User: user, Err: err})
+		}
+		return results, nil
+	}
+
+	var results []*model.UserBatchResult
+	var pending []func(ctx context.Context)
+	txCtx := context.WithValue(ctx, afterCommitKey{}, &pending)
+	err := s.repo.WithTransaction(txCtx, func(ctx context.Context) error {
+		// The transaction may be retried, so the results start over
+		results = make([]*model.UserBatchResult, 0, len(ids))
+		pending = nil
+		for _, id := range ids {
+			user, err := fn(ctx, id)
+			results = append(results, &model.UserBatchResult{Id: id, User: user, Err: err})
+			if err != nil {
+				return &batchItemError{err: err}
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		for _, fn := range pending {
+			fn(ctx)
+		}
+		return results, nil
+	}
+	var itemErr *batchItemError
+	if !errors.As(err, &itemErr) {
+		return nil, err
+	}
+
+	failed := results[len(results)-1]
+	aborted := errwrap.NewError(fmt.Sprintf("not applied, the batch failed at user %s", failed.Id), codes.Aborted.String()).
+		SetGrpcCode(codes.Aborted)
+	all := make([]*model.UserBatchResult, 0, len(ids))
+	for _, id := range ids {
+		if id == failed.Id {
+			all = append(all, failed)
+			continue
+		}
+		all = append(all, &model.UserBatchResult{Id: id, Err: aborted})
+	}
+	return all, nil
+}
+
+// validateBatchIds checks the size of a batch and rejects empty and duplicate ids
+func validateBatchIds(ids []string, max int) error {
+	if len(ids) == 0 {
+		return errwrap.NewError("ids are required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+	if len(ids) > max {
+		return errwrap.NewError(fmt.Sprintf("at most %d ids are allowed per batch", max), codes.InvalidArgument.String()).
+			SetGrpcCode(codes.InvalidArgument)
+	}
+
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" {
+			return errwrap.NewError("ids cannot be empty", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		}
+		if seen[id] {
+			return errwrap.NewError("duplicate id "+id, codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		}
+		seen[id] = true
+	}
+	return nil
+}
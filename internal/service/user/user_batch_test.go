@@ -0,0 +1,161 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+)
+
+func (f *fakeRepo) GetUsersByIds(ctx context.Context, ids []string) ([]*model.User, error) {
+	var users []*model.User
+	for _, id := range ids {
+		if id == f.user.Id {
+			user, _ := f.GetUserById(ctx, id)
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func TestBatchOperationsRequireAdmin(t *testing.T) {
+	repo := &fakeRepo{user: model.User{Id: "user-1", Status: model.UserStatus_Active}}
+	svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend()})
+	ctx := callerContext("user-1")
+
+	_, err := svc.BatchGetUsers(ctx, []string{"user-1"})
+	assertCode(t, err, codes.PermissionDenied)
+	_, err = svc.BatchUpdateUserStatus(ctx, []string{"user-1"}, model.UserStatus_Inactive, false)
+	assertCode(t, err, codes.PermissionDenied)
+	_, err = svc.BatchDeleteUsers(ctx, []string{"user-1"}, "spam", false)
+	assertCode(t, err, codes.PermissionDenied)
+	assert.Equal(t, model.UserStatus_Active, repo.user.Status)
+
+	results, err := svc.BatchGetUsers(callerContext("admin-1", auth.RoleAdmin), []string{"user-1", "user-2"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "user-1", results[0].User.Id)
+	assertCode(t, results[1].Err, codes.NotFound)
+
+	results, err = svc.BatchDeleteUsers(callerContext("admin-1", auth.RoleAdmin), []string{"user-1"}, "spam", false)
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, model.UserStatus_Inactive, repo.user.Status)
+}
+
+// fakeTxRepo runs transactions like the driver: a failed transaction is rolled back and retried
+// while its error, unwrapped one level at a time, has the TransientTransactionError label
+type fakeTxRepo struct {
+	*fakeRepo
+	// updateErrs are returned by the next calls of UpdateUser
+	updateErrs    []error
+	attempts      int
+	inTransaction bool
+}
+
+func (f *fakeTxRepo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	for {
+		f.attempts++
+		snapshot := f.user
+		f.inTransaction = true
+		err := fn(ctx)
+		f.inTransaction = false
+		if err == nil {
+			return nil
+		}
+		f.user = snapshot
+		if !hasErrorLabel(err, "TransientTransactionError") {
+			return err
+		}
+	}
+}
+
+func (f *fakeTxRepo) UpdateUser(ctx context.Context, user *model.User) error {
+	if len(f.updateErrs) > 0 {
+		err := f.updateErrs[0]
+		f.updateErrs = f.updateErrs[1:]
+		return err
+	}
+	return f.fakeRepo.UpdateUser(ctx, user)
+}
+
+func hasErrorLabel(err error, label string) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if labeled, ok := err.(mongo.LabeledError); ok && labeled.HasErrorLabel(label) {
+			return true
+		}
+	}
+	return false
+}
+
+// commitSideEffects records the indexed users and published events, and whether any ran within the transaction
+type commitSideEffects struct {
+	search.Backend
+	repo          *fakeTxRepo
+	indexed       []string
+	published     []string
+	inTransaction bool
+}
+
+func (c *commitSideEffects) Index(ctx context.Context, user *model.User) error {
+	c.inTransaction = c.inTransaction || c.repo.inTransaction
+	c.indexed = append(c.indexed, user.Id)
+	return nil
+}
+
+func (c *commitSideEffects) Publish(ctx context.Context, topic string, data []byte) error {
+	c.inTransaction = c.inTransaction || c.repo.inTransaction
+	c.published = append(c.published, topic)
+	return nil
+}
+
+func TestBatchWriteAllOrNothing(t *testing.T) {
+	// A write conflict mapped by the repository, the driver retries the transaction on its label
+	writeConflict := errwrap.NewError("database error", codes.Internal.String()).SetGrpcCode(codes.Internal).
+		SetOriginError(mongo.CommandError{Code: 112, Name: "WriteConflict", Labels: []string{"TransientTransactionError"}})
+	conflict := errwrap.NewError("email, nickname or phone already exists", codes.AlreadyExists.String()).SetGrpcCode(codes.AlreadyExists)
+
+	tests := []struct {
+		name       string
+		updateErrs []error
+		attempts   int
+		code       codes.Code
+	}{
+		{"committed", nil, 1, codes.OK},
+		{"retried after a transient error", []error{writeConflict}, 2, codes.OK},
+		{"aborted", []error{conflict}, 1, codes.AlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeTxRepo{fakeRepo: &fakeRepo{user: model.User{Id: "user-1", Status: model.UserStatus_Active}}, updateErrs: tt.updateErrs}
+			effects := &commitSideEffects{Backend: search.NewMemoryBackend(), repo: repo}
+			svc := newTestService(Deps{Repo: repo, Search: effects, Publisher: effects})
+
+			results, err := svc.BatchDeleteUsers(callerContext("admin-1", auth.RoleAdmin), []string{"user-1"}, "spam", true)
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, tt.attempts, repo.attempts)
+			assert.False(t, effects.inTransaction, "side effects run after the commit")
+
+			if tt.code != codes.OK {
+				assertCode(t, results[0].Err, tt.code)
+				assert.Equal(t, model.UserStatus_Active, repo.user.Status)
+				assert.Empty(t, effects.indexed)
+				assert.Empty(t, effects.published)
+				return
+			}
+			require.NoError(t, results[0].Err)
+			assert.Equal(t, model.UserStatus_Inactive, repo.user.Status)
+			assert.Equal(t, []string{"user-1"}, effects.indexed, "a retried transaction indexes once")
+			assert.Len(t, effects.published, 1)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"google.golang.org/grpc/codes"
+)
+
+// ExportUserData gathers everything stored about the user for a right of access request.
+// Only admins and the user itself may export the data.
+func (s *user) ExportUserData(ctx context.Context, id string) (*model.UserDataExport, error) {
+	if err := requireSelfOrAdmin(ctx, id); err != nil {
+		return nil, err
+	}
+
+	existingUser, err := s.repo.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	opaqueTokens, invalidations, err := s.tokens.ListUserTokens(ctx, id)
+	if err != nil {
+		return nil, errwrap.ErrInternal.SetMessage("failed to list tokens").SetOriginError(err)
+	}
+
+	events, err := s.repo.ListUserSecurityEvents(ctx, id, existingUser.Identifiers())
+	if err != nil {
+		return nil, err
+	}
+
+	export := &model.UserDataExport{
+		Version:            model.DataExportVersion,
+		GeneratedAt:        time.Now().UTC(),
+		User:               existingUser,
+		Sessions:           make([]*model.ExportedSession, 0, len(opaqueTokens)),
+		TokenInvalidations: make([]*model.ExportedTokenInvalidation, 0, len(invalidations)),
+		SecurityEvents:     events,
+	}
+	for _, token := range opaqueTokens {
+		export.Sessions = append(export.Sessions, &model.ExportedSession{
+			TokenID:   token.TokenID,
+			DeviceID:  token.DeviceID,
+			TokenType: token.TokenType,
+			IssuedAt:  token.IssuedAt,
+			ExpiresAt: token.ExpiresAt,
+		})
+	}
+	for _, invalidation := range invalidations {
+		export.TokenInvalidations = append(export.TokenInvalidations, &model.ExportedTokenInvalidation{
+			DeviceID:      invalidation.DeviceID,
+			TokenType:     invalidation.TokenType,
+			InvalidatedAt: invalidation.InvalidatedAt,
+			ExpiresAt:     invalidation.ExpiresAt,
+		})
+	}
+
+	return export, nil
+}
+
+// StoreUserDataExport writes the export as JSON to the blob store and returns its key
+func (s *user) StoreUserDataExport(ctx context.Context, export *model.UserDataExport) (string, error) {
+	if s.blobs == nil {
+		return "", errwrap.NewError("no blob store is configured", codes.FailedPrecondition.String()).SetGrpcCode(codes.FailedPrecondition)
+	}
+
+	data, err := json.Marshal(export)
+	if err != nil {
+		return "", errwrap.ErrInternal.SetMessage("failed to marshal data export").SetOriginError(err)
+	}
+	key := export.BlobKey()
+	if err := s.blobs.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		slog.ErrorContext(ctx, "failed to store data export", slog.Any("error", err), slog.String("key", key))
+		return "", errwrap.ErrInternal.SetMessage("failed to store data export").SetOriginError(err)
+	}
+
+	return key, nil
+}
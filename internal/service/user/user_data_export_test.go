@@ -0,0 +1,39 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func (f *fakeRepo) ListUserSecurityEvents(ctx context.Context, userID string, identifiers []string) ([]*model.SecurityEvent, error) {
+	return []*model.SecurityEvent{{Type: model.SecurityEvent_LoginSucceeded, UserID: userID}}, nil
+}
+
+func (f *fakeTokens) ListUserTokens(ctx context.Context, userID string) ([]auth.OpaqueToken, []auth.UserInvalidatedToken, error) {
+	return []auth.OpaqueToken{{UserID: userID, TokenID: "token-1"}}, nil, nil
+}
+
+func TestExportUserDataRequiresSelfOrAdmin(t *testing.T) {
+	repo := &fakeRepo{user: model.User{Id: "user-1", Email: "user@example.com"}}
+	svc := newTestService(Deps{Repo: repo, Tokens: &fakeTokens{}, Search: search.NewMemoryBackend()})
+
+	_, err := svc.ExportUserData(callerContext("user-2"), "user-1")
+	assertCode(t, err, codes.PermissionDenied)
+	_, err = svc.ExportUserData(context.Background(), "user-1")
+	assertCode(t, err, codes.PermissionDenied)
+
+	for _, ctx := range []context.Context{callerContext("user-1"), callerContext("admin-1", auth.RoleAdmin)} {
+		export, err := svc.ExportUserData(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", export.User.Id)
+		assert.Len(t, export.Sessions, 1)
+		assert.Len(t, export.SecurityEvents, 1)
+	}
+}
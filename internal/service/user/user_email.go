@@ -0,0 +1,180 @@
+package user
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/crypt"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/mail"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// emailTokenSize is the number of random bytes of email change tokens
+	emailTokenSize = 32
+	// emailChangeDuration is how long the new address can be confirmed
+	emailChangeDuration = 24 * time.Hour
+	// emailChangeResendInterval is the least time between two confirmation emails sent to a user
+	emailChangeResendInterval = time.Minute
+	// emailRevertDuration is how long the previous address can revert a confirmed change
+	emailRevertDuration = 7 * 24 * time.Hour
+)
+
+// emailChangeLinks are the URLs of the confirmation and revert links, the token is appended to them
+type emailChangeLinks struct {
+	confirm string
+	revert  string
+}
+
+// RequestEmailChange sends a confirmation link to the new email of the user and returns its expire time.
+// The current password is required. A new request replaces the previous one.
+func (s *user) RequestEmailChange(ctx context.Context, id string, newEmail string, password string, revokeSessions bool) (time.Time, error) {
+	if s.mail == nil {
+		return time.Time{}, errwrap.NewError("email change is not available", codes.FailedPrecondition.String()).
+			SetGrpcCode(codes.FailedPrecondition)
+	}
+
+	existingUser, err := s.repo.GetUserById(ctx, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(password)); err != nil {
+		return time.Time{}, errwrap.NewError("password is incorrect", codes.PermissionDenied.String()).SetGrpcCode(codes.PermissionDenied)
+	}
+	if newEmail == existingUser.Email {
+		return time.Time{}, errwrap.NewError("new email is the current email", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	now := time.Now().UTC()
+	if pending := existingUser.EmailChange; pending != nil && now.Before(pending.RequestedAt.Add(emailChangeResendInterval)) {
+		return time.Time{}, errwrap.NewError("an email was sent recently, try again later", codes.ResourceExhausted.String()).
+			SetGrpcCode(codes.ResourceExhausted)
+	}
+
+	// Emails are compared by their lookup keys, a user may change the case of its own email
+	if found, err := s.repo.GetUserByEmail(ctx, newEmail); err == nil && found.Id != existingUser.Id {
+		return time.Time{}, errwrap.NewError("email already exists", codes.AlreadyExists.String()).SetGrpcCode(codes.AlreadyExists)
+	} else if err != nil && !isNotFound(err) {
+		return time.Time{}, err
+	}
+
+	token, err := crypt.NewToken(emailTokenSize)
+	if err != nil {
+		return time.Time{}, errwrap.ErrInternal.SetMessage("failed to generate email change token").SetOriginError(err)
+	}
+	existingUser.EmailChange = &model.EmailChange{
+		NewEmail:       newEmail,
+		TokenHash:      crypt.HashToken(token),
+		RequestedAt:    now,
+		ExpireTime:     now.Add(emailChangeDuration),
+		RevokeSessions: revokeSessions,
+	}
+	existingUser.Meta.Update()
+	if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
+		return time.Time{}, err
+	}
+
+	message := mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Open the link below within %d hours to use this address for your account:\n%s%s\n",
+			int(emailChangeDuration.Hours()), s.emailLinks.confirm, token),
+	}
+	if err := s.mail.Send(ctx, message); err != nil {
+		slog.ErrorContext(ctx, "failed to send email change confirmation", slog.String("user_id", id), slog.Any("error", err))
+		return time.Time{}, errwrap.NewError("failed to send confirmation email", codes.Unavailable.String()).
+			SetGrpcCode(codes.Unavailable).SetOriginError(err)
+	}
+
+	return existingUser.EmailChange.ExpireTime, nil
+}
+
+// ConfirmEmailChange replaces the email of the user with the requested new address if the token matches.
+// The previous address is notified with a link to revert the change, and the sessions are revoked if requested.
+func (s *user) ConfirmEmailChange(ctx context.Context, id string, token string) (*model.User, error) {
+	existingUser, err := s.repo.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pending := existingUser.EmailChange
+	if pending == nil || time.Now().After(pending.ExpireTime) {
+		return nil, errwrap.NewError("no valid email change, request a new one", codes.FailedPrecondition.String()).
+			SetGrpcCode(codes.FailedPrecondition)
+	}
+	if subtle.ConstantTimeCompare([]byte(crypt.HashToken(token)), []byte(pending.TokenHash)) != 1 {
+		return nil, errwrap.NewError("invalid email change token", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	revertToken, err := crypt.NewToken(emailTokenSize)
+	if err != nil {
+		return nil, errwrap.ErrInternal.SetMessage("failed to generate email change token").SetOriginError(err)
+	}
+	previousEmail := existingUser.Email
+	existingUser.Email = pending.NewEmail
+	existingUser.EmailChange = nil
+	existingUser.EmailRevert = &model.EmailRevert{
+		PreviousEmail: previousEmail,
+		TokenHash:     crypt.HashToken(revertToken),
+		ExpireTime:    time.Now().UTC().Add(emailRevertDuration),
+	}
+	existingUser.Meta.Update()
+	if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
+		return nil, err
+	}
+	s.index(ctx, existingUser)
+	s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_EmailChanged, UserID: existingUser.Id, Identifier: previousEmail})
+
+	if pending.RevokeSessions {
+		if err := s.tokens.InvalidateUserTokens(ctx, id); err != nil {
+			return nil, errwrap.ErrInternal.SetMessage("failed to invalidate tokens").SetOriginError(err)
+		}
+	}
+
+	// The change is done, a failed notification is logged only
+	message := mail.Message{
+		To:      previousEmail,
+		Subject: "The email address of your account was changed",
+		Body: fmt.Sprintf("The email address of your account was changed to %s.\n"+
+			"If you did not request this, open the link below within %d days to restore this address and sign out all sessions:\n%s%s\n",
+			existingUser.Email, int(emailRevertDuration.Hours()/24), s.emailLinks.revert, revertToken),
+	}
+	if err := s.mail.Send(ctx, message); err != nil {
+		slog.ErrorContext(ctx, "failed to notify previous email", slog.String("user_id", id), slog.Any("error", err))
+	}
+
+	return existingUser, nil
+}
+
+// RevertEmailChange restores the previous email of the user the token was sent to and revokes all of its tokens.
+// It is called without authentication, the token proves access to the previous address.
+func (s *user) RevertEmailChange(ctx context.Context, token string) error {
+	existingUser, err := s.repo.GetUserByEmailRevertToken(ctx, crypt.HashToken(token))
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if err != nil || time.Now().After(existingUser.EmailRevert.ExpireTime) {
+		return errwrap.NewError("invalid or expired email revert token", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	changedEmail := existingUser.Email
+	existingUser.Email = existingUser.EmailRevert.PreviousEmail
+	existingUser.EmailRevert = nil
+	existingUser.EmailChange = nil
+	existingUser.Meta.Update()
+	if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
+		return err
+	}
+	s.index(ctx, existingUser)
+	s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_EmailChangeReverted, UserID: existingUser.Id, Identifier: changedEmail})
+
+	if err := s.tokens.InvalidateUserTokens(ctx, existingUser.Id); err != nil {
+		return errwrap.ErrInternal.SetMessage("failed to invalidate tokens").SetOriginError(err)
+	}
+	return nil
+}
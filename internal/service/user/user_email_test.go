@@ -0,0 +1,123 @@
+package user
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/crypt"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func (f *fakeRepo) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	switch email {
+	case f.user.Email:
+		return f.GetUserById(ctx, f.user.Id)
+	case "taken@example.com":
+		return &model.User{Id: "user-2", Email: email}, nil
+	}
+	return nil, errwrap.NewError("user not found", codes.NotFound.String()).SetGrpcCode(codes.NotFound)
+}
+
+func (f *fakeRepo) GetUserByEmailRevertToken(ctx context.Context, tokenHash string) (*model.User, error) {
+	if f.user.EmailRevert == nil || f.user.EmailRevert.TokenHash != tokenHash {
+		return nil, errwrap.NewError("user not found", codes.NotFound.String()).SetGrpcCode(codes.NotFound)
+	}
+	return f.GetUserById(ctx, f.user.Id)
+}
+
+// fakeRecorder records the types of the security events
+type fakeRecorder struct {
+	types []model.SecurityEventType
+}
+
+func (f *fakeRecorder) Record(ctx context.Context, event *model.SecurityEvent) {
+	f.types = append(f.types, event.Type)
+}
+
+// fakeTokens counts the revocations of all tokens of a user
+type fakeTokens struct {
+	TokenStore
+	invalidated int
+}
+
+func (f *fakeTokens) InvalidateUserTokens(ctx context.Context, userID string) error {
+	f.invalidated++
+	return nil
+}
+
+// tokenPattern finds the token at the end of a link in a message
+var tokenPattern = regexp.MustCompile(`token=([\w-]+)`)
+
+func newEmailTestService(t *testing.T, sender mail.Sender) (*user, *fakeRepo, *fakeRecorder, *fakeTokens) {
+	password, err := crypt.HashPassword("secret")
+	require.NoError(t, err)
+	repo := &fakeRepo{user: model.User{Id: "user-1", Email: "old@example.com", Password: password}}
+	events := &fakeRecorder{}
+	tokens := &fakeTokens{}
+	svc := newTestService(Deps{Repo: repo, Events: events, Tokens: tokens, Search: search.NewMemoryBackend(), Mail: sender})
+	return svc, repo, events, tokens
+}
+
+func linkToken(t *testing.T, message mail.Message) string {
+	t.Helper()
+	match := tokenPattern.FindStringSubmatch(message.Body)
+	require.Len(t, match, 2)
+	return match[1]
+}
+
+func TestEmailChange(t *testing.T) {
+	ctx := context.Background()
+	sender := &mail.Fake{}
+	svc, repo, events, tokens := newEmailTestService(t, sender)
+
+	_, err := svc.RequestEmailChange(ctx, "user-1", "new@example.com", "wrong", false)
+	assertCode(t, err, codes.PermissionDenied)
+	_, err = svc.RequestEmailChange(ctx, "user-1", "taken@example.com", "secret", false)
+	assertCode(t, err, codes.AlreadyExists)
+
+	_, err = svc.RequestEmailChange(ctx, "user-1", "new@example.com", "secret", true)
+	require.NoError(t, err)
+	assert.Equal(t, "old@example.com", repo.user.Email)
+	assert.Equal(t, "new@example.com", repo.user.PendingEmail())
+	messages := sender.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "new@example.com", messages[0].To)
+	confirmToken := linkToken(t, messages[0])
+
+	_, err = svc.ConfirmEmailChange(ctx, "user-1", "not the token")
+	assertCode(t, err, codes.InvalidArgument)
+
+	changed, err := svc.ConfirmEmailChange(ctx, "user-1", confirmToken)
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", changed.Email)
+	assert.Nil(t, repo.user.EmailChange)
+	assert.Equal(t, 1, tokens.invalidated)
+
+	// The previous address is notified with a revert link
+	messages = sender.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "old@example.com", messages[1].To)
+	revertToken := linkToken(t, messages[1])
+
+	assertCode(t, svc.RevertEmailChange(ctx, confirmToken), codes.InvalidArgument)
+	require.NoError(t, svc.RevertEmailChange(ctx, revertToken))
+	assert.Equal(t, "old@example.com", repo.user.Email)
+	assert.Nil(t, repo.user.EmailRevert)
+	assert.Equal(t, 2, tokens.invalidated)
+	assert.Equal(t, []model.SecurityEventType{model.SecurityEvent_EmailChanged, model.SecurityEvent_EmailChangeReverted}, events.types)
+
+	// A revert token can only be used once
+	assertCode(t, svc.RevertEmailChange(ctx, revertToken), codes.InvalidArgument)
+}
+
+func TestEmailIsNotUpdatable(t *testing.T) {
+	err := validateUpdateMask([]string{model.UserField_Email}, adminEditableFields)
+	assertCode(t, err, codes.InvalidArgument)
+}
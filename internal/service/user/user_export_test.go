@@ -0,0 +1,158 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+)
+
+// fakeStreamRepo streams its users in order, filtered by status and resumed after the _id of the sort key
+type fakeStreamRepo struct {
+	repository.Repository
+	users []*model.User
+
+	filter bson.M
+	after  []bson.RawValue
+}
+
+func (f *fakeStreamRepo) StreamUsers(ctx context.Context, filterCriteria bson.M, sort types.Sort, after []bson.RawValue, fn func(user *model.User, key []bson.RawValue) error) error {
+	f.filter, f.after = filterCriteria, after
+
+	resumed := after == nil
+	for _, user := range f.users {
+		if !resumed {
+			resumed = after[len(after)-1].StringValue() == user.Id
+			continue
+		}
+		if status, ok := filterCriteria["status"]; ok && status != user.Status {
+			continue
+		}
+		raw, err := bson.Marshal(user)
+		if err != nil {
+			return err
+		}
+		key, err := sort.KeyOf(raw)
+		if err != nil {
+			return err
+		}
+		if err := fn(user, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newExportTestService() (*user, *fakeStreamRepo) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakeStreamRepo{}
+	for i, id := range []string{"user-1", "user-2", "user-3", "user-4"} {
+		status := model.UserStatus_Active
+		if id == "user-3" {
+			status = model.UserStatus_Inactive
+		}
+		repo.users = append(repo.users, &model.User{
+			Id:       id,
+			Email:    id + "@example.com",
+			Password: "$2a$10$hash",
+			Status:   status,
+			Meta:     types.Meta{CreatedAt: created.Add(time.Duration(i) * time.Hour)},
+			Invite:   &model.UserInvite{TokenHash: "invite-hash"},
+		})
+	}
+	svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend(), PageTokens: types.NewPageTokenCodec([]byte("secret"), time.Hour)})
+	return svc, repo
+}
+
+// exportAll collects the ids and resume tokens of an export
+func exportAll(t *testing.T, svc *user, filter *model.UserFilter, resumeToken string) ([]string, []string) {
+	t.Helper()
+	var ids, tokens []string
+	err := svc.ExportUsers(callerContext("admin-1", auth.RoleAdmin), filter, resumeToken, func(user *model.User, token string) error {
+		ids = append(ids, user.Id)
+		tokens = append(tokens, token)
+		return nil
+	})
+	require.NoError(t, err)
+	return ids, tokens
+}
+
+func TestExportUsersRequiresAdmin(t *testing.T) {
+	svc, repo := newExportTestService()
+
+	err := svc.ExportUsers(callerContext("user-1"), &model.UserFilter{Sort: model.UserDefaultSort}, "", func(*model.User, string) error {
+		t.Fatal("no user must be exported")
+		return nil
+	})
+	assertCode(t, err, codes.PermissionDenied)
+	assert.Nil(t, repo.filter, "the repository is not queried")
+}
+
+func TestExportUsersFiltering(t *testing.T) {
+	svc, repo := newExportTestService()
+
+	ids, _ := exportAll(t, svc, &model.UserFilter{Sort: model.UserDefaultSort, Country: "TR"}, "")
+	assert.Equal(t, []string{"user-1", "user-2", "user-4"}, ids, "only active users are exported by default")
+	assert.Equal(t, "TR", repo.filter["country"])
+
+	ids, _ = exportAll(t, svc, &model.UserFilter{Sort: model.UserDefaultSort, Status: model.UserStatus_Inactive}, "")
+	assert.Equal(t, []string{"user-3"}, ids)
+}
+
+func TestExportUsersStreamingAndResume(t *testing.T) {
+	svc, repo := newExportTestService()
+	filter := &model.UserFilter{Sort: model.UserDefaultSort}
+
+	ids, tokens := exportAll(t, svc, filter, "")
+	require.Equal(t, []string{"user-1", "user-2", "user-4"}, ids)
+	assert.Nil(t, repo.after)
+
+	// A failing send stops the stream
+	errClosed := errors.New("stream closed")
+	var sent []string
+	err := svc.ExportUsers(callerContext("admin-1", auth.RoleAdmin), filter, "", func(user *model.User, token string) error {
+		sent = append(sent, user.Id)
+		return errClosed
+	})
+	assert.ErrorIs(t, err, errClosed)
+	assert.Equal(t, []string{"user-1"}, sent)
+
+	// The resume token of a user continues right after it
+	ids, _ = exportAll(t, svc, filter, tokens[0])
+	assert.Equal(t, []string{"user-2", "user-4"}, ids)
+	require.Len(t, repo.after, len(filter.Sort))
+	assert.Equal(t, "user-1", repo.after[1].StringValue())
+
+	// Resume tokens are bound to the filter they were issued for
+	err = svc.ExportUsers(callerContext("admin-1", auth.RoleAdmin), &model.UserFilter{Sort: model.UserDefaultSort, Country: "TR"}, tokens[0], func(*model.User, string) error { return nil })
+	assertCode(t, err, codes.InvalidArgument)
+}
+
+func TestExportUsersFieldSet(t *testing.T) {
+	svc, _ := newExportTestService()
+
+	var exported []*model.User
+	err := svc.ExportUsers(callerContext("admin-1", auth.RoleAdmin), &model.UserFilter{Sort: model.UserDefaultSort}, "", func(user *model.User, _ string) error {
+		exported = append(exported, user)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, exported)
+
+	// Users are sent as proto messages, secrets of the document must not be part of them
+	pbUser := exported[0].UserToProto()
+	assert.Equal(t, "user-1", pbUser.Id)
+	assert.Equal(t, "user-1@example.com", pbUser.Email)
+	assert.NotNil(t, pbUser.Meta)
+	assert.Empty(t, pbUser.Password)
+}
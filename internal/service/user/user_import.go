@@ -0,0 +1,260 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsaltun/user-service-grpc/internal/importer"
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/crypt"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// MaxImportRows limits the rows of a single import, larger inputs have to be split
+	MaxImportRows = 10000
+	// importBatchSize is the number of users written per InsertMany
+	importBatchSize = 500
+
+	inviteTokenBytes = 32
+	inviteTokenTTL   = 7 * 24 * time.Hour
+)
+
+// importItem is a valid row waiting to be written with its batch
+type importItem struct {
+	user   *model.User
+	result *model.UserImportResult
+}
+
+// ImportUsers creates users from the rows of input and reports the outcome of every row.
+//
+// Rows are validated like CreateUser. Users with a password get it hashed, users without one
+// get an invite token, which is returned once in the report. Valid rows are written in batches,
+// a failed row does not stop the import. With options.DryRun the rows are validated and checked
+// for conflicts with stored users, but nothing is written. Importing is reserved for admins.
+func (s *user) ImportUsers(ctx context.Context, input io.Reader, options model.ImportOptions) (*model.UserImportReport, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := importer.NewRowReader(input, options.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.UserImportReport{DryRun: options.DryRun}
+	keys := s.repo.KeyNormalizer()
+	// Lines by the lookup keys of emails and nicknames, which are unique among users
+	emailLines, nickNameLines := map[string]int{}, map[string]int{}
+	batch := make([]importItem, 0, importBatchSize)
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(report.Results) == MaxImportRows {
+			return nil, errwrap.NewError(fmt.Sprintf("import has more than %d rows", MaxImportRows), codes.InvalidArgument.String()).
+				SetGrpcCode(codes.InvalidArgument)
+		}
+
+		result := &model.UserImportResult{Line: row.Line}
+		report.Results = append(report.Results, result)
+		if row.Err != nil {
+			result.Err = row.Err
+			continue
+		}
+		result.Email = row.User.Email
+		if result.Err = validateNewUser(row.User); result.Err != nil {
+			continue
+		}
+		if result.Err = s.nicknames.Validate(row.User.NickName); result.Err != nil {
+			continue
+		}
+
+		// The unique indexes only catch duplicates between batches, and not at all in a dry run
+		emailKey, nickNameKey := keys.Email(row.User.Email), keys.NickName(row.User.NickName)
+		if line, ok := emailLines[emailKey]; ok {
+			result.Err = errwrap.ErrConflict.SetMessage(fmt.Sprintf("email is already used in line %d", line))
+			continue
+		}
+		if line, ok := nickNameLines[nickNameKey]; ok {
+			result.Err = errwrap.ErrConflict.SetMessage(fmt.Sprintf("nickname is already used in line %d", line))
+			continue
+		}
+		emailLines[emailKey] = row.Line
+		if nickNameKey != "" {
+			nickNameLines[nickNameKey] = row.Line
+		}
+
+		batch = append(batch, importItem{user: row.User, result: result})
+		if len(batch) == importBatchSize {
+			if err := s.importBatch(ctx, batch, options.DryRun); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := s.importBatch(ctx, batch, options.DryRun); err != nil {
+			return nil, err
+		}
+	}
+
+	slog.InfoContext(ctx, "users imported",
+		slog.Int("rows", len(report.Results)), slog.Int("failed", report.Failed()), slog.Bool("dry_run", options.DryRun))
+	return report, nil
+}
+
+// importBatch writes the users of a batch and records the outcome in their results
+func (s *user) importBatch(ctx context.Context, batch []importItem, dryRun bool) error {
+	if dryRun {
+		return s.checkImportConflicts(ctx, batch)
+	}
+
+	s.prepareImportedUsers(batch)
+
+	var items []importItem
+	var users []*model.User
+	for _, item := range batch {
+		if item.result.Err == nil {
+			items = append(items, item)
+			users = append(users, item.user)
+		}
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	errs, err := s.repo.CreateUsers(ctx, users)
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		if errs[i] != nil {
+			item.result.Err = errs[i]
+			item.result.InviteToken = ""
+			continue
+		}
+		item.result.Id = item.user.Id
+		s.index(ctx, item.user)
+	}
+	return nil
+}
+
+// prepareImportedUsers hashes the passwords or issues invite tokens and sets the defaults of new users.
+// Hashing is slow by design, so it runs on all CPUs.
+func (s *user) prepareImportedUsers(batch []importItem) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for _, item := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			item.result.Err = prepareImportedUser(item.user, item.result)
+		}()
+	}
+	wg.Wait()
+}
+
+func prepareImportedUser(user *model.User, result *model.UserImportResult) error {
+	if user.Password != "" {
+		hashedPwd, err := crypt.HashPassword(user.Password)
+		if err != nil {
+			return errwrap.NewError("unexpected error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+		}
+		user.Password = hashedPwd
+	} else {
+		token, err := crypt.NewToken(inviteTokenBytes)
+		if err != nil {
+			return errwrap.NewError("unexpected error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+		}
+		user.Invite = &model.UserInvite{
+			TokenHash:  crypt.HashToken(token),
+			ExpireTime: time.Now().UTC().Add(inviteTokenTTL),
+		}
+		result.InviteToken = token
+	}
+
+	user.Id = uuid.NewString()
+	user.Status = model.UserStatus_Active
+	user.Meta = types.NewMeta()
+	return nil
+}
+
+// checkImportConflicts reports the users of a batch whose email or nickname is already taken by a stored user.
+// They are compared by their lookup keys like the unique indexes do.
+func (s *user) checkImportConflicts(ctx context.Context, batch []importItem) error {
+	emails := make([]string, 0, len(batch))
+	var nickNames []string
+	for _, item := range batch {
+		emails = append(emails, item.user.Email)
+		if item.user.NickName != "" {
+			nickNames = append(nickNames, item.user.NickName)
+		}
+	}
+
+	existing, err := s.repo.FindUsersByEmailOrNickName(ctx, emails, nickNames)
+	if err != nil {
+		return err
+	}
+	keys := s.repo.KeyNormalizer()
+	takenEmails, takenNickNames := map[string]bool{}, map[string]bool{}
+	for _, user := range existing {
+		takenEmails[keys.Email(user.Email)] = true
+		if user.NickName != "" {
+			takenNickNames[keys.NickName(user.NickName)] = true
+		}
+	}
+
+	for _, item := range batch {
+		if takenEmails[keys.Email(item.user.Email)] || takenNickNames[keys.NickName(item.user.NickName)] {
+			item.result.Err = errwrap.ErrConflict.SetMessage("already exists with the same nickname or email")
+		}
+	}
+	return nil
+}
+
+// AcceptInvite sets the password of an imported user with the invite token issued by the import.
+// It is called without authentication, the token proves the user received the invite. The token is
+// single use, it is removed with the password set.
+func (s *user) AcceptInvite(ctx context.Context, token string, password string) error {
+	existingUser, err := s.repo.GetUserByInviteToken(ctx, crypt.HashToken(token))
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if err != nil || time.Now().After(existingUser.Invite.ExpireTime) || existingUser.Status != model.UserStatus_Active {
+		return errwrap.NewError("invalid or expired invite token", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	hashedPwd, err := crypt.HashPassword(password)
+	if err != nil {
+		if err == bcrypt.ErrPasswordTooLong {
+			return errwrap.NewError("password is too long", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		}
+		return errwrap.NewError("unexpected error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+	}
+
+	existingUser.Password = hashedPwd
+	existingUser.Invite = nil
+	existingUser.Meta.Update()
+	if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
+		return err
+	}
+	s.index(ctx, existingUser)
+	s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_PasswordChanged, UserID: existingUser.Id})
+	return nil
+}
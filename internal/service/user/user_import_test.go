@@ -0,0 +1,101 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/nickname"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+)
+
+func (f *fakeRepo) GetUserByInviteToken(ctx context.Context, tokenHash string) (*model.User, error) {
+	if f.user.Invite == nil || f.user.Invite.TokenHash != tokenHash {
+		return nil, errwrap.NewError("user not found", codes.NotFound.String()).SetGrpcCode(codes.NotFound)
+	}
+	invite := *f.user.Invite
+	user, _ := f.GetUserById(ctx, f.user.Id)
+	user.Invite = &invite
+	return user, nil
+}
+
+// newInvitedUser prepares a user imported without password and returns its invite token
+func newInvitedUser(t *testing.T) (model.User, string) {
+	user := model.User{Email: "invited@example.com"}
+	result := &model.UserImportResult{}
+	require.NoError(t, prepareImportedUser(&user, result))
+	require.NotEmpty(t, result.InviteToken)
+	return user, result.InviteToken
+}
+
+func TestAcceptInvite(t *testing.T) {
+	ctx := context.Background()
+	user, token := newInvitedUser(t)
+	repo := &fakeRepo{user: user}
+	events := &fakeRecorder{}
+	svc := newTestService(Deps{Repo: repo, Events: events, Search: search.NewMemoryBackend()})
+
+	require.NoError(t, svc.AcceptInvite(ctx, token, "new password"))
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(repo.user.Password), []byte("new password")))
+	assert.Nil(t, repo.user.Invite)
+	assert.Equal(t, []model.SecurityEventType{model.SecurityEvent_PasswordChanged}, events.types)
+
+	// The token is single use
+	assertCode(t, svc.AcceptInvite(ctx, token, "other password"), codes.InvalidArgument)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(repo.user.Password), []byte("new password")))
+}
+
+func TestAcceptInviteRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(user *model.User)
+		token    func(token string) string
+		password string
+	}{
+		{"unknown token", nil, func(string) string { return "unknown" }, "new password"},
+		{"expired invite", func(user *model.User) { user.Invite.ExpireTime = time.Now().Add(-time.Minute) }, nil, "new password"},
+		{"deleted user", func(user *model.User) { user.Status = model.UserStatus_Inactive }, nil, "new password"},
+		{"password too long", nil, nil, strings.Repeat("p", maxPasswordLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, token := newInvitedUser(t)
+			if tt.modify != nil {
+				tt.modify(&user)
+			}
+			if tt.token != nil {
+				token = tt.token(token)
+			}
+			repo := &fakeRepo{user: user}
+			svc := newTestService(Deps{Repo: repo, Events: &fakeRecorder{}, Search: search.NewMemoryBackend()})
+
+			assertCode(t, svc.AcceptInvite(context.Background(), token, tt.password), codes.InvalidArgument)
+			assert.NotNil(t, repo.user.Invite, "the invite is kept")
+			assert.Empty(t, repo.user.Password)
+		})
+	}
+}
+
+func TestImportUsersRequiresAdmin(t *testing.T) {
+	repo := &fakeRepo{user: model.User{Id: "user-1", NickName: "Ahmet"}}
+	svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend(), Nicknames: nickname.NewPolicy("", "")})
+	input := "email,nick_name\nmehmet@example.com,Mehmet\nahmet@example.com,AHMET\n"
+	options := model.ImportOptions{Format: model.ImportFormat_CSV, DryRun: true}
+
+	_, err := svc.ImportUsers(callerContext("user-1"), strings.NewReader(input), options)
+	assertCode(t, err, codes.PermissionDenied)
+
+	report, err := svc.ImportUsers(callerContext("admin-1", auth.RoleAdmin), strings.NewReader(input), options)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 2)
+	assert.NoError(t, report.Results[0].Err)
+	assertCode(t, report.Results[1].Err, codes.AlreadyExists)
+}
@@ -0,0 +1,168 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	middleware "github.com/nsaltun/user-service-grpc/pkg/v1/middleware/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// RestoreUser reactivates a deleted user and cancels its pending erasure.
+// The email and nickname must not have been taken by another user since the user was deleted.
+// Restoring is reserved for admins.
+func (s *user) RestoreUser(ctx context.Context, id string, reason string) (*model.User, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	existingUser, err := s.repo.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existingUser.Status != model.UserStatus_Inactive {
+		return nil, errwrap.NewError("user is not deleted", codes.FailedPrecondition.String()).SetGrpcCode(codes.FailedPrecondition)
+	}
+	if err := s.checkUniqueKeys(ctx, existingUser); err != nil {
+		return nil, err
+	}
+
+	existingUser.Status = model.UserStatus_Active
+	existingUser.Meta.Update()
+	event := recordStatusChange(ctx, existingUser, model.UserStatus_Inactive, reason)
+
+	if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
+		return nil, err
+	}
+	s.index(ctx, existingUser)
+	s.emitLifecycle(ctx, event)
+
+	return existingUser, nil
+}
+
+// RequestErasure deactivates the user and schedules the erasure of its personal data.
+// The user is purged after the erasure retention period unless it is restored before.
+// Only admins and the user itself may request the erasure.
+func (s *user) RequestErasure(ctx context.Context, id string, reason string) (*model.User, error) {
+	if err := requireSelfOrAdmin(ctx, id); err != nil {
+		return nil, err
+	}
+
+	existingUser, err := s.repo.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existingUser.ErasureRequest != nil {
+		return nil, errwrap.NewError("erasure is already requested", codes.FailedPrecondition.String()).SetGrpcCode(codes.FailedPrecondition)
+	}
+
+	previous := existingUser.Status
+	existingUser.Status = model.UserStatus_Inactive
+	existingUser.Meta.Update()
+	existingUser.ErasureRequest = &model.UserStatusChange{By: actor(ctx), Time: existingUser.UpdatedAt, Reason: reason}
+	event := recordStatusChange(ctx, existingUser, previous, "erasure requested")
+
+	if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
+		return nil, err
+	}
+	s.index(ctx, existingUser)
+	s.emitLifecycle(ctx, event)
+
+	if err := s.tokens.InvalidateUserTokens(ctx, id); err != nil {
+		return nil, errwrap.ErrInternal.SetMessage("failed to invalidate tokens").SetOriginError(err)
+	}
+
+	return existingUser, nil
+}
+
+// checkUniqueKeys fails if another user has the email or nickname of the user, or has verified its phone
+func (s *user) checkUniqueKeys(ctx context.Context, user *model.User) error {
+	var nickNames []string
+	if user.NickName != "" {
+		nickNames = []string{user.NickName}
+	}
+	users, err := s.repo.FindUsersByEmailOrNickName(ctx, []string{user.Email}, nickNames)
+	if err != nil {
+		return err
+	}
+
+	// Emails and nicknames are compared by their lookup keys like the unique indexes do
+	keys := s.repo.KeyNormalizer()
+	for _, other := range users {
+		if other.Id == user.Id {
+			continue
+		}
+		if keys.Email(other.Email) == keys.Email(user.Email) {
+			return errwrap.ErrConflict.SetMessage("email is used by another user")
+		}
+		return errwrap.ErrConflict.SetMessage("nickname is used by another user")
+	}
+
+	// Only verified phones are unique
+	if user.Phone == "" || !user.PhoneVerified {
+		return nil
+	}
+	if other, err := s.repo.GetUserByPhone(ctx, user.Phone); err == nil && other.Id != user.Id {
+		return errwrap.ErrConflict.SetMessage("phone is verified by another user")
+	} else if err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// recordStatusChange records who deactivated or restored the user after its status changed from previous.
+// It must be called after Meta.Update and returns the lifecycle event to emit, nil if the status did not change.
+func recordStatusChange(ctx context.Context, user *model.User, previous model.UserStatus, reason string) *model.UserLifecycleEvent {
+	if user.Status == previous {
+		return nil
+	}
+
+	change := &model.UserStatusChange{By: actor(ctx), Time: user.UpdatedAt, Reason: reason}
+	event := &model.UserLifecycleEvent{UserID: user.Id, By: change.By, Reason: reason, OccurredAt: change.Time}
+	switch user.Status {
+	case model.UserStatus_Inactive:
+		user.Deactivation = change
+		event.Type = model.UserLifecycle_Deactivated
+	case model.UserStatus_Active:
+		user.Restoration = change
+		// Restoring the user cancels a pending erasure
+		user.ErasureRequest = nil
+		event.Type = model.UserLifecycle_Restored
+	default:
+		return nil
+	}
+	return event
+}
+
+// actor identifies the caller by its user id, or by its service principal for internal calls
+func actor(ctx context.Context) string {
+	if userID, ok := middleware.GetUserID(ctx); ok {
+		return userID
+	}
+	if principal, ok := middleware.GetServicePrincipal(ctx); ok {
+		return "service:" + principal
+	}
+	return ""
+}
+
+// emitLifecycle publishes the event to the event bus if one is configured.
+// Within a transaction of batchWrite the event is held back until the commit.
+func (s *user) emitLifecycle(ctx context.Context, event *model.UserLifecycleEvent) {
+	if event == nil || s.publisher == nil {
+		return
+	}
+
+	afterCommit(ctx, func(ctx context.Context) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to marshal lifecycle event", slog.Any("error", err), slog.Any("type", event.Type))
+			return
+		}
+		if err := s.publisher.Publish(ctx, event.Topic(), data); err != nil {
+			slog.WarnContext(ctx, "failed to publish lifecycle event", slog.Any("error", err), slog.Any("type", event.Type))
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	middleware "github.com/nsaltun/user-service-grpc/pkg/v1/middleware/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+// callerContext returns a context authenticated like the auth interceptor does for an access token
+func callerContext(userID string, roles ...string) context.Context {
+	ctx := context.WithValue(context.Background(), middleware.UserIDKey, userID)
+	return context.WithValue(ctx, middleware.RolesKey, append([]string{auth.RoleUser}, roles...))
+}
+
+// servicePrincipalContext returns a context authenticated like the auth interceptor does for a client certificate
+func servicePrincipalContext(principal string, roles ...string) context.Context {
+	ctx := context.WithValue(context.Background(), middleware.ServicePrincipalKey, principal)
+	return context.WithValue(ctx, middleware.RolesKey, roles)
+}
+
+func TestRequestErasureRequiresSelfOrAdmin(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{"other user", callerContext("user-2"), codes.PermissionDenied},
+		{"anonymous", context.Background(), codes.PermissionDenied},
+		{"user itself", callerContext("user-1"), codes.OK},
+		{"admin", callerContext("admin-1", auth.RoleAdmin), codes.OK},
+		{"service principal without admin role", servicePrincipalContext("reporting"), codes.PermissionDenied},
+		{"service principal with admin role", servicePrincipalContext("billing", auth.RoleAdmin), codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{user: model.User{Id: "user-1", Status: model.UserStatus_Active}}
+			tokens := &fakeTokens{}
+			svc := newTestService(Deps{Repo: repo, Tokens: tokens, Search: search.NewMemoryBackend()})
+
+			erased, err := svc.RequestErasure(tt.ctx, "user-1", "gdpr")
+			if tt.code != codes.OK {
+				assertCode(t, err, tt.code)
+				assert.Nil(t, repo.user.ErasureRequest)
+				assert.Zero(t, tokens.invalidated)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, model.UserStatus_Inactive, erased.Status)
+			assert.NotNil(t, repo.user.ErasureRequest)
+			assert.Equal(t, 1, tokens.invalidated)
+		})
+	}
+}
+
+func TestDeleteUserRequiresSelfOrAdmin(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{"other user", callerContext("user-2"), codes.PermissionDenied},
+		{"anonymous", context.Background(), codes.PermissionDenied},
+		{"user itself", callerContext("user-1"), codes.OK},
+		{"admin", callerContext("admin-1", auth.RoleAdmin), codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{user: model.User{Id: "user-1", Status: model.UserStatus_Active}}
+			svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend()})
+
+			err := svc.DeleteUser(tt.ctx, "user-1", "")
+			if tt.code != codes.OK {
+				assertCode(t, err, tt.code)
+				assert.Equal(t, model.UserStatus_Active, repo.user.Status)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, model.UserStatus_Inactive, repo.user.Status)
+		})
+	}
+}
+
+func TestRestoreUserRequiresAdmin(t *testing.T) {
+	repo := &fakeRepo{user: model.User{Id: "user-1", Email: "user@example.com", Status: model.UserStatus_Inactive}}
+	svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend()})
+
+	_, err := svc.RestoreUser(callerContext("user-1"), "user-1", "mistake")
+	assertCode(t, err, codes.PermissionDenied)
+	assert.Equal(t, model.UserStatus_Inactive, repo.user.Status)
+
+	restored, err := svc.RestoreUser(callerContext("admin-1", auth.RoleAdmin), "user-1", "mistake")
+	require.NoError(t, err)
+	assert.Equal(t, model.UserStatus_Active, restored.Status)
+	assert.Equal(t, "admin-1", restored.Restoration.By)
+}
+
+func TestRestoreUserConflicts(t *testing.T) {
+	deleted := model.User{Id: "user-1", Email: "Ahmet@Example.com", NickName: "Ahmet", Phone: "+905321234567", PhoneVerified: true, Status: model.UserStatus_Inactive}
+
+	tests := []struct {
+		name            string
+		other           model.User
+		phoneVerifiedBy string
+		message         string
+	}{
+		{"email differing in case", model.User{Id: "user-2", Email: "ahmet@example.com"}, "", "email is used by another user"},
+		{"nickname differing in case", model.User{Id: "user-2", Email: "mehmet@example.com", NickName: "AHMET"}, "", "nickname is used by another user"},
+		{"verified phone", model.User{}, "user-2", "phone is verified by another user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{user: deleted, others: []model.User{tt.other}, phoneVerifiedBy: tt.phoneVerifiedBy}
+			svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend()})
+
+			_, err := svc.RestoreUser(callerContext("admin-1", auth.RoleAdmin), "user-1", "mistake")
+			assertCode(t, err, codes.AlreadyExists)
+			var wrapped errwrap.IError
+			require.ErrorAs(t, err, &wrapped)
+			assert.Equal(t, tt.message, wrapped.Message())
+			assert.Equal(t, model.UserStatus_Inactive, repo.user.Status)
+		})
+	}
+}
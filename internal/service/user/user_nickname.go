@@ -0,0 +1,74 @@
+package user
+
+import (
+	"context"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/nickname"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	middleware "github.com/nsaltun/user-service-grpc/pkg/v1/middleware/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// nicknameSuggestions is the number of suggestions for a taken nickname
+	nicknameSuggestions = 3
+	// nicknameCandidates is the number of candidates checked for the suggestions
+	nicknameCandidates = 10
+)
+
+// CheckNicknameAvailability reports whether the nickname can be chosen, and suggests available ones if it is taken.
+// Nicknames are compared by their lookup keys and checked against the nickname policy like on creation.
+// Checks are limited per client address. A nickname of an inactive user is reported as taken like any other.
+func (s *user) CheckNicknameAvailability(ctx context.Context, nickName string) (*model.NicknameCheck, error) {
+	if !s.nicknameChecks.Allow(middleware.GetClientIP(ctx)) {
+		return nil, errwrap.NewError("too many nickname checks, try again later", codes.ResourceExhausted.String()).
+			SetGrpcCode(codes.ResourceExhausted)
+	}
+
+	if !s.nicknames.Allowed(nickName) {
+		return &model.NicknameCheck{Availability: model.NicknameAvailability_NotAllowed}, nil
+	}
+	if _, err := s.repo.GetUserByNickName(ctx, nickName); isNotFound(err) {
+		return &model.NicknameCheck{Availability: model.NicknameAvailability_Available}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	suggestions, err := s.suggestNicknames(ctx, nickName)
+	if err != nil {
+		return nil, err
+	}
+	return &model.NicknameCheck{Availability: model.NicknameAvailability_Taken, Suggestions: suggestions}, nil
+}
+
+// suggestNicknames returns allowed nicknames derived from the taken one that no user has
+func (s *user) suggestNicknames(ctx context.Context, nickName string) ([]string, error) {
+	var candidates []string
+	for _, candidate := range nickname.Suggestions(nickName, nicknameCandidates) {
+		if s.nicknames.Allowed(candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	existing, err := s.repo.FindUsersByEmailOrNickName(ctx, []string{}, candidates)
+	if err != nil {
+		return nil, err
+	}
+	keys := s.repo.KeyNormalizer()
+	taken := map[string]bool{}
+	for _, user := range existing {
+		taken[keys.NickName(user.NickName)] = true
+	}
+
+	var suggestions []string
+	for _, candidate := range candidates {
+		if !taken[keys.NickName(candidate)] && len(suggestions) < nicknameSuggestions {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions, nil
+}
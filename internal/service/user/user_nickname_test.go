@@ -0,0 +1,80 @@
+package user
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/nickname"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func (f *fakeRepo) KeyNormalizer() model.UserKeyNormalizer {
+	return model.UserKeyNormalizer{}
+}
+
+func (f *fakeRepo) GetUserByNickName(ctx context.Context, nickName string) (*model.User, error) {
+	if f.KeyNormalizer().NickName(nickName) != f.KeyNormalizer().NickName(f.user.NickName) {
+		return nil, errwrap.NewError("user not found", codes.NotFound.String()).SetGrpcCode(codes.NotFound)
+	}
+	return f.GetUserById(ctx, f.user.Id)
+}
+
+func (f *fakeRepo) FindUsersByEmailOrNickName(ctx context.Context, emails []string, nickNames []string) ([]*model.User, error) {
+	var users []*model.User
+	for _, nickName := range nickNames {
+		if user, err := f.GetUserByNickName(ctx, nickName); err == nil {
+			users = append(users, user)
+		}
+	}
+
+	keys := f.KeyNormalizer()
+	for _, other := range f.others {
+		if slices.ContainsFunc(emails, func(email string) bool { return keys.Email(email) == keys.Email(other.Email) }) ||
+			slices.ContainsFunc(nickNames, func(nickName string) bool { return keys.NickName(nickName) == keys.NickName(other.NickName) }) {
+			users = append(users, &other)
+		}
+	}
+	return users, nil
+}
+
+func TestCheckNicknameAvailability(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRepo{user: model.User{Id: "user-1", NickName: "Ahmet", Status: model.UserStatus_Inactive}}
+	svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend(), Nicknames: nickname.NewPolicy("", "")})
+
+	// The nickname of an inactive user is taken like any other
+	check, err := svc.CheckNicknameAvailability(ctx, "AHMET")
+	require.NoError(t, err)
+	assert.Equal(t, model.NicknameAvailability_Taken, check.Availability)
+	assert.Len(t, check.Suggestions, nicknameSuggestions)
+	for _, suggestion := range check.Suggestions {
+		assert.Regexp(t, `^AHMET\d+$`, suggestion)
+	}
+
+	check, err = svc.CheckNicknameAvailability(ctx, "mehmet")
+	require.NoError(t, err)
+	assert.Equal(t, &model.NicknameCheck{Availability: model.NicknameAvailability_Available}, check)
+
+	check, err = svc.CheckNicknameAvailability(ctx, "Adm1n")
+	require.NoError(t, err)
+	assert.Equal(t, &model.NicknameCheck{Availability: model.NicknameAvailability_NotAllowed}, check)
+}
+
+func TestCheckNicknameAvailabilityIsRateLimited(t *testing.T) {
+	repo := &fakeRepo{user: model.User{Id: "user-1"}}
+	svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend(), Nicknames: nickname.NewPolicy("", "")})
+	svc.nicknameChecks = ratelimit.New(1, time.Minute)
+
+	_, err := svc.CheckNicknameAvailability(context.Background(), "mehmet")
+	require.NoError(t, err)
+	_, err = svc.CheckNicknameAvailability(context.Background(), "mehmet")
+	assertCode(t, err, codes.ResourceExhausted)
+}
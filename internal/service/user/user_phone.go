@@ -0,0 +1,123 @@
+package user
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/crypt"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// phoneCodeDigits is the length of the SMS verification code
+	phoneCodeDigits = 6
+	// phoneCodeDuration is how long a verification code can be entered
+	phoneCodeDuration = 10 * time.Minute
+	// phoneCodeResendInterval is the least time between two codes sent to a user
+	phoneCodeResendInterval = time.Minute
+	// maxPhoneCodeAttempts is the number of wrong codes after which the code is discarded
+	maxPhoneCodeAttempts = 5
+	// phoneCodeSecretBytes is the size of the generated code secret when none is configured
+	phoneCodeSecretBytes = 32
+)
+
+// SendPhoneVerification sends a verification code by SMS to the phone of the user and returns its expire time.
+// A new code replaces the previous one.
+func (s *user) SendPhoneVerification(ctx context.Context, id string) (time.Time, error) {
+	if s.sms == nil {
+		return time.Time{}, errwrap.NewError("phone verification is not available", codes.FailedPrecondition.String()).
+			SetGrpcCode(codes.FailedPrecondition)
+	}
+
+	existingUser, err := s.repo.GetUserById(ctx, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if existingUser.Phone == "" {
+		return time.Time{}, errwrap.NewError("user has no phone", codes.FailedPrecondition.String()).SetGrpcCode(codes.FailedPrecondition)
+	}
+	if existingUser.PhoneVerified {
+		return time.Time{}, errwrap.NewError("phone is already verified", codes.FailedPrecondition.String()).SetGrpcCode(codes.FailedPrecondition)
+	}
+
+	now := time.Now().UTC()
+	if pending := existingUser.PhoneVerification; pending != nil && now.Before(pending.SentAt.Add(phoneCodeResendInterval)) {
+		return time.Time{}, errwrap.NewError("a code was sent recently, try again later", codes.ResourceExhausted.String()).
+			SetGrpcCode(codes.ResourceExhausted)
+	}
+
+	code, err := crypt.NewNumericCode(phoneCodeDigits)
+	if err != nil {
+		return time.Time{}, errwrap.ErrInternal.SetMessage("failed to generate verification code").SetOriginError(err)
+	}
+	existingUser.PhoneVerification = &model.PhoneVerification{
+		CodeHash:   crypt.HMACToken(s.phoneCodeSecret, code),
+		SentAt:     now,
+		ExpireTime: now.Add(phoneCodeDuration),
+	}
+	existingUser.Meta.Update()
+	if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
+		return time.Time{}, err
+	}
+
+	message := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(phoneCodeDuration.Minutes()))
+	if err := s.sms.Send(ctx, existingUser.Phone, message); err != nil {
+		slog.ErrorContext(ctx, "failed to send verification code", slog.String("user_id", id), slog.Any("error", err))
+		return time.Time{}, errwrap.NewError("failed to send verification code", codes.Unavailable.String()).
+			SetGrpcCode(codes.Unavailable).SetOriginError(err)
+	}
+
+	return existingUser.PhoneVerification.ExpireTime, nil
+}
+
+// VerifyPhone marks the phone of the user as verified if the code matches the last code sent.
+// The code is discarded after maxPhoneCodeAttempts wrong codes, and the attempts per phone number are limited
+// across resent codes. A phone can only be verified by one user.
+func (s *user) VerifyPhone(ctx context.Context, id string, code string) (*model.User, error) {
+	existingUser, err := s.repo.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pending := existingUser.PhoneVerification
+	if pending == nil || time.Now().After(pending.ExpireTime) {
+		return nil, errwrap.NewError("no valid verification code, request a new one", codes.FailedPrecondition.String()).
+			SetGrpcCode(codes.FailedPrecondition)
+	}
+	if !s.phoneCodeAttempts.Allow(existingUser.Phone) {
+		return nil, errwrap.NewError("too many verification attempts, try again later", codes.ResourceExhausted.String()).
+			SetGrpcCode(codes.ResourceExhausted)
+	}
+
+	existingUser.Meta.Update()
+	if subtle.ConstantTimeCompare([]byte(crypt.HMACToken(s.phoneCodeSecret, code)), []byte(pending.CodeHash)) != 1 {
+		pending.Attempts++
+		if pending.Attempts >= maxPhoneCodeAttempts {
+			existingUser.PhoneVerification = nil
+		}
+		if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
+			return nil, err
+		}
+		return nil, errwrap.NewError("invalid verification code", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+
+	// The unique index on verified phones backs this check against concurrent verifications
+	if found, err := s.repo.GetUserByPhone(ctx, existingUser.Phone); err == nil && found.Id != existingUser.Id {
+		return nil, errwrap.NewError("phone is verified by another user", codes.AlreadyExists.String()).SetGrpcCode(codes.AlreadyExists)
+	} else if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	existingUser.PhoneVerified = true
+	existingUser.PhoneVerification = nil
+	if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
+		return nil, err
+	}
+	s.index(ctx, existingUser)
+
+	return existingUser, nil
+}
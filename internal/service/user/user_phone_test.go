@@ -0,0 +1,243 @@
+package user
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/crypt"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/sms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+// fakeRepo keeps a single user in memory
+type fakeRepo struct {
+	repository.Repository
+	user model.User
+
+	// phoneVerifiedBy is the id of another user having verified the phone of the user
+	phoneVerifiedBy string
+	// others are further stored users, they are only found by FindUsersByEmailOrNickName
+	others []model.User
+}
+
+func (f *fakeRepo) GetUserById(ctx context.Context, id string) (*model.User, error) {
+	user := f.user
+	if user.PhoneVerification != nil {
+		verification := *user.PhoneVerification
+		user.PhoneVerification = &verification
+	}
+	return &user, nil
+}
+
+func (f *fakeRepo) GetUserByPhone(ctx context.Context, phone string) (*model.User, error) {
+	if f.phoneVerifiedBy == "" || phone != f.user.Phone {
+		return nil, errwrap.NewError("user not found", codes.NotFound.String()).SetGrpcCode(codes.NotFound)
+	}
+	return &model.User{Id: f.phoneVerifiedBy, Phone: phone, PhoneVerified: true}, nil
+}
+
+func (f *fakeRepo) UpdateUser(ctx context.Context, user *model.User) error {
+	f.user = *user
+	return nil
+}
+
+// codePattern finds the verification code in a message
+var codePattern = regexp.MustCompile(`\d{6}`)
+
+func newPhoneTestService(sender sms.Sender) (*user, *fakeRepo) {
+	repo := &fakeRepo{user: model.User{Id: "user-1", Phone: "+905321234567", Country: "TR"}}
+	svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend(), SMS: sender})
+	return svc, repo
+}
+
+// newTestService creates the service with the default configuration
+func newTestService(deps Deps) *user {
+	return NewUserService(deps, Config{
+		EmailChangeConfirmURL:   "https://example.com/email-change/confirm?token=",
+		EmailChangeRevertURL:    "https://example.com/email-change/revert?token=",
+		NicknameCheckRateLimit:  30,
+		NicknameCheckRateWindow: time.Minute,
+		PhoneCodeSecret:         []byte("secret"),
+		PhoneCodeAttemptLimit:   10,
+		PhoneCodeAttemptWindow:  time.Hour,
+	}).(*user)
+}
+
+func assertCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	var wrapped errwrap.IError
+	require.ErrorAs(t, err, &wrapped)
+	assert.Equal(t, code, wrapped.GrpcCode())
+}
+
+func TestVerifyPhone(t *testing.T) {
+	ctx := context.Background()
+	sender := &sms.Fake{}
+	svc, repo := newPhoneTestService(sender)
+
+	_, err := svc.SendPhoneVerification(ctx, "user-1")
+	require.NoError(t, err)
+	messages := sender.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "+905321234567", messages[0].To)
+	code := codePattern.FindString(messages[0].Text)
+	require.NotEmpty(t, code)
+	assert.NotEqual(t, code, repo.user.PhoneVerification.CodeHash)
+	assert.NotEqual(t, crypt.HashToken(code), repo.user.PhoneVerification.CodeHash, "codes are keyed with the secret")
+
+	// A new code is only sent after the resend interval
+	_, err = svc.SendPhoneVerification(ctx, "user-1")
+	assertCode(t, err, codes.ResourceExhausted)
+
+	_, err = svc.VerifyPhone(ctx, "user-1", "not the code")
+	assertCode(t, err, codes.InvalidArgument)
+	assert.Equal(t, 1, repo.user.PhoneVerification.Attempts)
+
+	verified, err := svc.VerifyPhone(ctx, "user-1", code)
+	require.NoError(t, err)
+	assert.True(t, verified.PhoneVerified)
+	assert.Nil(t, repo.user.PhoneVerification)
+
+	_, err = svc.SendPhoneVerification(ctx, "user-1")
+	assertCode(t, err, codes.FailedPrecondition)
+}
+
+func TestVerifyPhoneDiscardsCodeAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newPhoneTestService(&sms.Fake{})
+
+	_, err := svc.SendPhoneVerification(ctx, "user-1")
+	require.NoError(t, err)
+	for range maxPhoneCodeAttempts {
+		_, err = svc.VerifyPhone(ctx, "user-1", "wrong")
+		assertCode(t, err, codes.InvalidArgument)
+	}
+	assert.Nil(t, repo.user.PhoneVerification)
+
+	_, err = svc.VerifyPhone(ctx, "user-1", "wrong")
+	assertCode(t, err, codes.FailedPrecondition)
+}
+
+func TestVerifyPhoneLimitsAttemptsAcrossResends(t *testing.T) {
+	ctx := context.Background()
+	sender := &sms.Fake{}
+	svc, repo := newPhoneTestService(sender)
+
+	// Resent codes do not reset the 10 attempts of the number
+	for _, wrongCodes := range []int{4, 4, 2} {
+		_, err := svc.SendPhoneVerification(ctx, "user-1")
+		require.NoError(t, err)
+		for range wrongCodes {
+			_, err = svc.VerifyPhone(ctx, "user-1", "wrong")
+			assertCode(t, err, codes.InvalidArgument)
+		}
+		repo.user.PhoneVerification.SentAt = repo.user.PhoneVerification.SentAt.Add(-phoneCodeResendInterval)
+	}
+
+	messages := sender.Messages()
+	code := codePattern.FindString(messages[len(messages)-1].Text)
+	_, err := svc.VerifyPhone(ctx, "user-1", code)
+	assertCode(t, err, codes.ResourceExhausted)
+	assert.False(t, repo.user.PhoneVerified)
+}
+
+func TestVerifyPhoneVerifiedByAnotherUser(t *testing.T) {
+	ctx := context.Background()
+	sender := &sms.Fake{}
+	svc, repo := newPhoneTestService(sender)
+	repo.phoneVerifiedBy = "user-2"
+
+	// Unverified phones are not unique, a code can still be sent
+	_, err := svc.SendPhoneVerification(ctx, "user-1")
+	require.NoError(t, err)
+	code := codePattern.FindString(sender.Messages()[0].Text)
+
+	_, err = svc.VerifyPhone(ctx, "user-1", code)
+	assertCode(t, err, codes.AlreadyExists)
+	assert.False(t, repo.user.PhoneVerified)
+}
+
+func TestSendPhoneVerificationWithoutProvider(t *testing.T) {
+	svc, _ := newPhoneTestService(nil)
+
+	_, err := svc.SendPhoneVerification(context.Background(), "user-1")
+	assertCode(t, err, codes.FailedPrecondition)
+}
+
+func TestApplyPhone(t *testing.T) {
+	existing := &model.User{Phone: "+905321234567", PhoneVerified: true, Country: "TR"}
+
+	// The same number in national format keeps the verification
+	require.NoError(t, applyPhone(existing, model.User{Phone: "0532 123 45 67"}, []string{model.UserField_Phone}))
+	assert.True(t, existing.PhoneVerified)
+
+	// National numbers are read with the updated country
+	update := model.User{Phone: "(201) 555-0123", Country: "US"}
+	require.NoError(t, applyPhone(existing, update, []string{model.UserField_Phone, model.UserField_Country}))
+	assert.Equal(t, "+12015550123", existing.Phone)
+	assert.False(t, existing.PhoneVerified)
+
+	require.NoError(t, applyPhone(existing, model.User{}, []string{model.UserField_Phone}))
+	assert.Empty(t, existing.Phone)
+}
+
+func TestPrivateFieldsVisibility(t *testing.T) {
+	stored := model.User{
+		Id:            "user-1",
+		FirstName:     "Ahmet",
+		Status:        model.UserStatus_Active,
+		Phone:         "+905321234567",
+		PhoneVerified: true,
+		EmailChange:   &model.EmailChange{NewEmail: "new@example.com", ExpireTime: time.Now().Add(time.Hour)},
+		Restoration:   &model.UserStatusChange{By: "admin-1"},
+	}
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		wantPrivate bool
+	}{
+		{"other user", callerContext("user-2"), false},
+		{"user itself", callerContext("user-1"), true},
+		{"admin", callerContext("admin-1", auth.RoleAdmin), true},
+		{"service principal", servicePrincipalContext("billing"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := search.NewMemoryBackend()
+			require.NoError(t, backend.Index(context.Background(), &stored))
+			svc := newTestService(Deps{Repo: &fakeRepo{user: stored}, Search: backend})
+
+			found, err := svc.GetUser(tt.ctx, model.UserLookup{Id: "user-1"})
+			require.NoError(t, err)
+			hits, err := svc.SearchUsers(tt.ctx, "ahmet", 10)
+			require.NoError(t, err)
+			require.Len(t, hits, 1)
+
+			for _, user := range []*model.User{found, hits[0].User} {
+				pbUser := user.UserToProto()
+				assert.Equal(t, "Ahmet", pbUser.FirstName)
+				if tt.wantPrivate {
+					assert.Equal(t, "+905321234567", pbUser.Phone)
+					assert.True(t, pbUser.PhoneVerified)
+					assert.Equal(t, "new@example.com", pbUser.PendingEmail)
+					assert.Equal(t, "admin-1", pbUser.Restoration.GetBy())
+					continue
+				}
+				assert.Empty(t, pbUser.Phone)
+				assert.False(t, pbUser.PhoneVerified)
+				assert.Empty(t, pbUser.PendingEmail)
+				assert.Nil(t, pbUser.Restoration)
+			}
+		})
+	}
+}
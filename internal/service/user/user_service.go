@@ -2,39 +2,263 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/nsaltun/user-service-grpc/internal/attributes"
 	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/nickname"
 	"github.com/nsaltun/user-service-grpc/internal/repository"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/internal/service/security"
+	"github.com/nsaltun/user-service-grpc/internal/watch"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/blobstore"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/crypt"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/eventbus"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/filter"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/mail"
+	middleware "github.com/nsaltun/user-service-grpc/pkg/v1/middleware/grpc"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/phone"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/ratelimit"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/sms"
 	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
 	pb "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
 	typesv1 "github.com/nsaltun/user-service-grpc/proto/gen/go/shared/types/v1"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, user *model.User) (*model.User, error)
-	UpdateUserById(ctx context.Context, id string, user *model.User) (*model.User, error)
-	DeleteUser(ctx context.Context, id string) error
+	UpdateUserById(ctx context.Context, id string, user *model.User, updateMask []string, expectedVersion *int32) (*model.User, error)
+	DeleteUser(ctx context.Context, id string, reason string) error
+	RestoreUser(ctx context.Context, id string, reason string) (*model.User, error)
+	RequestErasure(ctx context.Context, id string, reason string) (*model.User, error)
 	ListUsersByFilter(ctx context.Context, filter *model.UserFilter) (*pb.ListUsersResponse, error)
+	GetUser(ctx context.Context, lookup model.UserLookup) (*model.User, error)
+	GetMe(ctx context.Context, id string) (*model.User, error)
+	UpdateMe(ctx context.Context, id string, user *model.User, updateMask []string, expectedVersion *int32, currentPassword string) (*model.User, error)
+	DeleteMe(ctx context.Context, id string) error
+	SearchUsers(ctx context.Context, query string, limit int) ([]*model.UserSearchHit, error)
+	ExportUsers(ctx context.Context, filter *model.UserFilter, resumeToken string, send func(user *model.User, resumeToken string) error) error
+	WatchUsers(ctx context.Context, resumeToken string, send func(change *model.UserChange) error) error
+	ImportUsers(ctx context.Context, input io.Reader, options model.ImportOptions) (*model.UserImportReport, error)
+	BatchGetUsers(ctx context.Context, ids []string) ([]*model.UserBatchResult, error)
+	BatchUpdateUserStatus(ctx context.Context, ids []string, status model.UserStatus, allOrNothing bool) ([]*model.UserBatchResult, error)
+	BatchDeleteUsers(ctx context.Context, ids []string, reason string, allOrNothing bool) ([]*model.UserBatchResult, error)
+	ExportUserData(ctx context.Context, id string) (*model.UserDataExport, error)
+	StoreUserDataExport(ctx context.Context, export *model.UserDataExport) (string, error)
+	SendPhoneVerification(ctx context.Context, id string) (time.Time, error)
+	VerifyPhone(ctx context.Context, id string, code string) (*model.User, error)
+	RequestEmailChange(ctx context.Context, id string, newEmail string, password string, revokeSessions bool) (time.Time, error)
+	ConfirmEmailChange(ctx context.Context, id string, token string) (*model.User, error)
+	RevertEmailChange(ctx context.Context, token string) error
+	AcceptInvite(ctx context.Context, token string, password string) error
+	CheckNicknameAvailability(ctx context.Context, nickName string) (*model.NicknameCheck, error)
+	FilterFields(ctx context.Context) filter.Schema
 }
 
+// TokenStore revokes and lists the issued tokens of a user
+type TokenStore interface {
+	InvalidateUserTokens(ctx context.Context, userID string) error
+	ListUserTokens(ctx context.Context, userID string) ([]auth.OpaqueToken, []auth.UserInvalidatedToken, error)
+}
+
+// fieldSet is a set of user fields
+type fieldSet map[string]bool
+
+var (
+	// updatableFields are all user fields that can be addressed by an update.
+	// The email is changed with RequestEmailChange only, so that the new address is verified.
+	updatableFields = fieldSet{
+		model.UserField_FirstName: true,
+		model.UserField_LastName:  true,
+		model.UserField_NickName:  true,
+		model.UserField_Email:     true,
+		model.UserField_Country:   true,
+		model.UserField_Status:    true,
+		model.UserField_Password:  true,
+		model.UserField_Phone:     true,
+
+		model.UserField_CustomAttributes: true,
+	}
+
+	// immutableFields can never be changed by an update
+	immutableFields = fieldSet{
+		"id":   true,
+		"meta": true,
+	}
+
+	// adminEditableFields can be changed through UpdateUserById
+	adminEditableFields = fieldSet{
+		model.UserField_FirstName: true,
+		model.UserField_LastName:  true,
+		model.UserField_NickName:  true,
+		model.UserField_Country:   true,
+		model.UserField_Status:    true,
+		model.UserField_Password:  true,
+		model.UserField_Phone:     true,
+
+		model.UserField_CustomAttributes: true,
+	}
+
+	// selfEditableFields can be changed by users on their own profile through UpdateMe
+	selfEditableFields = fieldSet{
+		model.UserField_FirstName: true,
+		model.UserField_LastName:  true,
+		model.UserField_NickName:  true,
+		model.UserField_Country:   true,
+		model.UserField_Password:  true,
+		model.UserField_Phone:     true,
+
+		model.UserField_CustomAttributes: true,
+	}
+)
+
 type user struct {
-	repo repository.Repository
+	repo       repository.Repository
+	events     security.Recorder
+	tokens     TokenStore
+	pageTokens *types.PageTokenCodec
+	search     search.Backend
+	watcher    watch.Watcher
+	publisher  eventbus.Publisher
+	blobs      blobstore.Store
+	attributes *attributes.Registry
+	nicknames  *nickname.Policy
+	sms        sms.Sender
+	mail       mail.Sender
+	emailLinks emailChangeLinks
+
+	// nicknameChecks limits the availability checks per client address
+	nicknameChecks *ratelimit.Limiter
+	// phoneCodeSecret keys the hashes of SMS verification codes
+	phoneCodeSecret []byte
+	// phoneCodeAttempts limits the verification attempts per phone number, across resent codes
+	phoneCodeAttempts *ratelimit.Limiter
+}
+
+// Deps are the collaborators of the user service. Repo and Search are required,
+// the others may be left nil where the corresponding feature is not used.
+type Deps struct {
+	Repo       repository.Repository
+	Events     security.Recorder
+	Tokens     TokenStore
+	PageTokens *types.PageTokenCodec
+	Search     search.Backend
+	Watcher    watch.Watcher
+	Publisher  eventbus.Publisher
+	Blobs      blobstore.Store
+	Attributes *attributes.Registry
+	Nicknames  *nickname.Policy
+	SMS        sms.Sender
+	Mail       mail.Sender
+}
+
+// Config holds the settings of the user service
+type Config struct {
+	// EmailChangeConfirmURL and EmailChangeRevertURL are completed with the token of the link
+	EmailChangeConfirmURL string
+	EmailChangeRevertURL  string
+
+	// NicknameCheckRateLimit availability checks are allowed per client address and NicknameCheckRateWindow
+	NicknameCheckRateLimit  int
+	NicknameCheckRateWindow time.Duration
+
+	// PhoneCodeSecret is the HMAC key of stored SMS verification codes
+	PhoneCodeSecret []byte
+	// PhoneCodeAttemptLimit verification attempts are allowed per phone number and PhoneCodeAttemptWindow
+	PhoneCodeAttemptLimit  int
+	PhoneCodeAttemptWindow time.Duration
 }
 
-func NewUserService(repo repository.Repository) UserService {
+// NewConfigFromEnv reads the user service configuration from environment
+func NewConfigFromEnv() Config {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault("EMAIL_CHANGE_CONFIRM_URL", "http://localhost:8080/email-change/confirm?token=")
+	vi.SetDefault("EMAIL_CHANGE_REVERT_URL", "http://localhost:8080/email-change/revert?token=")
+	vi.SetDefault("NICKNAME_CHECK_RATE_LIMIT", 30)
+	vi.SetDefault("NICKNAME_CHECK_RATE_WINDOW", "1m")
+	vi.SetDefault("PHONE_CODE_SECRET", "")
+	vi.SetDefault("PHONE_CODE_ATTEMPT_LIMIT", 10)
+	vi.SetDefault("PHONE_CODE_ATTEMPT_WINDOW", "1h")
+
+	// Without a secret a random one is generated, then pending codes are only valid on this instance until restart
+	phoneCodeSecret := []byte(vi.GetString("PHONE_CODE_SECRET"))
+	if len(phoneCodeSecret) == 0 {
+		slog.Warn("PHONE_CODE_SECRET is not set, phone verification codes are not valid across instances and restarts")
+		phoneCodeSecret = make([]byte, phoneCodeSecretBytes)
+		if _, err := rand.Read(phoneCodeSecret); err != nil {
+			panic(err)
+		}
+	}
+
+	return Config{
+		EmailChangeConfirmURL:   vi.GetString("EMAIL_CHANGE_CONFIRM_URL"),
+		EmailChangeRevertURL:    vi.GetString("EMAIL_CHANGE_REVERT_URL"),
+		NicknameCheckRateLimit:  vi.GetInt("NICKNAME_CHECK_RATE_LIMIT"),
+		NicknameCheckRateWindow: vi.GetDuration("NICKNAME_CHECK_RATE_WINDOW"),
+		PhoneCodeSecret:         phoneCodeSecret,
+		PhoneCodeAttemptLimit:   vi.GetInt("PHONE_CODE_ATTEMPT_LIMIT"),
+		PhoneCodeAttemptWindow:  vi.GetDuration("PHONE_CODE_ATTEMPT_WINDOW"),
+	}
+}
+
+func NewUserService(deps Deps, config Config) UserService {
 	return &user{
-		repo: repo,
+		repo:       deps.Repo,
+		events:     deps.Events,
+		tokens:     deps.Tokens,
+		pageTokens: deps.PageTokens,
+		search:     deps.Search,
+		watcher:    deps.Watcher,
+		publisher:  deps.Publisher,
+		blobs:      deps.Blobs,
+		attributes: deps.Attributes,
+		nicknames:  deps.Nicknames,
+		sms:        deps.SMS,
+		mail:       deps.Mail,
+		emailLinks: emailChangeLinks{
+			confirm: config.EmailChangeConfirmURL,
+			revert:  config.EmailChangeRevertURL,
+		},
+		nicknameChecks:    ratelimit.New(config.NicknameCheckRateLimit, config.NicknameCheckRateWindow),
+		phoneCodeSecret:   config.PhoneCodeSecret,
+		phoneCodeAttempts: ratelimit.New(config.PhoneCodeAttemptLimit, config.PhoneCodeAttemptWindow),
 	}
 }
 
 // User service implementations
 func (s *user) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	if err := validateNewUser(user); err != nil {
+		return nil, err
+	}
+	if err := s.attributes.Validate(user.CustomAttributes); err != nil {
+		return nil, err
+	}
+	if err := s.nicknames.Validate(user.NickName); err != nil {
+		return nil, err
+	}
+	if user.Phone != "" {
+		normalized, err := phone.Normalize(user.Phone, user.Country)
+		if err != nil {
+			return nil, err
+		}
+		user.Phone = normalized
+	}
+
 	hashedPwd, err := crypt.HashPassword(user.Password)
 	if err != nil {
 		if err == bcrypt.ErrPasswordTooLong {
@@ -52,35 +276,124 @@ func (s *user) CreateUser(ctx context.Context, user *model.User) (*model.User, e
 	if err := s.repo.CreateUser(ctx, user); err != nil {
 		return nil, err
 	}
+	s.index(ctx, user)
 	return user, nil
 }
 
-// UpdateUserById updates a user by their ID with partial updates
-func (s *user) UpdateUserById(ctx context.Context, id string, user *model.User) (*model.User, error) {
+// maxPasswordLength is the longest password bcrypt can hash
+const maxPasswordLength = 72
+
+// validateNewUser checks a user to be created, CreateUser and ImportUsers share the rules
+func validateNewUser(user *model.User) error {
+	if user.Email == "" {
+		return errwrap.NewError("email is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+	if len(user.Password) > maxPasswordLength {
+		return errwrap.NewError("password is too long", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+	return nil
+}
+
+// UpdateUserById updates a user by their ID with partial updates. It is reserved for admins.
+func (s *user) UpdateUserById(ctx context.Context, id string, user *model.User, updateMask []string, expectedVersion *int32) (*model.User, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, id, user, updateMask, expectedVersion, adminEditableFields, nil)
+}
+
+// UpdateMe updates the authenticated user, restricted to the self-editable fields.
+// Changing the password requires the current password.
+func (s *user) UpdateMe(ctx context.Context, id string, user *model.User, updateMask []string, expectedVersion *int32, currentPassword string) (*model.User, error) {
+	return s.updateUser(ctx, id, user, updateMask, expectedVersion, selfEditableFields, &currentPassword)
+}
+
+// updateUser applies a partial update restricted to the editable fields.
+// With an update mask exactly the masked fields are applied, otherwise the non-empty ones.
+// Without a mask an unchanged email is ignored, a changed one is rejected like a masked email.
+// If expectedVersion is given, the update fails when the stored user has another version.
+// If currentPassword is given, a password change fails unless it matches the stored password.
+func (s *user) updateUser(ctx context.Context, id string, user *model.User, updateMask []string, expectedVersion *int32, editable fieldSet, currentPassword *string) (*model.User, error) {
+	paths := updateMask
+	if len(paths) == 0 {
+		// Without a mask clients send the whole user back, its email is compared with the stored one below
+		paths = slices.DeleteFunc(providedFields(*user), func(path string) bool { return path == model.UserField_Email })
+	}
+	if err := validateUpdateMask(paths, editable); err != nil {
+		return nil, err
+	}
+
 	// Get existing user to check if exists and merge updates
 	existingUser, err := s.repo.GetUserById(ctx, id)
 	if err != nil {
 		return nil, err // Repository should already return appropriate error
 	}
+	if len(updateMask) == 0 && user.Email != "" && user.Email != existingUser.Email {
+		return nil, errwrap.NewError("email is changed with RequestEmailChange", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+	if expectedVersion != nil && *expectedVersion != existingUser.Version {
+		return nil, errwrap.NewError("user version does not match the expected version", codes.FailedPrecondition.String()).
+			SetGrpcCode(codes.FailedPrecondition)
+	}
+
+	// Inactive users are only activated by RestoreUser, which checks their email, nickname and phone again
+	if slices.Contains(paths, model.UserField_Status) && user.Status == model.UserStatus_Active && existingUser.Status != model.UserStatus_Active {
+		return nil, errwrap.NewError("inactive users are restored with RestoreUser", codes.FailedPrecondition.String()).
+			SetGrpcCode(codes.FailedPrecondition)
+	}
+
+	if currentPassword != nil && slices.Contains(paths, model.UserField_Password) {
+		if err := bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(*currentPassword)); err != nil {
+			return nil, errwrap.NewError("current password is incorrect", codes.PermissionDenied.String()).SetGrpcCode(codes.PermissionDenied)
+		}
+	}
+
+	// A nickname that is not allowed any more can be kept, but not chosen
+	if slices.Contains(paths, model.UserField_NickName) && user.NickName != existingUser.NickName {
+		if err := s.nicknames.Validate(user.NickName); err != nil {
+			return nil, err
+		}
+	}
 
-	// Update only provided fields (partial update)
-	err = applyPartialUpdates(existingUser, *user)
+	// Update only masked fields (partial update)
+	previousStatus := existingUser.Status
+	err = applyPartialUpdates(existingUser, *user, paths)
 	if err != nil {
 		return nil, err
 	}
+	if namespaces, ok := updatedNamespaces(paths); ok {
+		if err := s.attributes.Validate(existingUser.CustomAttributes, namespaces...); err != nil {
+			return nil, err
+		}
+	}
 
 	// Update metadata
 	existingUser.Meta.Update()
+	event := recordStatusChange(ctx, existingUser, previousStatus, "")
 
 	// Save updated user
 	if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
 		return nil, err
 	}
+	s.index(ctx, existingUser)
+	s.emitLifecycle(ctx, event)
+
+	if slices.Contains(paths, model.UserField_Password) {
+		afterCommit(ctx, func(ctx context.Context) {
+			s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_PasswordChanged, UserID: existingUser.Id})
+		})
+	}
 
 	return existingUser, nil
 }
 
-func (s *user) DeleteUser(ctx context.Context, id string) error {
+// DeleteUser soft deletes the user by deactivating it, the reason is recorded with the deactivation.
+// Only the user itself and admins may delete a user.
+func (s *user) DeleteUser(ctx context.Context, id string, reason string) error {
+	if err := requireSelfOrAdmin(ctx, id); err != nil {
+		return err
+	}
+
 	// Check if user exists
 	existingUser, err := s.repo.GetUserById(ctx, id)
 	if err != nil {
@@ -93,73 +406,365 @@ func (s *user) DeleteUser(ctx context.Context, id string) error {
 	// Soft delete by updating status to Deleted
 	existingUser.Status = model.UserStatus_Inactive
 	existingUser.Meta.Update()
+	event := recordStatusChange(ctx, existingUser, model.UserStatus_Active, reason)
 
 	// Save updated user
 	if err := s.repo.UpdateUser(ctx, existingUser); err != nil {
 		return err
 	}
+	s.index(ctx, existingUser)
+	s.emitLifecycle(ctx, event)
 
 	return nil
 }
 
+// GetMe returns the authenticated user
+func (s *user) GetMe(ctx context.Context, id string) (*model.User, error) {
+	return s.repo.GetUserById(ctx, id)
+}
+
+// DeleteMe soft deletes the authenticated user and revokes all of its tokens
+func (s *user) DeleteMe(ctx context.Context, id string) error {
+	if err := s.DeleteUser(ctx, id, "account deleted"); err != nil {
+		return err
+	}
+
+	if err := s.tokens.InvalidateUserTokens(ctx, id); err != nil {
+		return errwrap.ErrInternal.SetMessage("failed to invalidate tokens").SetOriginError(err)
+	}
+
+	s.events.Record(ctx, &model.SecurityEvent{Type: model.SecurityEvent_SessionRevoked, UserID: id, Reason: "account deleted"})
+	return nil
+}
+
 func (s *user) ListUsersByFilter(ctx context.Context, filter *model.UserFilter) (*pb.ListUsersResponse, error) {
+	if filter.Email != "" && !isAdminOrService(ctx) {
+		return nil, errwrap.ErrPermissionDenied.SetMessage("not allowed to filter users by email")
+	}
+
 	filterBsonMap := filter.ToBson()
+	fingerprint := filter.Fingerprint()
+
+	// Continue after the last user of the previous page
+	if filter.Pagination.PageToken != "" {
+		after, err := s.pageTokens.Decode(filter.Pagination.PageToken, fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		if len(after) != len(filter.Sort) {
+			return nil, errwrap.NewError("invalid page token", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		}
+		filter.Pagination.After = after
+	}
 
 	// Get users from repository with filter
-	users, total, err := s.repo.ListUsers(ctx, filterBsonMap, filter.Pagination)
+	users, total, nextKey, err := s.repo.ListUsers(ctx, filterBsonMap, filter.Sort, filter.Pagination)
 	if err != nil {
 		return nil, err
 	}
 
+	var nextPageToken string
+	if nextKey != nil {
+		nextPageToken, err = s.pageTokens.Encode(nextKey, fingerprint)
+		if err != nil {
+			return nil, errwrap.ErrInternal.SetMessage("failed to issue page token").SetOriginError(err)
+		}
+	}
+
 	// Convert model users to proto users more efficiently
 	pbUsers := make([]*pb.User, 0, len(users)) // Pre-allocate with capacity
 	for _, user := range users {
-		pbUsers = append(pbUsers, user.UserToProto())
+		pbUsers = append(pbUsers, visibleUser(ctx, user).UserToProto())
 	}
 
 	return &pb.ListUsersResponse{
 		Users: pbUsers,
 		Params: &typesv1.Pagination{
 			TotalRecords:  total,
-			HasNext:       (filter.Pagination.Offset + filter.Pagination.Limit) < total,
-			HasPrevious:   filter.Pagination.Offset > 0,
+			HasNext:       nextKey != nil,
+			HasPrevious:   filter.Pagination.Offset > 0 || filter.Pagination.PageToken != "",
 			CurrentLimit:  filter.Pagination.Limit,
 			CurrentOffset: filter.Pagination.Offset,
+			NextPageToken: nextPageToken,
 		},
 	}, nil
 }
 
-// applyPartialUpdates updates only provided fields from source to target user
-func applyPartialUpdates(existingUser *model.User, user model.User) error {
+// ExportUsers passes every user matching the filter to send, in the order of filter.Sort.
+// Each user comes with a resume token, which continues the export after that user when passed as resumeToken.
+// Exporting is reserved for admins.
+func (s *user) ExportUsers(ctx context.Context, filter *model.UserFilter, resumeToken string, send func(user *model.User, resumeToken string) error) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	fingerprint := filter.Fingerprint()
+
+	var after []bson.RawValue
+	if resumeToken != "" {
+		var err error
+		after, err = s.pageTokens.Decode(resumeToken, fingerprint)
+		if err != nil {
+			return err
+		}
+		if len(after) != len(filter.Sort) {
+			return errwrap.NewError("invalid resume token", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		}
+	}
+
+	return s.repo.StreamUsers(ctx, filter.ToBson(), filter.Sort, after, func(user *model.User, key []bson.RawValue) error {
+		token, err := s.pageTokens.Encode(key, fingerprint)
+		if err != nil {
+			return errwrap.ErrInternal.SetMessage("failed to issue resume token").SetOriginError(err)
+		}
+		return send(user, token)
+	})
+}
+
+// WatchUsers passes every change of a user after resumeToken to send, until ctx is done.
+// Without a resume token only changes from now on are sent. Watching is reserved for admins.
+func (s *user) WatchUsers(ctx context.Context, resumeToken string, send func(change *model.UserChange) error) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	return s.watcher.Watch(ctx, resumeToken, send)
+}
+
+// GetUser looks up a single user by id, email or nickname.
+//
+// Lookups by email are only allowed for the owner of the address and internal service principals,
+// any other caller gets PermissionDenied whether the address is registered or not.
+// Inactive users are only visible to themselves and internal service principals, others get NotFound.
+// The phone, pending email and status change records are only returned to the user itself, admins and services.
+func (s *user) GetUser(ctx context.Context, lookup model.UserLookup) (*model.User, error) {
+	callerID, _ := middleware.GetUserID(ctx)
+	_, isService := middleware.GetServicePrincipal(ctx)
+
+	var (
+		found *model.User
+		err   error
+	)
+	switch {
+	case lookup.Id != "":
+		found, err = s.repo.GetUserById(ctx, lookup.Id)
+	case lookup.Email != "":
+		found, err = s.repo.GetUserByEmail(ctx, lookup.Email)
+		if !isService && (err != nil || found.Id != callerID) {
+			if err != nil && !isNotFound(err) {
+				return nil, err
+			}
+			return nil, errwrap.ErrPermissionDenied.SetMessage("not allowed to look up users by email")
+		}
+	case lookup.NickName != "":
+		found, err = s.repo.GetUserByNickName(ctx, lookup.NickName)
+	default:
+		return nil, errwrap.NewError("one of id, email or nick_name is required", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Hide deactivated users from everyone but themselves and internal services
+	if found.Status == model.UserStatus_Inactive && found.Id != callerID && !isService {
+		return nil, errwrap.NewError("user not found", codes.NotFound.String()).SetGrpcCode(codes.NotFound)
+	}
+
+	return visibleUser(ctx, found), nil
+}
+
+// isNotFound reports whether err is a NotFound application error
+func isNotFound(err error) bool {
+	ierr, ok := err.(errwrap.IError)
+	return ok && ierr.GrpcCode() == codes.NotFound
+}
+
+// providedFields returns the paths of the non-empty fields of user.
+// It keeps the behaviour of updates without a field mask where empty means "not provided".
+func providedFields(user model.User) []string {
+	var paths []string
 	if user.FirstName != "" {
-		existingUser.FirstName = user.FirstName
+		paths = append(paths, model.UserField_FirstName)
 	}
 	if user.LastName != "" {
-		existingUser.LastName = user.LastName
+		paths = append(paths, model.UserField_LastName)
 	}
 	if user.NickName != "" {
-		existingUser.NickName = user.NickName
+		paths = append(paths, model.UserField_NickName)
 	}
 	if user.Email != "" {
-		existingUser.Email = user.Email
+		paths = append(paths, model.UserField_Email)
 	}
 	if user.Country != "" {
-		existingUser.Country = user.Country
+		paths = append(paths, model.UserField_Country)
 	}
 	if user.Status != model.UserStatus_Unspecified {
-		existingUser.Status = user.Status
+		paths = append(paths, model.UserField_Status)
 	}
 	if user.Password != "" {
-		// Hash new password if provided
-		hashedPwd, err := crypt.HashPassword(user.Password)
-		if err != nil {
-			if err == bcrypt.ErrPasswordTooLong {
-				return errwrap.NewError("password is too long", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		paths = append(paths, model.UserField_Password)
+	}
+	if user.Phone != "" {
+		paths = append(paths, model.UserField_Phone)
+	}
+	// Only the given namespaces are replaced, the others are kept
+	for _, namespace := range slices.Sorted(maps.Keys(user.CustomAttributes)) {
+		paths = append(paths, model.UserField_CustomAttributes+"."+namespace)
+	}
+	return paths
+}
+
+// updatedNamespaces returns the attribute namespaces addressed by the paths, none if all attributes are replaced.
+// ok is false if the paths do not touch custom attributes.
+func updatedNamespaces(paths []string) (namespaces []string, ok bool) {
+	for _, path := range paths {
+		if path == model.UserField_CustomAttributes {
+			return nil, true
+		}
+		if namespace, found := strings.CutPrefix(path, model.UserField_CustomAttributes+"."); found {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces, len(namespaces) > 0
+}
+
+// validateUpdateMask rejects immutable, unknown and non-editable field paths
+func validateUpdateMask(paths []string, editable fieldSet) error {
+	for _, path := range paths {
+		root, _, _ := strings.Cut(path, ".")
+		// custom_attributes.<namespace> is checked against the registered namespaces after the update
+		field := path
+		if root == model.UserField_CustomAttributes {
+			field = root
+		}
+		switch {
+		case immutableFields[root]:
+			return errwrap.NewError(path+" is immutable", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		case !updatableFields[field]:
+			return errwrap.NewError("unknown field path "+path, codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		case field == model.UserField_Email:
+			return errwrap.NewError("email is changed with RequestEmailChange", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		case !editable[field]:
+			return errwrap.NewError(path+" is not editable", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		}
+	}
+	return nil
+}
+
+// applyPartialUpdates copies exactly the fields in paths from source to target user.
+// Paths must be validated with validateUpdateMask beforehand.
+func applyPartialUpdates(existingUser *model.User, user model.User, paths []string) error {
+	for _, path := range paths {
+		switch path {
+		case model.UserField_FirstName:
+			existingUser.FirstName = user.FirstName
+		case model.UserField_LastName:
+			existingUser.LastName = user.LastName
+		case model.UserField_NickName:
+			existingUser.NickName = user.NickName
+		case model.UserField_Country:
+			existingUser.Country = user.Country
+		case model.UserField_Status:
+			if user.Status == model.UserStatus_Unspecified {
+				return errwrap.NewError("status cannot be unspecified", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+			}
+			existingUser.Status = user.Status
+		case model.UserField_Password:
+			if user.Password == "" {
+				return errwrap.NewError("password cannot be empty", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
 			}
-			slog.Warn("hash password error", "error", err)
-			return errwrap.NewError("unexpected error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+			// Hash new password if provided
+			hashedPwd, err := crypt.HashPassword(user.Password)
+			if err != nil {
+				if err == bcrypt.ErrPasswordTooLong {
+					return errwrap.NewError("password is too long", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+				}
+				slog.Warn("hash password error", "error", err)
+				return errwrap.NewError("unexpected error", codes.Internal.String()).SetGrpcCode(codes.Internal).SetOriginError(err)
+			}
+			existingUser.Password = hashedPwd
+		case model.UserField_Phone:
+			if err := applyPhone(existingUser, user, paths); err != nil {
+				return err
+			}
+		case model.UserField_CustomAttributes:
+			existingUser.CustomAttributes = user.CustomAttributes
+		default:
+			// custom_attributes.<namespace> replaces the namespace, or removes it if the update does not have it
+			namespace := strings.TrimPrefix(path, model.UserField_CustomAttributes+".")
+			value, ok := user.CustomAttributes[namespace]
+			if !ok {
+				delete(existingUser.CustomAttributes, namespace)
+				continue
+			}
+			if existingUser.CustomAttributes == nil {
+				existingUser.CustomAttributes = model.UserAttributes{}
+			}
+			existingUser.CustomAttributes[namespace] = value
+		}
+	}
+	return nil
+}
+
+// FilterFields returns the fields the caller can use in filter expressions besides model.UserFilterSchema:
+// the filterable custom attributes, and the email and phone for admins and internal services
+func (s *user) FilterFields(ctx context.Context) filter.Schema {
+	if !isAdminOrService(ctx) {
+		return s.attributes.FilterFields()
+	}
+	fields := maps.Clone(model.UserPrivateFilterSchema)
+	maps.Copy(fields, s.attributes.FilterFields())
+	return fields
+}
+
+// applyPhone sets the normalized phone of the update, an empty phone removes it.
+// National numbers are read with the country of the user, as it is after the update.
+// A changed phone must be verified again.
+func applyPhone(existingUser *model.User, user model.User, paths []string) error {
+	normalized := ""
+	if user.Phone != "" {
+		country := existingUser.Country
+		if slices.Contains(paths, model.UserField_Country) {
+			country = user.Country
+		}
+		var err error
+		if normalized, err = phone.Normalize(user.Phone, country); err != nil {
+			return err
 		}
-		existingUser.Password = hashedPwd
+	}
+
+	if normalized != existingUser.Phone {
+		existingUser.Phone = normalized
+		existingUser.PhoneVerified = false
+		existingUser.PhoneVerification = nil
 	}
 	return nil
 }
+
+// SearchUsers finds active users whose names or nickname start with every term of the query.
+// Private fields of other users are left out like in GetUser.
+func (s *user) SearchUsers(ctx context.Context, query string, limit int) ([]*model.UserSearchHit, error) {
+	terms := model.SearchTerms(query)
+	if len(terms) == 0 {
+		return nil, errwrap.NewError(fmt.Sprintf("query must contain a word of at least %d characters", model.SearchMinTermLength), codes.InvalidArgument.String()).
+			SetGrpcCode(codes.InvalidArgument)
+	}
+
+	hits, err := s.search.Search(ctx, terms, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, hit := range hits {
+		hit.User = visibleUser(ctx, hit.User)
+	}
+	return hits, nil
+}
+
+// index updates the user in the search backend. The user is already saved, so failures are only logged.
+// Within a transaction of batchWrite the user is indexed after the commit.
+func (s *user) index(ctx context.Context, user *model.User) {
+	afterCommit(ctx, func(ctx context.Context) {
+		if err := s.search.Index(ctx, user); err != nil {
+			slog.WarnContext(ctx, "failed to index user for search", slog.String("user_id", user.Id), slog.Any("error", err))
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/attributes"
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestFilterByEmailAndPhoneRequiresAdmin(t *testing.T) {
+	svc := newTestService(Deps{Repo: &fakeRepo{}, Search: search.NewMemoryBackend(), Attributes: attributes.NewRegistry("")})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		allowed bool
+	}{
+		{"user", callerContext("user-1"), false},
+		{"admin", callerContext("admin-1", auth.RoleAdmin), true},
+		{"service principal", servicePrincipalContext("billing"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, expression := range []string{`email:"@corp.com"`, `phone = "+90*"`} {
+				filter := &model.UserFilter{Expression: expression}
+				err := filter.ParseExpression(svc.FilterFields(tt.ctx))
+				if tt.allowed {
+					assert.NoError(t, err, expression)
+				} else {
+					assert.Error(t, err, expression)
+				}
+			}
+
+			filter := &model.UserFilter{Expression: `first_name = "Ahmet"`}
+			assert.NoError(t, filter.ParseExpression(svc.FilterFields(tt.ctx)), "other fields are available to everyone")
+
+			if !tt.allowed {
+				_, err := svc.ListUsersByFilter(tt.ctx, &model.UserFilter{Email: "ahmet@example.com"})
+				assertCode(t, err, codes.PermissionDenied)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/crypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+)
+
+func TestUpdateMePasswordRequiresCurrentPassword(t *testing.T) {
+	ctx := context.Background()
+	password, err := crypt.HashPassword("secret")
+	require.NoError(t, err)
+	repo := &fakeRepo{user: model.User{Id: "user-1", FirstName: "Ahmet", Password: password}}
+	events := &fakeRecorder{}
+	svc := newTestService(Deps{Repo: repo, Events: events, Search: search.NewMemoryBackend()})
+	update := &model.User{Password: "new secret"}
+	mask := []string{model.UserField_Password}
+
+	for _, current := range []string{"", "wrong"} {
+		_, err = svc.UpdateMe(ctx, "user-1", update, mask, nil, current)
+		assertCode(t, err, codes.PermissionDenied)
+		assert.Equal(t, password, repo.user.Password)
+	}
+	// Without a mask the password is detected as provided field
+	_, err = svc.UpdateMe(ctx, "user-1", &model.User{Password: "new secret"}, nil, nil, "")
+	assertCode(t, err, codes.PermissionDenied)
+	assert.Empty(t, events.types)
+
+	_, err = svc.UpdateMe(ctx, "user-1", update, mask, nil, "secret")
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(repo.user.Password), []byte("new secret")))
+	assert.Equal(t, []model.SecurityEventType{model.SecurityEvent_PasswordChanged}, events.types)
+
+	// Other fields are changed without the password
+	updated, err := svc.UpdateMe(ctx, "user-1", &model.User{FirstName: "Mehmet"}, []string{model.UserField_FirstName}, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Mehmet", updated.FirstName)
+
+	// Admins set passwords without knowing the current one
+	_, err = svc.UpdateUserById(callerContext("admin-1", auth.RoleAdmin), "user-1", &model.User{Password: "reset"}, mask, nil)
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(repo.user.Password), []byte("reset")))
+}
+
+func TestUpdateUserByIdRequiresAdmin(t *testing.T) {
+	repo := &fakeRepo{user: model.User{Id: "user-1", Status: model.UserStatus_Active, Password: "hash"}}
+	svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend()})
+
+	// Users cannot bypass the self-editable fields and the current password on their own profile
+	for _, update := range []struct {
+		user model.User
+		mask []string
+	}{
+		{model.User{Status: model.UserStatus_Inactive}, []string{model.UserField_Status}},
+		{model.User{Password: "new secret"}, []string{model.UserField_Password}},
+		{model.User{FirstName: "Mehmet"}, nil},
+	} {
+		_, err := svc.UpdateUserById(callerContext("user-1"), "user-1", &update.user, update.mask, nil)
+		assertCode(t, err, codes.PermissionDenied)
+	}
+	_, err := svc.UpdateUserById(callerContext("user-2"), "user-1", &model.User{FirstName: "Mehmet"}, nil, nil)
+	assertCode(t, err, codes.PermissionDenied)
+	assert.Equal(t, model.User{Id: "user-1", Status: model.UserStatus_Active, Password: "hash"}, repo.user)
+}
+
+func TestUpdateUserByIdDoesNotRestore(t *testing.T) {
+	ctx := callerContext("admin-1", auth.RoleAdmin)
+	repo := &fakeRepo{user: model.User{Id: "user-1", Status: model.UserStatus_Inactive}}
+	svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend()})
+	mask := []string{model.UserField_Status}
+
+	_, err := svc.UpdateUserById(ctx, "user-1", &model.User{Status: model.UserStatus_Active}, mask, nil)
+	assertCode(t, err, codes.FailedPrecondition)
+	assert.Equal(t, model.UserStatus_Inactive, repo.user.Status)
+	assert.Nil(t, repo.user.Restoration)
+
+	// An active user keeps its status
+	repo.user.Status = model.UserStatus_Active
+	_, err = svc.UpdateUserById(ctx, "user-1", &model.User{Status: model.UserStatus_Active}, mask, nil)
+	require.NoError(t, err)
+}
+
+func TestUpdateWithoutMaskKeepsUnchangedEmail(t *testing.T) {
+	repo := &fakeRepo{user: model.User{Id: "user-1", Email: "ahmet@example.com", FirstName: "Ahmet", Status: model.UserStatus_Active}}
+	svc := newTestService(Deps{Repo: repo, Search: search.NewMemoryBackend()})
+
+	// The whole user is sent back with its unchanged email
+	updated, err := svc.UpdateMe(context.Background(), "user-1", &model.User{Email: "ahmet@example.com", FirstName: "Mehmet"}, nil, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Mehmet", updated.FirstName)
+
+	_, err = svc.UpdateMe(context.Background(), "user-1", &model.User{Email: "mehmet@example.com", FirstName: "Ali"}, nil, nil, "")
+	assertCode(t, err, codes.InvalidArgument)
+	assert.Equal(t, "ahmet@example.com", repo.user.Email)
+	assert.Equal(t, "Mehmet", repo.user.FirstName)
+}
+
+func TestValidateUpdateMask(t *testing.T) {
+	tests := []struct {
+		name     string
+		paths    []string
+		editable fieldSet
+		wantErr  string
+	}{
+		{"editable fields", []string{model.UserField_FirstName, model.UserField_Phone}, selfEditableFields, ""},
+		{"empty mask", nil, selfEditableFields, ""},
+		{"custom attribute namespace", []string{"custom_attributes.billing"}, selfEditableFields, ""},
+		{"all custom attributes", []string{model.UserField_CustomAttributes}, adminEditableFields, ""},
+		{"unknown field", []string{"nickname"}, adminEditableFields, "unknown field path nickname"},
+		{"unknown nested field", []string{"first_name.value"}, adminEditableFields, "unknown field path first_name.value"},
+		{"unknown after valid field", []string{model.UserField_LastName, "roles"}, adminEditableFields, "unknown field path roles"},
+		{"immutable id", []string{"id"}, adminEditableFields, "id is immutable"},
+		{"immutable nested meta", []string{"meta.created_at"}, adminEditableFields, "meta.created_at is immutable"},
+		{"email", []string{model.UserField_Email}, adminEditableFields, "email is changed with RequestEmailChange"},
+		{"status by the user", []string{model.UserField_Status}, selfEditableFields, "status is not editable"},
+		{"status by an admin", []string{model.UserField_Status}, adminEditableFields, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUpdateMask(tt.paths, tt.editable)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assertCode(t, err, codes.InvalidArgument)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestApplyPartialUpdates(t *testing.T) {
+	existing := func() model.User {
+		return model.User{
+			FirstName: "Ahmet",
+			LastName:  "Yilmaz",
+			Country:   "TR",
+			Status:    model.UserStatus_Active,
+			CustomAttributes: model.UserAttributes{
+				"billing":  map[string]any{"plan": "free"},
+				"settings": map[string]any{"theme": "dark"},
+			},
+		}
+	}
+	update := model.User{
+		FirstName: "Mehmet",
+		LastName:  "Demir",
+		Country:   "DE",
+		CustomAttributes: model.UserAttributes{
+			"billing": map[string]any{"plan": "pro"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		paths   []string
+		update  *model.User // the shared update if nil
+		want    func(*model.User)
+		wantErr string
+	}{
+		{
+			name:  "only masked fields",
+			paths: []string{model.UserField_FirstName},
+			want:  func(u *model.User) { u.FirstName = "Mehmet" },
+		},
+		{
+			name:   "masked field cleared",
+			paths:  []string{model.UserField_LastName},
+			update: &model.User{FirstName: "Mehmet"},
+			want:   func(u *model.User) { u.LastName = "" },
+		},
+		{
+			name:  "nested namespace replaced",
+			paths: []string{"custom_attributes.billing"},
+			want:  func(u *model.User) { u.CustomAttributes["billing"] = map[string]any{"plan": "pro"} },
+		},
+		{
+			name:  "nested namespace removed",
+			paths: []string{"custom_attributes.settings"},
+			want:  func(u *model.User) { delete(u.CustomAttributes, "settings") },
+		},
+		{
+			name:  "all custom attributes replaced",
+			paths: []string{model.UserField_CustomAttributes},
+			want: func(u *model.User) {
+				u.CustomAttributes = model.UserAttributes{"billing": map[string]any{"plan": "pro"}}
+			},
+		},
+		{
+			name:    "unspecified status",
+			paths:   []string{model.UserField_Status},
+			wantErr: "status cannot be unspecified",
+		},
+		{
+			name:    "empty password",
+			paths:   []string{model.UserField_Password},
+			wantErr: "password cannot be empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := update
+			if tt.update != nil {
+				source = *tt.update
+			}
+			got := existing()
+			err := applyPartialUpdates(&got, source, tt.paths)
+			if tt.wantErr != "" {
+				assertCode(t, err, codes.InvalidArgument)
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			want := existing()
+			tt.want(&want)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	t.Run("nested namespace without attributes", func(t *testing.T) {
+		var got model.User
+		require.NoError(t, applyPartialUpdates(&got, update, []string{"custom_attributes.billing"}))
+		assert.Equal(t, model.UserAttributes{"billing": map[string]any{"plan": "pro"}}, got.CustomAttributes)
+	})
+}
@@ -0,0 +1,45 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/internal/search"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+// fakeWatcher sends its changes once
+type fakeWatcher struct {
+	changes []*model.UserChange
+}
+
+func (f *fakeWatcher) Watch(ctx context.Context, resumeToken string, fn func(change *model.UserChange) error) error {
+	for _, change := range f.changes {
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestWatchUsersRequiresAdmin(t *testing.T) {
+	watcher := &fakeWatcher{changes: []*model.UserChange{{User: &model.User{Id: "user-1"}}}}
+	svc := newTestService(Deps{Repo: &fakeRepo{}, Search: search.NewMemoryBackend(), Watcher: watcher})
+
+	var received []*model.UserChange
+	send := func(change *model.UserChange) error {
+		received = append(received, change)
+		return nil
+	}
+
+	err := svc.WatchUsers(callerContext("user-1"), "", send)
+	assertCode(t, err, codes.PermissionDenied)
+	assert.Empty(t, received)
+
+	require.NoError(t, svc.WatchUsers(callerContext("admin-1", auth.RoleAdmin), "", send))
+	assert.Len(t, received, 1)
+}
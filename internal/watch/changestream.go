@@ -0,0 +1,102 @@
+package watch
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/status"
+)
+
+// changeStreamTokenPrefix marks resume tokens of change streams
+const changeStreamTokenPrefix = "cs."
+
+// changeEvent is the part of a change stream event used to build a UserChange
+type changeEvent struct {
+	OperationType string              `bson:"operationType"`
+	FullDocument  model.User          `bson:"fullDocument"`
+	ClusterTime   primitive.Timestamp `bson:"clusterTime"`
+
+	// DocumentKey identifies the user of a delete event, which has no full document
+	DocumentKey struct {
+		Id string `bson:"_id"`
+	} `bson:"documentKey"`
+}
+
+// changeStreamWatcher watches the users collection with a MongoDB change stream
+type changeStreamWatcher struct {
+	collection *mongo.Collection
+}
+
+// NewChangeStreamWatcher creates a watcher based on change streams of the collection
+func NewChangeStreamWatcher(collection *mongo.Collection) Watcher {
+	return &changeStreamWatcher{collection: collection}
+}
+
+func (w *changeStreamWatcher) Watch(ctx context.Context, resumeToken string, fn func(change *model.UserChange) error) error {
+	// Passwords never leave the database
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}}}}},
+		{{Key: "$project", Value: bson.M{"fullDocument.password": 0}}},
+	}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeToken != "" {
+		token, err := decodeChangeStreamToken(resumeToken)
+		if err != nil {
+			return err
+		}
+		opts.SetStartAfter(token)
+	}
+
+	stream, err := w.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event changeEvent
+		if err := stream.Decode(&event); err != nil {
+			slog.WarnContext(ctx, "failed to decode user change event", slog.Any("error", err))
+			return err
+		}
+
+		token := changeStreamTokenPrefix + base64.RawURLEncoding.EncodeToString(stream.ResumeToken())
+		var change *model.UserChange
+		if event.OperationType == "delete" {
+			change = model.NewUserDeletion(event.DocumentKey.Id, time.Unix(int64(event.ClusterTime.T), 0), token)
+		} else {
+			change = model.NewUserChange(&event.FullDocument, event.OperationType == "insert", token)
+		}
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return stream.Err()
+}
+
+func decodeChangeStreamToken(resumeToken string) (bson.Raw, error) {
+	encoded, found := strings.CutPrefix(resumeToken, changeStreamTokenPrefix)
+	if !found {
+		return nil, errInvalidResumeToken
+	}
+
+	token, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || bson.Raw(token).Validate() != nil {
+		return nil, errInvalidResumeToken
+	}
+	return bson.Raw(token), nil
+}
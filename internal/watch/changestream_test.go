@@ -0,0 +1,81 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestChangeStreamWatcher(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("insert, update and delete", func(mt *mtest.T) {
+		watcher := NewChangeStreamWatcher(mt.Coll)
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		updatedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		deletedAt := updatedAt.Add(time.Hour)
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, ns, mtest.FirstBatch,
+			changeEventDoc("1", "insert", bson.D{{Key: "_id", Value: "user-1"}, {Key: "status", Value: model.UserStatus_Active}}, updatedAt),
+			changeEventDoc("2", "update", bson.D{{Key: "_id", Value: "user-1"}, {Key: "status", Value: model.UserStatus_Inactive}}, updatedAt),
+			changeEventDoc("3", "delete", nil, deletedAt),
+		))
+
+		var changes []*model.UserChange
+		errDone := errors.New("done")
+		err := watcher.Watch(context.Background(), "", func(change *model.UserChange) error {
+			changes = append(changes, change)
+			if len(changes) == 3 {
+				return errDone
+			}
+			return nil
+		})
+		require.ErrorIs(mt, err, errDone)
+
+		watch := mt.GetStartedEvent()
+		require.Equal(mt, "aggregate", watch.CommandName)
+		match := watch.Command.Lookup("pipeline").Array().Index(1).Value().Document().Lookup("$match", "operationType", "$in").Array()
+		operations, err := match.Values()
+		require.NoError(mt, err)
+		var operationTypes []string
+		for _, operation := range operations {
+			operationTypes = append(operationTypes, operation.StringValue())
+		}
+		assert.Equal(mt, []string{"insert", "update", "replace", "delete"}, operationTypes)
+
+		require.Len(mt, changes, 3)
+		assert.Equal(mt, model.UserChange_Created, changes[0].Type)
+		assert.Equal(mt, model.UserChange_Deactivated, changes[1].Type)
+
+		// A delete event has no full document, the user is identified by the document key
+		deletion := changes[2]
+		assert.Equal(mt, model.UserChange_Deleted, deletion.Type)
+		assert.Equal(mt, &model.User{Id: "user-1"}, deletion.User)
+		assert.True(mt, deletedAt.Equal(deletion.ChangeTime))
+		assert.NotEqual(mt, changes[1].ResumeToken, deletion.ResumeToken)
+		token, err := decodeChangeStreamToken(deletion.ResumeToken)
+		require.NoError(mt, err)
+		assert.Equal(mt, "3", token.Lookup("_data").StringValue())
+	})
+}
+
+// changeEventDoc is a change stream event of user-1, the full document is left out for deletes
+func changeEventDoc(resumeToken string, operationType string, fullDocument bson.D, clusterTime time.Time) bson.D {
+	event := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "_data", Value: resumeToken}}},
+		{Key: "operationType", Value: operationType},
+		{Key: "clusterTime", Value: primitive.Timestamp{T: uint32(clusterTime.Unix())}},
+		{Key: "documentKey", Value: bson.D{{Key: "_id", Value: "user-1"}}},
+	}
+	if fullDocument != nil {
+		event = append(event, bson.E{Key: "fullDocument", Value: fullDocument})
+	}
+	return event
+}
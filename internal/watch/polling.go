@@ -0,0 +1,103 @@
+package watch
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// pollingTokenPrefix marks resume tokens of the polling watcher
+	pollingTokenPrefix = "poll."
+
+	// pollingTokenQuery binds polling resume tokens to watching
+	pollingTokenQuery = "watch_users"
+)
+
+// pollingSort orders users by their last change, the position of the last change is the resume token
+var pollingSort = types.Sort{{Field: "updatedAt"}, {Field: "_id"}}
+
+// pollingWatcher watches users by repeatedly reading the users changed after the last seen change.
+// It works on standalone MongoDB and in tests, but as it relies on updatedAt, writes that commit
+// out of timestamp order within a poll interval may be missed.
+type pollingWatcher struct {
+	users    UserStreamer
+	tokens   *types.PageTokenCodec
+	interval time.Duration
+}
+
+// NewPollingWatcher creates a watcher that polls users every interval
+func NewPollingWatcher(users UserStreamer, tokens *types.PageTokenCodec, interval time.Duration) Watcher {
+	return &pollingWatcher{users: users, tokens: tokens, interval: interval}
+}
+
+func (w *pollingWatcher) Watch(ctx context.Context, resumeToken string, fn func(change *model.UserChange) error) error {
+	after, err := w.start(resumeToken)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		err := w.users.StreamUsers(ctx, bson.M{}, pollingSort, after, func(user *model.User, key []bson.RawValue) error {
+			token, err := w.tokens.Encode(key, pollingTokenQuery)
+			if err != nil {
+				return err
+			}
+			// The key is only valid during the callback
+			after = cloneKey(key)
+			return fn(model.NewUserChange(user, user.Version == 0, pollingTokenPrefix+token))
+		})
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// start returns the sort key to start after, now if there is no resume token
+func (w *pollingWatcher) start(resumeToken string) ([]bson.RawValue, error) {
+	if resumeToken == "" {
+		t, value, err := bson.MarshalValue(time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		// Every id sorts after the empty string
+		idType, id, err := bson.MarshalValue("")
+		if err != nil {
+			return nil, err
+		}
+		return []bson.RawValue{{Type: t, Value: value}, {Type: idType, Value: id}}, nil
+	}
+
+	encoded, found := strings.CutPrefix(resumeToken, pollingTokenPrefix)
+	if !found {
+		return nil, errInvalidResumeToken
+	}
+	key, err := w.tokens.Decode(encoded, pollingTokenQuery)
+	if err != nil || len(key) != len(pollingSort) {
+		return nil, errInvalidResumeToken
+	}
+	return key, nil
+}
+
+func cloneKey(key []bson.RawValue) []bson.RawValue {
+	cloned := make([]bson.RawValue, len(key))
+	for i, v := range key {
+		cloned[i] = bson.RawValue{Type: v.Type, Value: slices.Clone(v.Value)}
+	}
+	return cloned
+}
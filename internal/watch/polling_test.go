@@ -0,0 +1,118 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fakeUsers implements UserStreamer over users in memory, ordered by updatedAt and _id
+type fakeUsers struct {
+	mu    sync.Mutex
+	users map[string]model.User
+}
+
+func (f *fakeUsers) save(user model.User) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.users[user.Id] = user
+}
+
+func (f *fakeUsers) StreamUsers(ctx context.Context, filterCriteria bson.M, s types.Sort, after []bson.RawValue, fn func(user *model.User, key []bson.RawValue) error) error {
+	f.mu.Lock()
+	var users []model.User
+	for _, u := range f.users {
+		if after == nil || u.UpdatedAt.After(after[0].Time()) || (u.UpdatedAt.Equal(after[0].Time()) && u.Id > after[1].StringValue()) {
+			users = append(users, u)
+		}
+	}
+	f.mu.Unlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].UpdatedAt.Equal(users[j].UpdatedAt) {
+			return users[i].UpdatedAt.Before(users[j].UpdatedAt)
+		}
+		return users[i].Id < users[j].Id
+	})
+	for _, u := range users {
+		doc, err := bson.Marshal(u)
+		if err != nil {
+			return err
+		}
+		key, err := s.KeyOf(doc)
+		if err != nil {
+			return err
+		}
+		if err := fn(&u, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errStop ends a watch from the callback
+var errStop = errors.New("stop")
+
+func TestPollingWatcher(t *testing.T) {
+	ctx := context.Background()
+	users := &fakeUsers{users: map[string]model.User{}}
+	watcher := NewPollingWatcher(users, types.NewPageTokenCodec([]byte("secret"), time.Hour), 10*time.Millisecond)
+
+	// Changes before watching started are not emitted
+	old := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond)
+	users.save(model.User{Id: "old", Status: model.UserStatus_Active, Meta: types.Meta{CreatedAt: old, UpdatedAt: old}})
+
+	now := time.Now().UTC().Add(time.Second).Truncate(time.Millisecond)
+	go func() {
+		users.save(model.User{Id: "a", Password: "hash", Status: model.UserStatus_Active, Meta: types.Meta{CreatedAt: now, UpdatedAt: now}})
+		users.save(model.User{Id: "b", Status: model.UserStatus_Active, Meta: types.Meta{CreatedAt: now, UpdatedAt: now.Add(time.Millisecond), Version: 1}})
+		users.save(model.User{Id: "c", Status: model.UserStatus_Inactive, Meta: types.Meta{CreatedAt: now, UpdatedAt: now.Add(2 * time.Millisecond), Version: 2}})
+	}()
+
+	var changes []*model.UserChange
+	err := watcher.Watch(ctx, "", func(change *model.UserChange) error {
+		changes = append(changes, change)
+		if len(changes) == 3 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+
+	require.Len(t, changes, 3)
+	assert.Equal(t, "a", changes[0].User.Id)
+	assert.Equal(t, model.UserChange_Created, changes[0].Type)
+	assert.Empty(t, changes[0].User.Password)
+	assert.Equal(t, model.UserChange_Updated, changes[1].Type)
+	assert.Equal(t, model.UserChange_Deactivated, changes[2].Type)
+
+	// Resuming continues after the given change
+	var resumed []string
+	err = watcher.Watch(ctx, changes[0].ResumeToken, func(change *model.UserChange) error {
+		resumed = append(resumed, change.User.Id)
+		if len(resumed) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, []string{"b", "c"}, resumed)
+}
+
+func TestPollingWatcherInvalidResumeToken(t *testing.T) {
+	watcher := NewPollingWatcher(&fakeUsers{users: map[string]model.User{}}, types.NewPageTokenCodec([]byte("secret"), time.Hour), time.Millisecond)
+
+	for _, token := range []string{"garbage", "poll.garbage", "cs.abc"} {
+		err := watcher.Watch(context.Background(), token, func(*model.UserChange) error { return nil })
+		assert.Error(t, err, token)
+	}
+}
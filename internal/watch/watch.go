@@ -0,0 +1,92 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/internal/model"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/db/mongohandler"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/types"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	configKeyWatchMode         = "WATCH_USERS_MODE"
+	configKeyWatchPollInterval = "WATCH_USERS_POLL_INTERVAL"
+
+	// ModeAuto uses change streams and falls back to polling when they are not supported
+	ModeAuto = "auto"
+	// ModeChangeStream only uses change streams, which require a replica set or sharded cluster
+	ModeChangeStream = "changestream"
+	// ModePolling polls the users collection for changed users
+	ModePolling = "polling"
+
+	// changeStreamsNotSupported is the Mongo error code of a change stream on a standalone server
+	changeStreamsNotSupported = 40573
+)
+
+var errInvalidResumeToken = errwrap.NewError("invalid resume token", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+
+// Watcher streams changes of users
+type Watcher interface {
+	// Watch calls fn for every change after resumeToken, or after now if it is empty, until ctx is done or fn fails
+	Watch(ctx context.Context, resumeToken string, fn func(change *model.UserChange) error) error
+}
+
+// UserStreamer reads users in a sort order starting after a sort key, it is implemented by repository.UserRepo
+type UserStreamer interface {
+	StreamUsers(ctx context.Context, filterCriteria bson.M, sort types.Sort, after []bson.RawValue, fn func(user *model.User, key []bson.RawValue) error) error
+}
+
+// NewWatcherFromEnv creates the watcher selected by WATCH_USERS_MODE, auto by default
+func NewWatcherFromEnv(mongoWrapper *mongohandler.MongoDBWrapper, users UserStreamer, tokens *types.PageTokenCodec) Watcher {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault(configKeyWatchMode, ModeAuto)
+	vi.SetDefault(configKeyWatchPollInterval, 2*time.Second)
+
+	collection := mongoWrapper.Database.Collection("users")
+	polling := NewPollingWatcher(users, tokens, vi.GetDuration(configKeyWatchPollInterval))
+
+	switch strings.ToLower(vi.GetString(configKeyWatchMode)) {
+	case ModeChangeStream:
+		return NewChangeStreamWatcher(collection)
+	case ModePolling:
+		return polling
+	default:
+		return &fallbackWatcher{primary: NewChangeStreamWatcher(collection), fallback: polling}
+	}
+}
+
+// fallbackWatcher uses change streams until the server reports that they are not supported
+type fallbackWatcher struct {
+	primary     Watcher
+	fallback    Watcher
+	unsupported atomic.Bool
+}
+
+func (w *fallbackWatcher) Watch(ctx context.Context, resumeToken string, fn func(change *model.UserChange) error) error {
+	if w.unsupported.Load() || strings.HasPrefix(resumeToken, pollingTokenPrefix) {
+		return w.fallback.Watch(ctx, resumeToken, fn)
+	}
+
+	err := w.primary.Watch(ctx, resumeToken, fn)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == changeStreamsNotSupported {
+		slog.WarnContext(ctx, "change streams are not supported, watching users by polling", slog.Any("error", err))
+		w.unsupported.Store(true)
+		if resumeToken != "" {
+			return errInvalidResumeToken
+		}
+		return w.fallback.Watch(ctx, resumeToken, fn)
+	}
+	return err
+}
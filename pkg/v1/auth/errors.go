@@ -14,12 +14,15 @@ var (
 	ErrInvalidTokenTypeExpectedAccess  = NewJwtError("invalid token type: expected access token")
 	ErrInvalidTokenTypeExpectedRefresh = NewJwtError("invalid token type: expected refresh token")
 	ErrTokenInvalidated                = NewJwtError("token has been invalidated")
+	ErrRefreshTokenReused              = NewJwtError("refresh token has already been used")
 	ErrTokenStatusVerificationFailed   = NewJwtError("failed to verify token status")
 	ErrRefreshTokenValidationFailed    = NewJwtError("failed to validate refresh token")
 	ErrGenerateAccessTokenFailed       = NewJwtError("failed to generate access token")
 	ErrGenerateRefreshTokenFailed      = NewJwtError("failed to generate refresh token")
 	ErrInvalidateTokenFailed           = NewJwtError("failed to invalidate token")
 	ErrInvalidateDeviceTokenFailed     = NewJwtError("failed to invalidate device token")
+	ErrCertificateBindingMismatch      = NewJwtError("token is not bound to the presented client certificate")
+	ErrPermissionDenied                = NewJwtError("token does not grant a role required by the endpoint")
 )
 
 func NewJwtError(msg string) *JwtError {
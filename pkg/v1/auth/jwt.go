@@ -2,12 +2,11 @@ package auth
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"crypto/x509"
+	"crypto"
 	"encoding/base64"
-	"encoding/pem"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -32,12 +31,24 @@ const (
 	TokenTypeAccess  = "access"
 	TokenTypeRefresh = "refresh"
 
+	// Roles required by protected endpoints. Every authenticated user has RoleUser.
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+
+	// Token modes
+	TokenModeJWT    = "jwt"    // self-contained signed JWTs
+	TokenModeOpaque = "opaque" // random reference tokens resolved server-side
+
 	// Default configuration keys
-	configKeyAccessDuration  = "JWT_ACCESS_TOKEN_DURATION"
-	configKeyRefreshDuration = "JWT_REFRESH_TOKEN_DURATION"
-	configKeyPrivateKey      = "JWT_PRIVATE_KEY"
-	configKeyAuthEnabled     = "JWT_AUTH_ENABLED"
-	configKeyCollection      = "MONGODB_COLLECTION"
+	configKeyAccessDuration   = "JWT_ACCESS_TOKEN_DURATION"
+	configKeyRefreshDuration  = "JWT_REFRESH_TOKEN_DURATION"
+	configKeyPrivateKey       = "JWT_PRIVATE_KEY"
+	configKeyAlgorithm        = "JWT_SIGNING_ALGORITHM"
+	configKeyAuthEnabled      = "JWT_AUTH_ENABLED"
+	configKeyCollection       = "MONGODB_COLLECTION"
+	configKeyTokenMode        = "JWT_TOKEN_MODE"
+	configKeyOpaqueCollection = "JWT_OPAQUE_TOKEN_COLLECTION"
+	configKeyCertBoundTokens  = "JWT_CERT_BOUND_TOKENS"
 
 	// Default duration values
 	defaultAccessDuration  = "15m" // 15 minutes
@@ -55,6 +66,15 @@ type Claims struct {
 	// DeviceID tracks which device issued the token (used for refresh tokens)
 	DeviceID string `json:"device_id,omitempty"`
 
+	// Confirmation binds the token to the client certificate it was issued to (RFC 8705)
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+
+	// Roles granted to the token owner (used for access tokens)
+	Roles []string `json:"roles,omitempty"`
+
+	// rotated is set for opaque refresh tokens that were already exchanged
+	rotated bool
+
 	// Embed standard JWT claims (exp, iat, etc)
 	jwt.RegisteredClaims
 }
@@ -75,17 +95,27 @@ type UserInvalidatedToken struct {
 
 	// ExpiresAt is used by MongoDB's TTL index for automatic cleanup
 	ExpiresAt time.Time `bson:"expires_at"`
+
+	// Reason is InvalidationReasonRotated for the refresh tokens of a device replaced on refresh, empty for revocations
+	Reason string `bson:"reason,omitempty"`
 }
 
+// InvalidationReasonRotated marks the invalidation of a refresh token that was exchanged for a new one
+const InvalidationReasonRotated = "rotated"
+
 // JWTManager handles JWT token operations including generation, validation, and revocation
 type JWTManager struct {
 	stack.AbstractProvider
 
 	// Configuration
 	authEnabled      bool
+	tokenMode        string
+	certBoundTokens  bool
 	privateKeyBase64 string
-	privateKey       *ecdsa.PrivateKey
-	publicKey        *ecdsa.PublicKey
+	algorithm        string
+	signingMethod    jwt.SigningMethod
+	privateKey       crypto.Signer
+	publicKey        crypto.PublicKey
 
 	// Token settings
 	accessTokenDuration  time.Duration
@@ -95,12 +125,16 @@ type JWTManager struct {
 	protectedRoles map[string][]string
 
 	// Storage
-	collection *mongo.Collection
+	collection       *mongo.Collection
+	opaqueCollection *mongo.Collection
 }
 
 // tokenParserFn defines a function type for extracting tokens from context
 type tokenParserFn func(ctx context.Context) (string, error)
 
+// RoleResolver returns the current roles of a user, it is used to issue tokens on refresh
+type RoleResolver func(ctx context.Context, userID string) ([]string, error)
+
 // NewJWTManager creates and configures a new JWTManager instance
 func NewJWTManager(mongoWrapper *mongohandler.MongoDBWrapper) *JWTManager {
 	vi := viper.New()
@@ -110,32 +144,67 @@ func NewJWTManager(mongoWrapper *mongohandler.MongoDBWrapper) *JWTManager {
 	vi.SetDefault(configKeyAccessDuration, defaultAccessDuration)
 	vi.SetDefault(configKeyRefreshDuration, defaultRefreshDuration)
 	vi.SetDefault(configKeyPrivateKey, "")
+	vi.SetDefault(configKeyAlgorithm, AlgorithmES256)
 	vi.SetDefault(configKeyAuthEnabled, "true")
 	vi.SetDefault(configKeyCollection, "user_invalidated_tokens")
+	vi.SetDefault(configKeyTokenMode, TokenModeJWT)
+	vi.SetDefault(configKeyOpaqueCollection, "user_opaque_tokens")
+	vi.SetDefault(configKeyCertBoundTokens, false)
 
 	// Define protected endpoints and their required roles
 	protectedEndpoints := map[string][]string{
-		"/core.user.v1.UserAPI/CreateUser":     {"user"},
-		"/core.user.v1.UserAPI/UpdateUserById": {"user"},
-		"/core.user.v1.UserAPI/DeleteUserById": {"user"},
-		"/core.user.v1.UserAPI/ListUsers":      {"user"},
-		"/core.user.v1.AuthAPI/Logout":         {"user"},
+		"/core.user.v1.UserAPI/CreateUser":            {RoleUser},
+		"/core.user.v1.UserAPI/UpdateUserById":        {RoleAdmin},
+		"/core.user.v1.UserAPI/DeleteUserById":        {RoleUser},
+		"/core.user.v1.UserAPI/ListUsers":             {RoleUser},
+		"/core.user.v1.UserAPI/GetUser":               {RoleUser},
+		"/core.user.v1.UserAPI/GetMe":                 {RoleUser},
+		"/core.user.v1.UserAPI/UpdateMe":              {RoleUser},
+		"/core.user.v1.UserAPI/SearchUsers":           {RoleUser},
+		"/core.user.v1.UserAPI/ExportUsers":           {RoleAdmin},
+		"/core.user.v1.UserAPI/WatchUsers":            {RoleAdmin},
+		"/core.user.v1.UserAPI/ImportUsers":           {RoleAdmin},
+		"/core.user.v1.UserAPI/BatchGetUsers":         {RoleAdmin},
+		"/core.user.v1.UserAPI/BatchUpdateUserStatus": {RoleAdmin},
+		"/core.user.v1.UserAPI/BatchDeleteUsers":      {RoleAdmin},
+		"/core.user.v1.UserAPI/RestoreUser":           {RoleAdmin},
+		"/core.user.v1.UserAPI/RequestErasure":        {RoleUser},
+		"/core.user.v1.UserAPI/DeleteMe":              {RoleUser},
+		"/core.user.v1.UserAPI/ExportMyData":          {RoleUser},
+		"/core.user.v1.UserAPI/ExportUserData":        {RoleUser},
+		"/core.user.v1.UserAPI/SendPhoneVerification": {RoleUser},
+		"/core.user.v1.UserAPI/VerifyPhone":           {RoleUser},
+		"/core.user.v1.UserAPI/RequestEmailChange":    {RoleUser},
+		"/core.user.v1.UserAPI/ConfirmEmailChange":    {RoleUser},
+		"/core.user.v1.AuthAPI/Logout":                {RoleUser},
+		// Admin endpoints
+		"/core.user.v1.SecurityAPI/ListSecurityEvents": {RoleAdmin},
 	}
 
 	collection := mongoWrapper.Collection(vi.GetString(configKeyCollection))
+	opaqueCollection := mongoWrapper.Collection(vi.GetString(configKeyOpaqueCollection))
 
 	return &JWTManager{
 		privateKeyBase64:     vi.GetString(configKeyPrivateKey),
+		algorithm:            vi.GetString(configKeyAlgorithm),
 		accessTokenDuration:  vi.GetDuration(configKeyAccessDuration),
 		refreshTokenDuration: vi.GetDuration(configKeyRefreshDuration),
 		protectedRoles:       protectedEndpoints,
 		authEnabled:          vi.GetBool(configKeyAuthEnabled),
+		tokenMode:            strings.ToLower(vi.GetString(configKeyTokenMode)),
+		certBoundTokens:      vi.GetBool(configKeyCertBoundTokens),
 		collection:           collection,
+		opaqueCollection:     opaqueCollection,
 	}
 }
 
 // Init initializes the JWT manager by setting up encryption keys and database indexes
 func (m *JWTManager) Init() error {
+	// Validate token mode
+	if m.tokenMode != TokenModeJWT && m.tokenMode != TokenModeOpaque {
+		return fmt.Errorf("unsupported %s %q: expected %q or %q", configKeyTokenMode, m.tokenMode, TokenModeJWT, TokenModeOpaque)
+	}
+
 	// Initialize encryption keys
 	if err := m.setKeys(); err != nil {
 		return fmt.Errorf("failed to initialize encryption keys: %w", err)
@@ -146,6 +215,13 @@ func (m *JWTManager) Init() error {
 		return fmt.Errorf("failed to create MongoDB indexes: %w", err)
 	}
 
+	// Create opaque token store indexes
+	if m.tokenMode == TokenModeOpaque {
+		if err := m.createOpaqueIndexes(); err != nil {
+			return fmt.Errorf("failed to create opaque token indexes: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -176,13 +252,19 @@ func (m *JWTManager) createIndexes() error {
 	return nil
 }
 
-// setKeys initializes the ECDSA key pair for token signing and verification
+// setKeys initializes the key pair for token signing and verification
 func (m *JWTManager) setKeys() error {
-	// Skip if authentication is disabled
-	if !m.authEnabled {
+	// Skip if authentication is disabled or tokens are not signed
+	if !m.authEnabled || m.tokenMode == TokenModeOpaque {
 		return nil
 	}
 
+	// Resolve the configured signing algorithm
+	signingMethod, err := signingMethodFor(m.algorithm)
+	if err != nil {
+		return err
+	}
+
 	// Validate private key configuration
 	if m.privateKeyBase64 == "" {
 		return fmt.Errorf("JWT_PRIVATE_KEY environment variable is not set")
@@ -202,41 +284,37 @@ func (m *JWTManager) setKeys() error {
 	cleanKey := strings.Trim(string(privateKeyPEM), "\"")
 	cleanKey = strings.ReplaceAll(cleanKey, "\\n", "\n")
 
-	// Parse the PEM block
-	block, _ := pem.Decode([]byte(cleanKey))
-	if block == nil {
-		return fmt.Errorf("failed to decode PEM block: invalid PEM format")
-	}
-
-	// Verify key type
-	if block.Type != "EC PRIVATE KEY" {
-		return fmt.Errorf("expected EC PRIVATE KEY but got %s", block.Type)
+	// Parse the private key
+	privateKey, err := parsePrivateKeyPEM([]byte(cleanKey))
+	if err != nil {
+		return err
 	}
 
-	// Parse the ECDSA private key
-	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
-	if err != nil {
-		return fmt.Errorf("failed to parse private key: %w", err)
+	// Verify key type against the configured algorithm
+	if err := checkKeyMatchesAlgorithm(privateKey, signingMethod); err != nil {
+		return err
 	}
 
-	// Store both private and public keys
+	// Store signing method and both private and public keys
+	m.signingMethod = signingMethod
 	m.privateKey = privateKey
-	m.publicKey = &privateKey.PublicKey
+	m.publicKey = privateKey.Public()
 	return nil
 }
 
-// GenerateTokenPair creates a new pair of access and refresh tokens for a user
-func (m *JWTManager) GenerateTokenPair(ctx context.Context, userID string, deviceID string) (accessToken string, refreshToken string, err error) {
+// GenerateTokenPair creates a new pair of access and refresh tokens for a user with the given roles
+func (m *JWTManager) GenerateTokenPair(ctx context.Context, userID string, deviceID string, roles []string) (accessToken string, refreshToken string, err error) {
 	now := time.Now()
+	cnf := m.certificateConfirmation(ctx)
 
 	// Generate access token first
-	accessToken, err = m.generateAccessToken(userID, now)
+	accessToken, err = m.generateAccessToken(ctx, userID, roles, cnf, now)
 	if err != nil {
 		return "", "", ErrGenerateAccessTokenFailed.SetOriginErr(err)
 	}
 
 	// Generate refresh token
-	refreshToken, err = m.generateRefreshToken(userID, deviceID, now)
+	refreshToken, err = m.generateRefreshToken(ctx, userID, deviceID, cnf, now)
 	if err != nil {
 		return "", "", ErrGenerateRefreshTokenFailed.SetOriginErr(err)
 	}
@@ -245,10 +323,12 @@ func (m *JWTManager) GenerateTokenPair(ctx context.Context, userID string, devic
 }
 
 // generateAccessToken creates a new access token for the given user
-func (m *JWTManager) generateAccessToken(userID string, now time.Time) (string, error) {
+func (m *JWTManager) generateAccessToken(ctx context.Context, userID string, roles []string, cnf *Confirmation, now time.Time) (string, error) {
 	claims := Claims{
-		UserID:    userID,
-		TokenType: TokenTypeAccess,
+		UserID:       userID,
+		TokenType:    TokenTypeAccess,
+		Confirmation: cnf,
+		Roles:        withUserRole(roles),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(m.accessTokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -256,16 +336,16 @@ func (m *JWTManager) generateAccessToken(userID string, now time.Time) (string,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
-	return token.SignedString(m.privateKey)
+	return m.issueToken(ctx, claims)
 }
 
 // generateRefreshToken creates a new refresh token for the given user and device
-func (m *JWTManager) generateRefreshToken(userID string, deviceID string, now time.Time) (string, error) {
+func (m *JWTManager) generateRefreshToken(ctx context.Context, userID string, deviceID string, cnf *Confirmation, now time.Time) (string, error) {
 	claims := Claims{
-		UserID:    userID,
-		TokenType: TokenTypeRefresh,
-		DeviceID:  deviceID,
+		UserID:       userID,
+		TokenType:    TokenTypeRefresh,
+		DeviceID:     deviceID,
+		Confirmation: cnf,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(m.refreshTokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -273,12 +353,33 @@ func (m *JWTManager) generateRefreshToken(userID string, deviceID string, now ti
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	return m.issueToken(ctx, claims)
+}
+
+// issueToken turns the claims into a token string according to the configured token mode
+func (m *JWTManager) issueToken(ctx context.Context, claims Claims) (string, error) {
+	if m.tokenMode == TokenModeOpaque {
+		return m.issueOpaqueToken(ctx, claims)
+	}
+
+	if m.signingMethod == nil {
+		return "", errSigningKeyNotConfigured
+	}
+
+	token := jwt.NewWithClaims(m.signingMethod, claims)
 	return token.SignedString(m.privateKey)
 }
 
-// Validate verifies the validity of an access token and returns its claims
-func (m *JWTManager) Validate(ctx context.Context, tokenStr string) (*Claims, error) {
+// parseToken resolves a token string into its claims according to the configured token mode
+func (m *JWTManager) parseToken(ctx context.Context, tokenStr string) (*Claims, error) {
+	if m.tokenMode == TokenModeOpaque {
+		return m.resolveOpaqueToken(ctx, tokenStr)
+	}
+
+	if m.signingMethod == nil {
+		return nil, ErrTokenJwtParse.SetOriginErr(errSigningKeyNotConfigured)
+	}
+
 	var claims Claims
 
 	// Parse and verify the token signature
@@ -286,12 +387,13 @@ func (m *JWTManager) Validate(ctx context.Context, tokenStr string) (*Claims, er
 		tokenStr,
 		&claims,
 		func(token *jwt.Token) (interface{}, error) {
-			// Verify the signing method
-			if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
+			// Verify the signing method strictly against the configured one
+			if token.Method.Alg() != m.signingMethod.Alg() {
 				return nil, ErrInvalidToken.SetOriginErr(fmt.Errorf("unexpected token signing method"))
 			}
 			return m.publicKey, nil
 		},
+		jwt.WithValidMethods([]string{m.signingMethod.Alg()}),
 	)
 
 	if err != nil {
@@ -305,6 +407,16 @@ func (m *JWTManager) Validate(ctx context.Context, tokenStr string) (*Claims, er
 		return nil, ErrInvalidToken
 	}
 
+	return &claims, nil
+}
+
+// Validate verifies the validity of an access token and returns its claims
+func (m *JWTManager) Validate(ctx context.Context, tokenStr string) (*Claims, error) {
+	claims, err := m.parseToken(ctx, tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
 	// Verify token type
 	if claims.TokenType == "" {
 		return nil, ErrTokenTypeNotSpecified
@@ -313,12 +425,18 @@ func (m *JWTManager) Validate(ctx context.Context, tokenStr string) (*Claims, er
 		return nil, ErrInvalidTokenTypeExpectedAccess
 	}
 
+	// Verify certificate binding
+	if err := checkCertificateBinding(ctx, claims); err != nil {
+		return nil, err
+	}
+
 	// Check if token has been invalidated
 	filter := bson.M{
 		"$or": []bson.M{
 			// Check user-wide invalidation
 			{
 				"user_id":        claims.UserID,
+				"device_id":      bson.M{"$exists": false},
 				"token_type":     TokenTypeAccess,
 				"invalidated_at": bson.M{"$gte": claims.IssuedAt.Time},
 			},
@@ -340,68 +458,76 @@ func (m *JWTManager) Validate(ctx context.Context, tokenStr string) (*Claims, er
 		return nil, ErrTokenInvalidated
 	case err == mongo.ErrNoDocuments:
 		// No invalidation record found -> token is valid
-		return &claims, nil
+		return claims, nil
 	default:
 		return nil, ErrTokenStatusVerificationFailed.SetOriginErr(err)
 	}
 }
 
-// RefreshTokens validates a refresh token and generates a new token pair
-func (m *JWTManager) RefreshTokens(ctx context.Context, refreshToken string) (accessToken string, newRefreshToken string, err error) {
+// RefreshTokens validates a refresh token and generates a new token pair.
+// The roles of the new access token are resolved again, so role changes take effect on refresh.
+func (m *JWTManager) RefreshTokens(ctx context.Context, refreshToken string, resolveRoles RoleResolver) (accessToken string, newRefreshToken string, err error) {
 	// Validate the refresh token
 	claims, err := m.validateRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return "", "", err
 	}
 
+	roles, err := resolveRoles(ctx, claims.UserID)
+	if err != nil {
+		return "", "", ErrRefreshTokenValidationFailed.SetOriginErr(err)
+	}
+
 	now := time.Now()
 
+	// Opaque refresh tokens are single use, claim the token before issuing its successors
+	if m.tokenMode == TokenModeOpaque {
+		if err = m.rotateOpaqueToken(ctx, refreshToken, now); err != nil {
+			return "", "", err
+		}
+	}
+
 	// Generate new access token
-	accessToken, err = m.generateAccessToken(claims.UserID, now)
+	accessToken, err = m.generateAccessToken(ctx, claims.UserID, roles, claims.Confirmation, now)
 	if err != nil {
 		return "", "", ErrGenerateAccessTokenFailed.SetOriginErr(err)
 	}
 
 	// Generate new refresh token
-	newRefreshToken, err = m.generateRefreshToken(claims.UserID, claims.DeviceID, now)
+	newRefreshToken, err = m.generateRefreshToken(ctx, claims.UserID, claims.DeviceID, claims.Confirmation, now)
 	if err != nil {
 		return "", "", ErrGenerateRefreshTokenFailed.SetOriginErr(err)
 	}
 
-	// Invalidate the old refresh token
+	// Invalidate the old refresh token, presenting it again is detected as reuse
 	invalidatedAt := now.Add(-time.Second)
-	if err = m.InvalidateToken(ctx, claims.UserID, claims.DeviceID, TokenTypeRefresh, invalidatedAt); err != nil {
+	if err = m.invalidateToken(ctx, claims.UserID, claims.DeviceID, TokenTypeRefresh, invalidatedAt, InvalidationReasonRotated); err != nil {
 		return "", "", ErrInvalidateTokenFailed.SetOriginErr(err)
 	}
 
 	return accessToken, newRefreshToken, nil
 }
 
-// validateRefreshToken verifies a refresh token's validity and returns its claims
-func (m *JWTManager) validateRefreshToken(ctx context.Context, tokenStr string) (*Claims, error) {
-	var claims Claims
-
-	// Parse and verify the token signature
-	token, err := jwt.ParseWithClaims(
-		tokenStr,
-		&claims,
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
-				return nil, ErrInvalidToken.SetOriginErr(fmt.Errorf("unexpected token signing method"))
-			}
-			return m.publicKey, nil
-		},
-	)
-
+// ParseRefreshToken verifies a refresh token's signature and type without checking whether it
+// has been invalidated. It is used to identify the owner of a rejected refresh token.
+func (m *JWTManager) ParseRefreshToken(ctx context.Context, tokenStr string) (*Claims, error) {
+	claims, err := m.parseToken(ctx, tokenStr)
 	if err != nil {
-		if errors.Is(err, jwt.ErrTokenExpired) {
-			return nil, ErrTokenExpired.SetOriginErr(err)
-		}
-		return nil, ErrTokenJwtParse.SetOriginErr(err)
+		return nil, err
 	}
 
-	if !token.Valid {
-		return nil, ErrInvalidToken
+	if claims.TokenType != TokenTypeRefresh {
+		return nil, ErrInvalidTokenTypeExpectedRefresh
+	}
+
+	return claims, nil
+}
+
+// validateRefreshToken verifies a refresh token's validity and returns its claims
+func (m *JWTManager) validateRefreshToken(ctx context.Context, tokenStr string) (*Claims, error) {
+	claims, err := m.parseToken(ctx, tokenStr)
+	if err != nil {
+		return nil, err
 	}
 
 	// Verify token type
@@ -409,16 +535,29 @@ func (m *JWTManager) validateRefreshToken(ctx context.Context, tokenStr string)
 		return nil, ErrInvalidTokenTypeExpectedRefresh
 	}
 
+	// Verify certificate binding
+	if err := checkCertificateBinding(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	// A rotated opaque refresh token is presented again
+	if claims.rotated {
+		return nil, ErrRefreshTokenReused
+	}
+
 	// Check if token has been invalidated before issued at time.
 	filter := bson.M{
 		"$or": []bson.M{
+			// User-wide invalidation, device invalidations do not affect other devices
 			{
 				"user_id":    claims.UserID,
+				"device_id":  bson.M{"$exists": false},
 				"token_type": TokenTypeRefresh,
 				"invalidated_at": bson.M{
 					"$gte": claims.IssuedAt.Time,
 				},
 			},
+			// Device-specific invalidation or rotation
 			{
 				"user_id":    claims.UserID,
 				"token_type": TokenTypeRefresh,
@@ -430,15 +569,20 @@ func (m *JWTManager) validateRefreshToken(ctx context.Context, tokenStr string)
 		},
 	}
 
+	// Revocations sort before rotations, a token that was also revoked is not reported as reused
 	var invalidToken UserInvalidatedToken
-	err = m.collection.FindOne(ctx, filter).Decode(&invalidToken)
-	if err == nil {
+	opts := options.FindOne().SetSort(bson.D{{Key: "reason", Value: 1}})
+	err = m.collection.FindOne(ctx, filter, opts).Decode(&invalidToken)
+	switch {
+	case err == nil && invalidToken.Reason == InvalidationReasonRotated:
+		return nil, ErrRefreshTokenReused
+	case err == nil:
 		return nil, ErrTokenInvalidated
-	} else if err != mongo.ErrNoDocuments {
+	case err != mongo.ErrNoDocuments:
 		return nil, ErrTokenStatusVerificationFailed.SetOriginErr(err)
 	}
 
-	return &claims, nil
+	return claims, nil
 }
 
 // InvalidateUserTokens revokes all tokens for a specific user
@@ -487,6 +631,11 @@ func (m *JWTManager) InvalidateTokensBefore(ctx context.Context, userID string,
 
 // InvalidateToken revokes a specific token
 func (m *JWTManager) InvalidateToken(ctx context.Context, userID, deviceID, tokenType string, invalidatedAt time.Time) error {
+	return m.invalidateToken(ctx, userID, deviceID, tokenType, invalidatedAt, "")
+}
+
+// invalidateToken stores the invalidation of the tokens of a type issued to a device until invalidatedAt
+func (m *JWTManager) invalidateToken(ctx context.Context, userID, deviceID, tokenType string, invalidatedAt time.Time, reason string) error {
 	expiryDuration := m.accessTokenDuration
 
 	// Set expiry duration based on token type
@@ -500,6 +649,7 @@ func (m *JWTManager) InvalidateToken(ctx context.Context, userID, deviceID, toke
 		TokenType:     tokenType,
 		InvalidatedAt: invalidatedAt,
 		ExpiresAt:     invalidatedAt.Add(expiryDuration + BufferTimeForExpiration),
+		Reason:        reason,
 	}
 
 	_, err := m.collection.InsertOne(ctx, invalidToken)
@@ -529,6 +679,82 @@ func (m *JWTManager) InvalidateByDeviceID(ctx context.Context, userID string, de
 	return nil
 }
 
+// EraseUserTokens removes the personal data of a user from the token stores.
+// Issued opaque tokens are deleted. Invalidation records are kept until they expire, so they keep revoking the
+// tokens of the user, but their device ids are removed.
+func (m *JWTManager) EraseUserTokens(ctx context.Context, userID string) error {
+	if _, err := m.opaqueCollection.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return fmt.Errorf("failed to delete opaque tokens: %w", err)
+	}
+
+	filter := bson.M{"user_id": userID, "device_id": bson.M{"$exists": true}}
+	if _, err := m.collection.UpdateMany(ctx, filter, bson.M{"$unset": bson.M{"device_id": ""}}); err != nil {
+		return fmt.Errorf("failed to erase device ids of invalidated tokens: %w", err)
+	}
+
+	return nil
+}
+
+// ListUserTokens returns the opaque tokens issued to a user and the invalidations of its tokens.
+// Tombstones of rotated refresh tokens are left out.
+func (m *JWTManager) ListUserTokens(ctx context.Context, userID string) ([]OpaqueToken, []UserInvalidatedToken, error) {
+	opaqueTokens := []OpaqueToken{}
+	filter := bson.M{"user_id": userID, "rotated_at": bson.M{"$exists": false}}
+	cursor, err := m.opaqueCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "issued_at", Value: -1}}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list opaque tokens: %w", err)
+	}
+	if err := cursor.All(ctx, &opaqueTokens); err != nil {
+		return nil, nil, fmt.Errorf("failed to list opaque tokens: %w", err)
+	}
+
+	invalidations := []UserInvalidatedToken{}
+	cursor, err = m.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "invalidated_at", Value: -1}}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list invalidated tokens: %w", err)
+	}
+	if err := cursor.All(ctx, &invalidations); err != nil {
+		return nil, nil, fmt.Errorf("failed to list invalidated tokens: %w", err)
+	}
+
+	return opaqueTokens, invalidations, nil
+}
+
+// certificateConfirmation returns the confirmation claim for the client certificate of the
+// current connection, or nil when certificate bound tokens are disabled or no certificate is presented
+func (m *JWTManager) certificateConfirmation(ctx context.Context) *Confirmation {
+	if !m.certBoundTokens {
+		return nil
+	}
+
+	cert := PeerCertificate(ctx)
+	if cert == nil {
+		return nil
+	}
+
+	return &Confirmation{X5tS256: CertificateThumbprint(cert)}
+}
+
+// checkCertificateBinding verifies that a certificate bound token is presented over a
+// connection authenticated with the same client certificate
+func checkCertificateBinding(ctx context.Context, claims *Claims) error {
+	if claims.Confirmation == nil || claims.Confirmation.X5tS256 == "" {
+		return nil
+	}
+
+	cert := PeerCertificate(ctx)
+	if cert == nil || CertificateThumbprint(cert) != claims.Confirmation.X5tS256 {
+		return ErrCertificateBindingMismatch
+	}
+
+	return nil
+}
+
+// RefreshTokenDuration returns the lifetime of issued refresh tokens
+func (m *JWTManager) RefreshTokenDuration() time.Duration {
+	return m.refreshTokenDuration
+}
+
 // Authorize validates a token and checks if it has permission to access an endpoint
 func (m *JWTManager) Authorize(ctx context.Context, endpoint string, tokenParser tokenParserFn) (*Claims, error) {
 	// Skip authorization if not required
@@ -548,9 +774,44 @@ func (m *JWTManager) Authorize(ctx context.Context, endpoint string, tokenParser
 		return nil, err
 	}
 
+	// Check the caller has a role the endpoint requires
+	if err := m.checkRoles(endpoint, claims.Roles); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
+// AuthorizeRoles checks that the roles of a caller authenticated without a token,
+// e.g. a service principal, grant access to an endpoint
+func (m *JWTManager) AuthorizeRoles(endpoint string, roles []string) error {
+	if !m.needsAuth(endpoint) {
+		return nil
+	}
+	return m.checkRoles(endpoint, roles)
+}
+
+// checkRoles verifies that the roles contain one of the roles required by an endpoint
+func (m *JWTManager) checkRoles(endpoint string, roles []string) error {
+	for _, required := range m.protectedRoles[endpoint] {
+		if slices.Contains(roles, required) {
+			return nil
+		}
+	}
+	return ErrPermissionDenied
+}
+
+// withUserRole returns the roles with the implicit user role every authenticated user has
+func withUserRole(roles []string) []string {
+	granted := []string{RoleUser}
+	for _, role := range roles {
+		if role != "" && !slices.Contains(granted, role) {
+			granted = append(granted, role)
+		}
+	}
+	return granted
+}
+
 // needsAuth checks if an endpoint requires authentication
 func (m *JWTManager) needsAuth(endpoint string) bool {
 	if !m.authEnabled {
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const (
+	testUserEndpoint  = "/core.user.v1.UserAPI/GetMe"
+	testAdminEndpoint = "/core.user.v1.UserAPI/ExportUsers"
+)
+
+func TestAuthorizeRoles(t *testing.T) {
+	manager := newTestJWTManager(t)
+
+	tests := []struct {
+		name     string
+		roles    []string
+		endpoint string
+		wantErr  error
+	}{
+		{"user on user endpoint", nil, testUserEndpoint, nil},
+		{"non-admin on admin endpoint", nil, testAdminEndpoint, ErrPermissionDenied},
+		{"unknown role on admin endpoint", []string{"auditor"}, testAdminEndpoint, ErrPermissionDenied},
+		{"admin on admin endpoint", []string{RoleAdmin}, testAdminEndpoint, nil},
+		{"admin on user endpoint", []string{RoleAdmin}, testUserEndpoint, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := manager.generateAccessToken(context.Background(), "user-1", tt.roles, nil, time.Now())
+			require.NoError(t, err)
+
+			claims, err := manager.parseToken(context.Background(), token)
+			require.NoError(t, err)
+			assert.Contains(t, claims.Roles, RoleUser, "every token carries the user role")
+
+			err = manager.checkRoles(tt.endpoint, claims.Roles)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRefreshTokenInvalidation(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	resolveRoles := func(ctx context.Context, userID string) ([]string, error) { return nil, nil }
+
+	tests := []struct {
+		name         string
+		invalidation UserInvalidatedToken
+		wantErr      error
+	}{
+		{"revoked by logout", UserInvalidatedToken{UserID: "user-1", TokenType: TokenTypeRefresh}, ErrTokenInvalidated},
+		{"revoked on the device", UserInvalidatedToken{UserID: "user-1", DeviceID: "device-1", TokenType: TokenTypeRefresh}, ErrTokenInvalidated},
+		{"rotated", UserInvalidatedToken{UserID: "user-1", DeviceID: "device-1", TokenType: TokenTypeRefresh, Reason: InvalidationReasonRotated}, ErrRefreshTokenReused},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			manager := newTestJWTManager(mt.T)
+			manager.collection = mt.Coll
+			token, err := manager.generateRefreshToken(context.Background(), "user-1", "device-1", nil, time.Now())
+			require.NoError(mt, err)
+
+			tt.invalidation.InvalidatedAt = time.Now()
+			raw, err := bson.Marshal(tt.invalidation)
+			require.NoError(mt, err)
+			var doc bson.D
+			require.NoError(mt, bson.Unmarshal(raw, &doc))
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, mt.Coll.Database().Name()+"."+mt.Coll.Name(), mtest.FirstBatch, doc))
+
+			_, _, err = manager.RefreshTokens(context.Background(), token, resolveRoles)
+			assert.ErrorIs(mt, err, tt.wantErr)
+
+			// Revocations are found before rotations
+			lookup := mt.GetStartedEvent()
+			require.Equal(mt, "find", lookup.CommandName)
+			assert.Equal(mt, int32(1), lookup.Command.Lookup("sort", "reason").Int32())
+		})
+	}
+}
+
+func TestWithUserRole(t *testing.T) {
+	assert.Equal(t, []string{RoleUser}, withUserRole(nil))
+	assert.Equal(t, []string{RoleUser, RoleAdmin}, withUserRole([]string{RoleAdmin, RoleUser, "", RoleAdmin}))
+}
+
+// newTestJWTManager returns a manager signing JWTs with a fresh ES256 key, without token storage
+func newTestJWTManager(t *testing.T) *JWTManager {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return &JWTManager{
+		authEnabled:          true,
+		tokenMode:            TokenModeJWT,
+		signingMethod:        jwt.SigningMethodES256,
+		privateKey:           key,
+		publicKey:            key.Public(),
+		accessTokenDuration:  time.Minute,
+		refreshTokenDuration: time.Hour,
+		protectedRoles: map[string][]string{
+			testUserEndpoint:  {RoleUser},
+			testAdminEndpoint: {RoleAdmin},
+		},
+	}
+}
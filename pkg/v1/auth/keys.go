@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgorithmES256 = "ES256"
+	AlgorithmES384 = "ES384"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	// minRSAKeyBits is the minimum accepted RSA modulus size
+	minRSAKeyBits = 2048
+)
+
+// errSigningKeyNotConfigured is returned when a token is signed or verified without a loaded key
+var errSigningKeyNotConfigured = errors.New("signing key is not configured")
+
+// signingMethods maps the supported algorithm names to their jwt signing methods
+var signingMethods = map[string]jwt.SigningMethod{
+	AlgorithmES256: jwt.SigningMethodES256,
+	AlgorithmES384: jwt.SigningMethodES384,
+	AlgorithmRS256: jwt.SigningMethodRS256,
+	AlgorithmEdDSA: jwt.SigningMethodEdDSA,
+}
+
+// signingMethodFor returns the jwt signing method of a configured algorithm name
+func signingMethodFor(algorithm string) (jwt.SigningMethod, error) {
+	for name, method := range signingMethods {
+		if strings.EqualFold(name, algorithm) {
+			return method, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+}
+
+// parsePrivateKeyPEM parses a SEC1 (EC PRIVATE KEY), PKCS#1 (RSA PRIVATE KEY) or PKCS#8 (PRIVATE KEY) PEM block
+func parsePrivateKeyPEM(pemBytes []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block: invalid PEM format")
+	}
+
+	var (
+		key any
+		err error
+	)
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// checkKeyMatchesAlgorithm verifies that the private key can be used with the signing algorithm
+func checkKeyMatchesAlgorithm(key crypto.Signer, method jwt.SigningMethod) error {
+	switch method.Alg() {
+	case AlgorithmES256, AlgorithmES384:
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("%s requires an ECDSA key but got %T", method.Alg(), key)
+		}
+		expected := elliptic.P256()
+		if method.Alg() == AlgorithmES384 {
+			expected = elliptic.P384()
+		}
+		if ecKey.Curve != expected {
+			return fmt.Errorf("%s requires curve %s but got %s", method.Alg(), expected.Params().Name, ecKey.Curve.Params().Name)
+		}
+	case AlgorithmRS256:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("%s requires an RSA key but got %T", method.Alg(), key)
+		}
+		if rsaKey.N.BitLen() < minRSAKeyBits {
+			return fmt.Errorf("%s requires at least %d bit RSA key but got %d", method.Alg(), minRSAKeyBits, rsaKey.N.BitLen())
+		}
+	case AlgorithmEdDSA:
+		if _, ok := key.(ed25519.PrivateKey); !ok {
+			return fmt.Errorf("%s requires an Ed25519 key but got %T", method.Alg(), key)
+		}
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", method.Alg())
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetKeys(t *testing.T) {
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsa1024, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	sec1, err := x509.MarshalECPrivateKey(p256)
+	require.NoError(t, err)
+	p256PEM := pemBlock("EC PRIVATE KEY", sec1)
+	p384SEC1, err := x509.MarshalECPrivateKey(p384)
+	require.NoError(t, err)
+	p384PEM := pemBlock("EC PRIVATE KEY", p384SEC1)
+	rsaPEM := pemBlock("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsa2048))
+	smallRSAPEM := pemBlock("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsa1024))
+
+	tests := []struct {
+		name      string
+		algorithm string
+		key       string // base64 encoded as in JWT_PRIVATE_KEY
+		wantErr   string
+	}{
+		{"ES256 with SEC1 key", AlgorithmES256, encodeKey(p256PEM), ""},
+		{"ES256 with PKCS#8 key", AlgorithmES256, encodeKey(pkcs8PEM(t, p256)), ""},
+		{"ES384", AlgorithmES384, encodeKey(p384PEM), ""},
+		{"RS256 with PKCS#1 key", AlgorithmRS256, encodeKey(rsaPEM), ""},
+		{"RS256 with PKCS#8 key", AlgorithmRS256, encodeKey(pkcs8PEM(t, rsa2048)), ""},
+		{"EdDSA", AlgorithmEdDSA, encodeKey(pkcs8PEM(t, ed)), ""},
+		{"algorithm name in lower case", "es256", encodeKey(p256PEM), ""},
+		{"quoted key with escaped newlines", AlgorithmES256, encodeKey(`"` + strings.ReplaceAll(p256PEM, "\n", `\n`) + `"`), ""},
+
+		{"unsupported algorithm", "HS256", encodeKey(p256PEM), `unsupported signing algorithm "HS256"`},
+		{"ES256 with RSA key", AlgorithmES256, encodeKey(rsaPEM), "ES256 requires an ECDSA key"},
+		{"ES256 with P-384 key", AlgorithmES256, encodeKey(p384PEM), "ES256 requires curve P-256 but got P-384"},
+		{"ES384 with P-256 key", AlgorithmES384, encodeKey(p256PEM), "ES384 requires curve P-384 but got P-256"},
+		{"RS256 with ECDSA key", AlgorithmRS256, encodeKey(p256PEM), "RS256 requires an RSA key"},
+		{"RS256 with small key", AlgorithmRS256, encodeKey(smallRSAPEM), "RS256 requires at least 2048 bit RSA key but got 1024"},
+		{"EdDSA with ECDSA key", AlgorithmEdDSA, encodeKey(p256PEM), "EdDSA requires an Ed25519 key"},
+
+		{"missing key", AlgorithmES256, "", "JWT_PRIVATE_KEY environment variable is not set"},
+		{"key not base64", AlgorithmES256, "not base64!", "failed to decode base64 private key"},
+		{"not PEM", AlgorithmES256, encodeKey("private key"), "invalid PEM format"},
+		{"public key", AlgorithmES256, encodeKey(pkixPEM(t, p256.Public())), "unsupported PEM block type PUBLIC KEY"},
+		{"corrupted key", AlgorithmES256, encodeKey(pemBlock("EC PRIVATE KEY", sec1[:len(sec1)/2])), "failed to parse private key"},
+		{"mislabeled key", AlgorithmRS256, encodeKey(pemBlock("RSA PRIVATE KEY", sec1)), "failed to parse private key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &JWTManager{
+				authEnabled:         true,
+				tokenMode:           TokenModeJWT,
+				algorithm:           tt.algorithm,
+				privateKeyBase64:    tt.key,
+				accessTokenDuration: time.Minute,
+			}
+
+			err := manager.setKeys()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, manager.signingMethod)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, strings.ToUpper(tt.algorithm), strings.ToUpper(manager.signingMethod.Alg()))
+
+			// Tokens are signed and verified with the loaded key pair
+			token, err := manager.generateAccessToken(context.Background(), "user-1", nil, nil, time.Now())
+			require.NoError(t, err)
+			claims, err := manager.parseToken(context.Background(), token)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", claims.UserID)
+		})
+	}
+}
+
+func TestSetKeysSkipped(t *testing.T) {
+	for _, manager := range []*JWTManager{
+		{authEnabled: false, tokenMode: TokenModeJWT},
+		{authEnabled: true, tokenMode: TokenModeOpaque},
+	} {
+		require.NoError(t, manager.setKeys(), "no key is needed")
+		assert.Nil(t, manager.signingMethod)
+	}
+}
+
+func TestParseTokenRejectsOtherAlgorithm(t *testing.T) {
+	es256 := newTestJWTManager(t)
+	token, err := es256.generateAccessToken(context.Background(), "user-1", nil, nil, time.Now())
+	require.NoError(t, err)
+
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	eddsa := &JWTManager{authEnabled: true, tokenMode: TokenModeJWT, algorithm: AlgorithmEdDSA, privateKeyBase64: encodeKey(pkcs8PEM(t, ed))}
+	require.NoError(t, eddsa.setKeys())
+
+	_, err = eddsa.parseToken(context.Background(), token)
+	assert.ErrorIs(t, err, ErrTokenJwtParse)
+}
+
+func pemBlock(blockType string, der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
+
+func pkcs8PEM(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pemBlock("PRIVATE KEY", der)
+}
+
+func pkixPEM(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pemBlock("PUBLIC KEY", der)
+}
+
+// encodeKey encodes a PEM key as expected in JWT_PRIVATE_KEY
+func encodeKey(key string) string {
+	return base64.StdEncoding.EncodeToString([]byte(key))
+}
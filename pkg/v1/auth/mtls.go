@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	configKeyServicePrincipals = "MTLS_SERVICE_PRINCIPALS"
+	configKeyServiceRoles      = "MTLS_SERVICE_ROLES"
+)
+
+// Confirmation is the RFC 8705 confirmation claim binding a token to a client certificate
+type Confirmation struct {
+	// X5tS256 is the base64url encoded SHA-256 thumbprint of the client certificate
+	X5tS256 string `json:"x5t#S256"`
+}
+
+// PrincipalMapper maps verified client certificate identities to service principals and their roles
+type PrincipalMapper struct {
+	// principals maps a certificate identity (URI SAN, DNS SAN or CN) to a service principal
+	principals map[string]string
+
+	// roles maps a service principal to the roles it is granted, like the roles of an access token
+	roles map[string][]string
+}
+
+// NewPrincipalMapper creates a mapper from certificate identities to service principals.
+// A principal may only call the endpoints that require one of its roles, or public ones.
+func NewPrincipalMapper(principals map[string]string, roles map[string][]string) *PrincipalMapper {
+	return &PrincipalMapper{principals: principals, roles: roles}
+}
+
+// NewPrincipalMapperFromEnv reads the mapping from MTLS_SERVICE_PRINCIPALS,
+// formatted as comma separated identity=principal pairs, e.g.
+// "spiffe://mesh/ns/billing/sa/api=billing,orders.internal=orders".
+// The roles of the principals are read from MTLS_SERVICE_ROLES, formatted as comma separated
+// principal=role pairs where a principal may be listed once per role, e.g. "billing=admin,orders=user".
+func NewPrincipalMapperFromEnv() *PrincipalMapper {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault(configKeyServicePrincipals, "")
+	vi.SetDefault(configKeyServiceRoles, "")
+
+	principals := map[string]string{}
+	for _, pair := range strings.Split(vi.GetString(configKeyServicePrincipals), ",") {
+		identity, principal, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || identity == "" || principal == "" {
+			continue
+		}
+		principals[identity] = principal
+	}
+
+	roles := map[string][]string{}
+	for _, pair := range strings.Split(vi.GetString(configKeyServiceRoles), ",") {
+		principal, role, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || principal == "" || role == "" {
+			continue
+		}
+		roles[principal] = append(roles[principal], role)
+	}
+
+	return NewPrincipalMapper(principals, roles)
+}
+
+// Roles returns the roles granted to a service principal
+func (p *PrincipalMapper) Roles(principal string) []string {
+	if p == nil {
+		return nil
+	}
+	return p.roles[principal]
+}
+
+// Resolve returns the service principal of a verified client certificate.
+// URI SANs are checked first, then DNS SANs and finally the subject common name.
+func (p *PrincipalMapper) Resolve(cert *x509.Certificate) (string, bool) {
+	if p == nil || cert == nil {
+		return "", false
+	}
+
+	for _, uri := range cert.URIs {
+		if principal, ok := p.principals[uri.String()]; ok {
+			return principal, true
+		}
+	}
+	for _, dns := range cert.DNSNames {
+		if principal, ok := p.principals[dns]; ok {
+			return principal, true
+		}
+	}
+	if principal, ok := p.principals[cert.Subject.CommonName]; ok && cert.Subject.CommonName != "" {
+		return principal, true
+	}
+
+	return "", false
+}
+
+// PeerCertificate returns the verified client certificate of the gRPC peer, if any
+func PeerCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return nil
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0]
+}
+
+// CertificateThumbprint returns the RFC 8705 x5t#S256 thumbprint of a certificate
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
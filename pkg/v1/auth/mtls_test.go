@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestPrincipalMapperResolve(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://mesh/ns/billing/sa/api")
+	mapper := NewPrincipalMapper(map[string]string{
+		"spiffe://mesh/ns/billing/sa/api": "billing",
+		"orders.internal":                 "orders",
+		"reporting":                       "reporting",
+	}, nil)
+
+	tests := []struct {
+		name      string
+		cert      *x509.Certificate
+		principal string
+		found     bool
+	}{
+		{"uri san", newTestCert(t, "ignored", nil, []*url.URL{spiffe}), "billing", true},
+		{"dns san", newTestCert(t, "ignored", []string{"orders.internal"}, nil), "orders", true},
+		{"common name", newTestCert(t, "reporting", nil, nil), "reporting", true},
+		{"unknown identity", newTestCert(t, "stranger", []string{"stranger.internal"}, nil), "", false},
+		{"no certificate", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, found := mapper.Resolve(tt.cert)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.principal, principal)
+		})
+	}
+}
+
+func TestServicePrincipalRoles(t *testing.T) {
+	t.Setenv(configKeyServicePrincipals, "billing.internal=billing, orders.internal=orders,malformed")
+	t.Setenv(configKeyServiceRoles, "billing=admin,billing=user,orders=user,=admin")
+	mapper := NewPrincipalMapperFromEnv()
+	manager := newTestJWTManager(t)
+
+	tests := []struct {
+		name     string
+		identity string
+		endpoint string
+		wantErr  error
+	}{
+		{"admin principal on admin method", "billing.internal", testAdminEndpoint, nil},
+		{"admin principal on user method", "billing.internal", testUserEndpoint, nil},
+		{"user principal on admin method", "orders.internal", testAdminEndpoint, ErrPermissionDenied},
+		{"user principal on user method", "orders.internal", testUserEndpoint, nil},
+		{"any principal on public method", "orders.internal", "/core.user.v1.AuthAPI/Login", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, found := mapper.Resolve(newTestCert(t, "ignored", []string{tt.identity}, nil))
+			require.True(t, found)
+
+			err := manager.AuthorizeRoles(tt.endpoint, mapper.Roles(principal))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	// A principal without configured roles may only call public methods
+	unprivileged := NewPrincipalMapper(map[string]string{"reporting": "reporting"}, nil)
+	assert.Empty(t, unprivileged.Roles("reporting"))
+	assert.ErrorIs(t, manager.AuthorizeRoles(testUserEndpoint, unprivileged.Roles("reporting")), ErrPermissionDenied)
+}
+
+func TestCertificateBinding(t *testing.T) {
+	bound := newTestCert(t, "billing", nil, nil)
+	other := newTestCert(t, "orders", nil, nil)
+	claims := &Claims{Confirmation: &Confirmation{X5tS256: CertificateThumbprint(bound)}}
+
+	assert.NoError(t, checkCertificateBinding(peerContext(bound), claims))
+	assert.ErrorIs(t, checkCertificateBinding(peerContext(other), claims), ErrCertificateBindingMismatch)
+	assert.ErrorIs(t, checkCertificateBinding(context.Background(), claims), ErrCertificateBindingMismatch)
+	assert.NoError(t, checkCertificateBinding(context.Background(), &Claims{}), "unbound tokens are accepted without a certificate")
+
+	manager := &JWTManager{certBoundTokens: true}
+	assert.Equal(t, claims.Confirmation, manager.certificateConfirmation(peerContext(bound)))
+	assert.Nil(t, manager.certificateConfirmation(context.Background()))
+}
+
+func peerContext(cert *x509.Certificate) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+}
+
+func newTestCert(t *testing.T, commonName string, dnsNames []string, uris []*url.URL) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		URIs:         uris,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// opaqueTokenBytes is the amount of random bytes used for an opaque token
+const opaqueTokenBytes = 32
+
+// OpaqueToken represents an issued reference token in the MongoDB collection.
+// Only the SHA-256 hash of the token is stored, the raw value is handed out to the client once.
+type OpaqueToken struct {
+	// TokenHash is the hex encoded SHA-256 hash of the raw token
+	TokenHash string `bson:"_id"`
+
+	// TokenID is the unique identifier of the token (jti)
+	TokenID string `bson:"token_id,omitempty"`
+
+	// UserID of the token owner
+	UserID string `bson:"user_id"`
+
+	// DeviceID that issued the token (if applicable)
+	DeviceID string `bson:"device_id,omitempty"`
+
+	// TokenType distinguishes between access and refresh tokens
+	TokenType string `bson:"token_type"`
+
+	// Roles granted to the token owner
+	Roles []string `bson:"roles,omitempty"`
+
+	// CertThumbprint is the x5t#S256 thumbprint of the client certificate the token is bound to
+	CertThumbprint string `bson:"cnf_x5t_s256,omitempty"`
+
+	// IssuedAt is the time the token was issued
+	IssuedAt time.Time `bson:"issued_at"`
+
+	// ExpiresAt is used by MongoDB's TTL index for automatic cleanup
+	ExpiresAt time.Time `bson:"expires_at"`
+
+	// RotatedAt is set when a refresh token is exchanged. The reference is kept as a tombstone until it
+	// expires, so a reused refresh token is recognized as invalidated instead of unknown.
+	RotatedAt *time.Time `bson:"rotated_at,omitempty"`
+}
+
+// createOpaqueIndexes sets up the required MongoDB indexes for the opaque token store
+func (m *JWTManager) createOpaqueIndexes() error {
+	// Create TTL index for automatic token cleanup
+	ttlIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "expires_at", Value: 1}},
+		Options: &options.IndexOptions{
+			ExpireAfterSeconds: new(int32), // Expire immediately after expires_at
+		},
+	}
+	if _, err := m.opaqueCollection.Indexes().CreateOne(context.Background(), ttlIndex); err != nil {
+		return fmt.Errorf("failed to create TTL index: %w", err)
+	}
+
+	// Create index for looking up tokens of a user
+	userIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+	}
+	if _, err := m.opaqueCollection.Indexes().CreateOne(context.Background(), userIndex); err != nil {
+		return fmt.Errorf("failed to create user index: %w", err)
+	}
+
+	return nil
+}
+
+// issueOpaqueToken generates a random token and stores its hash together with the claims
+func (m *JWTManager) issueOpaqueToken(ctx context.Context, claims Claims) (string, error) {
+	raw := make([]byte, opaqueTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	doc := OpaqueToken{
+		TokenHash: hashOpaqueToken(token),
+		TokenID:   claims.ID,
+		UserID:    claims.UserID,
+		DeviceID:  claims.DeviceID,
+		TokenType: claims.TokenType,
+		Roles:     claims.Roles,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if claims.Confirmation != nil {
+		doc.CertThumbprint = claims.Confirmation.X5tS256
+	}
+	if _, err := m.opaqueCollection.InsertOne(ctx, doc); err != nil {
+		return "", fmt.Errorf("failed to store opaque token: %w", err)
+	}
+
+	return token, nil
+}
+
+// resolveOpaqueToken looks up the stored reference of an opaque token and returns its claims
+func (m *JWTManager) resolveOpaqueToken(ctx context.Context, tokenStr string) (*Claims, error) {
+	var doc OpaqueToken
+	err := m.opaqueCollection.FindOne(ctx, bson.M{"_id": hashOpaqueToken(tokenStr)}).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrInvalidToken
+		}
+		return nil, ErrTokenStatusVerificationFailed.SetOriginErr(err)
+	}
+
+	// TTL cleanup runs periodically, so expiration must be checked explicitly
+	if !time.Now().Before(doc.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+
+	claims := &Claims{
+		UserID:    doc.UserID,
+		TokenType: doc.TokenType,
+		DeviceID:  doc.DeviceID,
+		Roles:     doc.Roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(doc.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(doc.IssuedAt),
+			ID:        doc.TokenID,
+		},
+	}
+	if doc.CertThumbprint != "" {
+		claims.Confirmation = &Confirmation{X5tS256: doc.CertThumbprint}
+	}
+	claims.rotated = doc.RotatedAt != nil
+
+	return claims, nil
+}
+
+// rotateOpaqueToken marks the stored reference of an opaque refresh token as rotated.
+// Only one caller can rotate a token, the others get ErrRefreshTokenReused.
+func (m *JWTManager) rotateOpaqueToken(ctx context.Context, tokenStr string, rotatedAt time.Time) error {
+	filter := bson.M{"_id": hashOpaqueToken(tokenStr), "rotated_at": bson.M{"$exists": false}}
+	result, err := m.opaqueCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"rotated_at": rotatedAt}})
+	if err != nil {
+		return ErrInvalidateTokenFailed.SetOriginErr(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrRefreshTokenReused
+	}
+	return nil
+}
+
+// hashOpaqueToken returns the hex encoded SHA-256 hash of a raw opaque token
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestOpaqueRefreshTokenReuse(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	now := time.Now().Truncate(time.Millisecond)
+	resolveRoles := func(ctx context.Context, userID string) ([]string, error) { return nil, nil }
+
+	mt.Run("rotation", func(mt *mtest.T) {
+		manager := newTestOpaqueManager(mt)
+		mt.AddMockResponses(
+			opaqueTokenResponse(mt, refreshTokenDoc(now, nil)),
+			noInvalidationResponse(mt),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateSuccessResponse(), // access token
+			mtest.CreateSuccessResponse(), // refresh token
+			mtest.CreateSuccessResponse(), // invalidation
+		)
+
+		accessToken, refreshToken, err := manager.RefreshTokens(context.Background(), "refresh-token", resolveRoles)
+		require.NoError(mt, err)
+		assert.NotEmpty(mt, accessToken)
+		assert.NotEqual(mt, "refresh-token", refreshToken)
+	})
+
+	mt.Run("reuse of a rotated token", func(mt *mtest.T) {
+		manager := newTestOpaqueManager(mt)
+		rotatedAt := now.Add(-time.Minute)
+		mt.AddMockResponses(opaqueTokenResponse(mt, refreshTokenDoc(now, &rotatedAt)))
+
+		_, _, err := manager.RefreshTokens(context.Background(), "refresh-token", resolveRoles)
+		assert.ErrorIs(mt, err, ErrRefreshTokenReused)
+
+		// The owner of the reused token is still known, so its sessions can be revoked
+		mt.AddMockResponses(opaqueTokenResponse(mt, refreshTokenDoc(now, &rotatedAt)))
+		claims, err := manager.ParseRefreshToken(context.Background(), "refresh-token")
+		require.NoError(mt, err)
+		assert.Equal(mt, "user-1", claims.UserID)
+		assert.Equal(mt, "device-1", claims.DeviceID)
+	})
+
+	mt.Run("concurrent rotation", func(mt *mtest.T) {
+		manager := newTestOpaqueManager(mt)
+		mt.AddMockResponses(
+			opaqueTokenResponse(mt, refreshTokenDoc(now, nil)),
+			noInvalidationResponse(mt),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+		)
+
+		_, _, err := manager.RefreshTokens(context.Background(), "refresh-token", resolveRoles)
+		assert.ErrorIs(mt, err, ErrRefreshTokenReused, "only one exchange of a refresh token succeeds")
+	})
+}
+
+func TestOpaqueTokens(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	now := time.Now().Truncate(time.Millisecond)
+
+	mt.Run("issue", func(mt *mtest.T) {
+		manager := newTestOpaqueManager(mt)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		accessToken, refreshToken, err := manager.GenerateTokenPair(context.Background(), "user-1", "device-1", []string{RoleAdmin})
+		require.NoError(mt, err)
+		assert.NotEqual(mt, accessToken, refreshToken)
+
+		for _, tt := range []struct {
+			token     string
+			tokenType string
+			ttl       time.Duration
+		}{
+			{accessToken, TokenTypeAccess, time.Minute},
+			{refreshToken, TokenTypeRefresh, time.Hour},
+		} {
+			insert := mt.GetStartedEvent()
+			require.Equal(mt, "insert", insert.CommandName)
+			var doc OpaqueToken
+			require.NoError(mt, bson.Unmarshal(insert.Command.Lookup("documents").Array().Index(0).Value().Document(), &doc))
+
+			// Only the hash of the token is stored
+			assert.Len(mt, tt.token, 43)
+			assert.Equal(mt, hashOpaqueToken(tt.token), doc.TokenHash)
+			assert.NotContains(mt, insert.Command.String(), tt.token)
+			assert.Equal(mt, "user-1", doc.UserID)
+			assert.Equal(mt, tt.tokenType, doc.TokenType)
+			assert.Equal(mt, tt.ttl, doc.ExpiresAt.Sub(doc.IssuedAt))
+		}
+	})
+
+	mt.Run("validate", func(mt *mtest.T) {
+		manager := newTestOpaqueManager(mt)
+		mt.AddMockResponses(
+			opaqueTokenResponse(mt, accessTokenDoc(now, now.Add(time.Minute))),
+			noInvalidationResponse(mt),
+		)
+
+		claims, err := manager.Validate(context.Background(), "access-token")
+		require.NoError(mt, err)
+		assert.Equal(mt, "user-1", claims.UserID)
+		assert.Equal(mt, []string{RoleUser, RoleAdmin}, claims.Roles)
+		assert.Equal(mt, now.Unix(), claims.IssuedAt.Unix())
+
+		lookup := mt.GetStartedEvent()
+		require.Equal(mt, "find", lookup.CommandName)
+		assert.Equal(mt, hashOpaqueToken("access-token"), lookup.Command.Lookup("filter", "_id").StringValue())
+	})
+
+	mt.Run("unknown token", func(mt *mtest.T) {
+		manager := newTestOpaqueManager(mt)
+		mt.AddMockResponses(noInvalidationResponse(mt))
+
+		_, err := manager.Validate(context.Background(), "access-token")
+		assert.ErrorIs(mt, err, ErrInvalidToken)
+	})
+
+	mt.Run("refresh token as access token", func(mt *mtest.T) {
+		manager := newTestOpaqueManager(mt)
+		mt.AddMockResponses(opaqueTokenResponse(mt, refreshTokenDoc(now, nil)))
+
+		_, err := manager.Validate(context.Background(), "refresh-token")
+		assert.ErrorIs(mt, err, ErrInvalidTokenTypeExpectedAccess)
+	})
+
+	mt.Run("revoke", func(mt *mtest.T) {
+		manager := newTestOpaqueManager(mt)
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		require.NoError(mt, manager.InvalidateUserTokens(context.Background(), "user-1"))
+		insert := mt.GetStartedEvent()
+		require.Equal(mt, "insert", insert.CommandName)
+		invalidations, err := insert.Command.Lookup("documents").Array().Values()
+		require.NoError(mt, err)
+		assert.Len(mt, invalidations, 2, "access and refresh tokens are invalidated")
+
+		// The stored token is found, but it was issued before the invalidation
+		raw, err := bson.Marshal(UserInvalidatedToken{UserID: "user-1", TokenType: TokenTypeAccess, InvalidatedAt: now})
+		require.NoError(mt, err)
+		var invalidation bson.D
+		require.NoError(mt, bson.Unmarshal(raw, &invalidation))
+		mt.AddMockResponses(
+			opaqueTokenResponse(mt, accessTokenDoc(now.Add(-time.Second), now.Add(time.Minute))),
+			mtest.CreateCursorResponse(0, mt.Coll.Database().Name()+"."+mt.Coll.Name(), mtest.FirstBatch, invalidation),
+		)
+
+		_, err = manager.Validate(context.Background(), "access-token")
+		assert.ErrorIs(mt, err, ErrTokenInvalidated)
+	})
+
+	mt.Run("expiry", func(mt *mtest.T) {
+		manager := newTestOpaqueManager(mt)
+		// The TTL index has not removed the expired token yet
+		mt.AddMockResponses(opaqueTokenResponse(mt, accessTokenDoc(now.Add(-2*time.Minute), now.Add(-time.Minute))))
+
+		_, err := manager.Validate(context.Background(), "access-token")
+		assert.ErrorIs(mt, err, ErrTokenExpired)
+
+		mt.AddMockResponses(opaqueTokenResponse(mt, refreshTokenDoc(now.Add(-2*time.Hour), nil)))
+		_, _, err = manager.RefreshTokens(context.Background(), "refresh-token", nil)
+		assert.ErrorIs(mt, err, ErrTokenExpired)
+	})
+}
+
+// newTestOpaqueManager returns an opaque mode manager storing its tokens in the mocked collection
+func newTestOpaqueManager(mt *mtest.T) *JWTManager {
+	return &JWTManager{
+		authEnabled:          true,
+		tokenMode:            TokenModeOpaque,
+		collection:           mt.Coll,
+		opaqueCollection:     mt.Coll,
+		accessTokenDuration:  time.Minute,
+		refreshTokenDuration: time.Hour,
+	}
+}
+
+func refreshTokenDoc(issuedAt time.Time, rotatedAt *time.Time) OpaqueToken {
+	return OpaqueToken{
+		TokenHash: hashOpaqueToken("refresh-token"),
+		TokenID:   "token-1",
+		UserID:    "user-1",
+		DeviceID:  "device-1",
+		TokenType: TokenTypeRefresh,
+		IssuedAt:  issuedAt,
+		ExpiresAt: issuedAt.Add(time.Hour),
+		RotatedAt: rotatedAt,
+	}
+}
+
+func accessTokenDoc(issuedAt time.Time, expiresAt time.Time) OpaqueToken {
+	return OpaqueToken{
+		TokenHash: hashOpaqueToken("access-token"),
+		UserID:    "user-1",
+		TokenType: TokenTypeAccess,
+		Roles:     []string{RoleUser, RoleAdmin},
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// opaqueTokenResponse is the reply of a lookup finding the given token document
+func opaqueTokenResponse(mt *mtest.T, doc OpaqueToken) bson.D {
+	raw, err := bson.Marshal(doc)
+	require.NoError(mt, err)
+	var d bson.D
+	require.NoError(mt, bson.Unmarshal(raw, &d))
+	return mtest.CreateCursorResponse(0, mt.Coll.Database().Name()+"."+mt.Coll.Name(), mtest.FirstBatch, d)
+}
+
+// noInvalidationResponse is the reply of a lookup finding no invalidation record
+func noInvalidationResponse(mt *mtest.T) bson.D {
+	return mtest.CreateCursorResponse(0, mt.Coll.Database().Name()+"."+mt.Coll.Name(), mtest.FirstBatch)
+}
@@ -0,0 +1,114 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Supported blob store drivers
+const (
+	DriverNone = "none" // no blob store is configured
+	DriverFile = "file" // objects are files below a local directory, meant for development
+)
+
+// ErrNotFound is returned by Get if there is no object under the key
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps binary objects under slash separated keys. A nil Store means no store is configured.
+type Store interface {
+	// Put writes the object under the key, replacing an existing one
+	Put(ctx context.Context, key string, data io.Reader) error
+	// Get opens the object under the key, the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// NewFromEnv creates the store selected with BLOB_STORE_DRIVER, nil if no store is configured.
+// The file driver keeps the objects below BLOB_STORE_DIR.
+func NewFromEnv() Store {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault("BLOB_STORE_DRIVER", DriverNone)
+	vi.SetDefault("BLOB_STORE_DIR", "data/blobs")
+
+	switch driver := strings.ToLower(vi.GetString("BLOB_STORE_DRIVER")); driver {
+	case DriverFile:
+		return NewFileStore(vi.GetString("BLOB_STORE_DIR"))
+	case DriverNone, "":
+		return nil
+	default:
+		slog.Warn("unsupported blob store driver, blobs cannot be stored", "driver", driver)
+		return nil
+	}
+}
+
+// FileStore keeps the objects as files below a directory
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store below dir, which is created on the first write
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Put writes the object to a temporary file first, so a failed write never leaves a partial object behind
+func (s *FileStore) Put(ctx context.Context, key string, data io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".blob-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return file, nil
+}
+
+// path maps the key to a file below the directory, keys leaving the directory are rejected
+func (s *FileStore) path(key string) (string, error) {
+	if !fs.ValidPath(key) || key == "." {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
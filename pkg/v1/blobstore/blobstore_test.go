@@ -0,0 +1,37 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileStore(t.TempDir())
+
+	require.NoError(t, store.Put(ctx, "exports/user-1/data.json", strings.NewReader("first")))
+	require.NoError(t, store.Put(ctx, "exports/user-1/data.json", strings.NewReader("second")))
+
+	blob, err := store.Get(ctx, "exports/user-1/data.json")
+	require.NoError(t, err)
+	defer blob.Close()
+	data, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	_, err = store.Get(ctx, "exports/user-2/data.json")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFileStoreRejectsInvalidKeys(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+
+	for _, key := range []string{"", ".", "../outside", "/absolute", "exports//data.json"} {
+		assert.Error(t, store.Put(context.Background(), key, strings.NewReader("data")), key)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
 	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -84,6 +85,34 @@ func (m *MongoDBWrapper) Collection(name string) *mongo.Collection {
 	return m.Database.Collection(name)
 }
 
+// WithTransaction runs fn in a transaction, which is committed if fn succeeds and aborted otherwise.
+// All operations of the transaction must use the context passed to fn, it carries the session.
+// Transactions require a replica set or sharded cluster.
+func (m *MongoDBWrapper) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := m.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (any, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
+// SupportsTransactions reports whether the server is a replica set member or a mongos, which support transactions
+func (m *MongoDBWrapper) SupportsTransactions(ctx context.Context) (bool, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := m.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false, err
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
+
 // Disconnect gracefully closes the MongoDB connection
 func (m *MongoDBWrapper) Disconnect() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -90,6 +90,11 @@ func (e *errorWrapper) OriginErr() error {
 	return e.originErr
 }
 
+// Unwrap exposes the origin error to errors.Is and errors.As, e.g. for the labels of a mongo error
+func (e *errorWrapper) Unwrap() error {
+	return e.originErr
+}
+
 func (e *errorWrapper) clone() *errorWrapper {
 	if e == nil {
 		return nil
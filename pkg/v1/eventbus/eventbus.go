@@ -0,0 +1,82 @@
+package eventbus
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// Supported event bus drivers
+const (
+	DriverNone  = "none"  // events are not published
+	DriverLocal = "local" // in-process fan-out to subscribers
+)
+
+// Publisher publishes events to a message bus. A nil Publisher means no bus is configured.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, data []byte) error
+}
+
+// Handler consumes events published on a topic
+type Handler func(ctx context.Context, topic string, data []byte)
+
+// NewFromEnv creates the publisher selected with EVENT_BUS_DRIVER, nil if no bus is configured
+func NewFromEnv() Publisher {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault("EVENT_BUS_DRIVER", DriverNone)
+
+	switch driver := strings.ToLower(vi.GetString("EVENT_BUS_DRIVER")); driver {
+	case DriverLocal:
+		return NewLocal()
+	case DriverNone, "":
+		return nil
+	default:
+		slog.Warn("unsupported event bus driver, events will not be published", "driver", driver)
+		return nil
+	}
+}
+
+// LocalBus is an in-process event bus delivering events synchronously to its subscribers
+type LocalBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewLocal creates an in-process event bus
+func NewLocal() *LocalBus {
+	return &LocalBus{handlers: map[string][]Handler{}}
+}
+
+// Subscribe registers a handler for a topic. A topic ending with ".>" matches every topic with that prefix.
+func (b *LocalBus) Subscribe(topic string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+// Publish delivers the event to every subscriber of the topic
+func (b *LocalBus) Publish(ctx context.Context, topic string, data []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for pattern, handlers := range b.handlers {
+		if !matchTopic(pattern, topic) {
+			continue
+		}
+		for _, h := range handlers {
+			h(ctx, topic, data)
+		}
+	}
+	return nil
+}
+
+func matchTopic(pattern, topic string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, ".>"); ok {
+		return strings.HasPrefix(topic, prefix+".")
+	}
+	return pattern == topic
+}
@@ -0,0 +1,246 @@
+// Package filter compiles AIP-160 style filter expressions into MongoDB queries.
+//
+// Example:
+//
+//	country IN (TR, DE) AND status != INACTIVE AND create_time >= "2024-01-01T00:00:00Z"
+//
+// Only fields declared in a Schema can be filtered. String values ending with `*` match by prefix,
+// the `:` comparator matches a substring. Both are case-insensitive and escape regex meta characters.
+package filter
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+)
+
+// FieldType is the type of a filterable field
+type FieldType int
+
+const (
+	// String fields support all comparators, prefix matching with a trailing `*` and `:` for substrings
+	String FieldType = iota
+
+	// Enum fields map symbolic names to stored values and only support =, != and IN
+	Enum
+
+	// Timestamp fields accept RFC 3339 timestamps or dates (2006-01-02) and support =, !=, <, <=, >, >= and IN
+	Timestamp
+
+	// Bool fields accept true and false and only support =, != and IN
+	Bool
+
+	// Number fields accept decimal numbers and support =, !=, <, <=, >, >= and IN
+	Number
+)
+
+// Field describes a filterable field
+type Field struct {
+	// Path is the document field the filter field is stored in
+	Path string
+
+	// Type of the field
+	Type FieldType
+
+	// Values maps the accepted names of an Enum field to their stored values, names are case-insensitive
+	Values map[string]any
+}
+
+// Schema is the whitelist of filterable fields keyed by their name in filter expressions
+type Schema map[string]Field
+
+// Filter is a compiled filter expression
+type Filter struct {
+	query bson.M
+	paths []string
+}
+
+// ToBson returns the MongoDB query of the filter
+func (f *Filter) ToBson() bson.M {
+	return f.query
+}
+
+// References reports whether the filter restricts the document field path
+func (f *Filter) References(path string) bool {
+	for _, p := range f.paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses a filter expression and compiles it against the schema.
+// Errors are returned as InvalidArgument.
+func Parse(expression string, schema Schema) (*Filter, error) {
+	if len(expression) > MaxLength {
+		return nil, invalidFilter(fmt.Errorf("filter is longer than %d characters", MaxLength))
+	}
+
+	n, err := parse(expression)
+	if err != nil {
+		return nil, invalidFilter(err)
+	}
+
+	c := &compiler{schema: schema}
+	query, err := c.compile(n)
+	if err != nil {
+		return nil, invalidFilter(err)
+	}
+
+	return &Filter{query: query, paths: c.paths}, nil
+}
+
+func invalidFilter(err error) error {
+	return errwrap.NewError("invalid filter: "+err.Error(), codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+}
+
+// compiler turns a parsed expression into a MongoDB query
+type compiler struct {
+	schema Schema
+	paths  []string
+}
+
+func (c *compiler) compile(n node) (bson.M, error) {
+	switch n := n.(type) {
+	case andNode:
+		children, err := c.compileAll(n.children)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$and": children}, nil
+	case orNode:
+		children, err := c.compileAll(n.children)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$or": children}, nil
+	case notNode:
+		child, err := c.compile(n.child)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": bson.A{child}}, nil
+	case restriction:
+		return c.compileRestriction(n)
+	}
+	return nil, fmt.Errorf("unsupported expression")
+}
+
+func (c *compiler) compileAll(nodes []node) (bson.A, error) {
+	compiled := make(bson.A, 0, len(nodes))
+	for _, n := range nodes {
+		query, err := c.compile(n)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, query)
+	}
+	return compiled, nil
+}
+
+func (c *compiler) compileRestriction(r restriction) (bson.M, error) {
+	field, ok := c.schema[r.field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %s at position %d", r.field, r.pos)
+	}
+	c.paths = append(c.paths, field.Path)
+
+	if r.comparator == "IN" {
+		values := make(bson.A, 0, len(r.values))
+		for _, raw := range r.values {
+			value, err := field.convert(r.field, raw)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return bson.M{field.Path: bson.M{"$in": values}}, nil
+	}
+
+	raw := r.values[0]
+	if field.Type == String {
+		switch {
+		case r.comparator == ":":
+			return bson.M{field.Path: bson.M{"$regex": regexp.QuoteMeta(raw), "$options": "i"}}, nil
+		case r.comparator == "=" && strings.HasSuffix(raw, "*"):
+			return bson.M{field.Path: bson.M{"$regex": "^" + regexp.QuoteMeta(strings.TrimSuffix(raw, "*")), "$options": "i"}}, nil
+		}
+	}
+
+	if !field.supports(r.comparator) {
+		return nil, fmt.Errorf("comparator %s is not supported for field %s", r.comparator, r.field)
+	}
+	value, err := field.convert(r.field, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.comparator {
+	case "=":
+		return bson.M{field.Path: value}, nil
+	case "!=":
+		return bson.M{field.Path: bson.M{"$ne": value}}, nil
+	case "<":
+		return bson.M{field.Path: bson.M{"$lt": value}}, nil
+	case "<=":
+		return bson.M{field.Path: bson.M{"$lte": value}}, nil
+	case ">":
+		return bson.M{field.Path: bson.M{"$gt": value}}, nil
+	default:
+		return bson.M{field.Path: bson.M{"$gte": value}}, nil
+	}
+}
+
+func (f Field) supports(comparator string) bool {
+	switch f.Type {
+	case Enum, Bool:
+		return comparator == "=" || comparator == "!="
+	case Timestamp, Number:
+		return comparator != ":"
+	}
+	return true
+}
+
+// convert parses a raw filter value into the stored type of the field
+func (f Field) convert(name, raw string) (any, error) {
+	switch f.Type {
+	case Enum:
+		for key, value := range f.Values {
+			if strings.EqualFold(key, raw) {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid value %q for field %s", raw, name)
+	case Timestamp:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t.UTC(), nil
+		}
+		if t, err := time.Parse(time.DateOnly, raw); err == nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("invalid timestamp %q for field %s, expected RFC 3339 or 2006-01-02", raw, name)
+	case Bool:
+		switch raw {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid value %q for field %s, expected true or false", raw, name)
+	case Number:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("invalid number %q for field %s", raw, name)
+		}
+		return n, nil
+	}
+	return raw, nil
+}
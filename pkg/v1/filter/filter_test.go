@@ -0,0 +1,99 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var testSchema = Schema{
+	"name":        {Path: "name", Type: String},
+	"country":     {Path: "country", Type: String},
+	"status":      {Path: "status", Type: Enum, Values: map[string]any{"ACTIVE": 1, "INACTIVE": 2}},
+	"create_time": {Path: "createdAt", Type: Timestamp},
+	"verified":    {Path: "attrs.verified", Type: Bool},
+	"score":       {Path: "attrs.score", Type: Number},
+}
+
+func TestParse(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   bson.M
+	}{
+		{"equality", `country = TR`, bson.M{"country": "TR"}},
+		{"quoted value", `name = "Ahmet Yılmaz"`, bson.M{"name": "Ahmet Yılmaz"}},
+		{"enum", `status != inactive`, bson.M{"status": bson.M{"$ne": 2}}},
+		{"in", `country IN (TR, "DE")`, bson.M{"country": bson.M{"$in": bson.A{"TR", "DE"}}}},
+		{"date window", `create_time >= 2024-01-01 AND create_time < "2024-02-01T00:00:00Z"`, bson.M{"$and": bson.A{
+			bson.M{"createdAt": bson.M{"$gte": created}},
+			bson.M{"createdAt": bson.M{"$lt": created.AddDate(0, 1, 0)}},
+		}}},
+		{"bool", `verified = true`, bson.M{"attrs.verified": true}},
+		{"number", `score >= 2.5`, bson.M{"attrs.score": bson.M{"$gte": 2.5}}},
+		{"prefix is escaped", `name = "a.b(*"`, bson.M{"name": bson.M{"$regex": `^a\.b\(`, "$options": "i"}}},
+		{"has is escaped", `name:"x+y"`, bson.M{"name": bson.M{"$regex": `x\+y`, "$options": "i"}}},
+		{"negation", `-country = TR`, bson.M{"$nor": bson.A{bson.M{"country": "TR"}}}},
+		{"implicit and", `country = TR NOT status = ACTIVE`, bson.M{"$and": bson.A{
+			bson.M{"country": "TR"},
+			bson.M{"$nor": bson.A{bson.M{"status": 1}}},
+		}}},
+		{"or binds tighter than and", `name = a AND country = TR OR country = DE`, bson.M{"$and": bson.A{
+			bson.M{"name": "a"},
+			bson.M{"$or": bson.A{bson.M{"country": "TR"}, bson.M{"country": "DE"}}},
+		}}},
+		{"parentheses", `(name = a AND country = TR) OR country = DE`, bson.M{"$or": bson.A{
+			bson.M{"$and": bson.A{bson.M{"name": "a"}, bson.M{"country": "TR"}}},
+			bson.M{"country": "DE"},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.expression, testSchema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, f.ToBson())
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"unknown field", `password = x`},
+		{"unknown enum value", `status = deleted`},
+		{"unsupported comparator", `status > ACTIVE`},
+		{"invalid timestamp", `create_time > yesterday`},
+		{"invalid bool", `verified = yes`},
+		{"unsupported bool comparator", `verified > false`},
+		{"invalid number", `score < NaN`},
+		{"missing value", `country =`},
+		{"unbalanced parentheses", `(country = TR`},
+		{"unterminated string", `name = "abc`},
+		{"dangling operator", `country = TR AND`},
+		{"too deep", `((((((((((((((((((country = TR))))))))))))))))))`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression, testSchema)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	f, err := Parse(`status = ACTIVE OR create_time > 2024-01-01`, testSchema)
+	require.NoError(t, err)
+
+	assert.True(t, f.References("status"))
+	assert.True(t, f.References("createdAt"))
+	assert.False(t, f.References("country"))
+}
@@ -0,0 +1,115 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenComparator
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenMinus
+)
+
+// token is a lexical token of a filter expression
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// comparators ordered so that two character operators are matched first
+var comparators = []string{"<=", ">=", "!=", "=", "<", ">", ":"}
+
+// lex splits a filter expression into tokens.
+// Words end at whitespace, parentheses, commas, comparators and quotes, so values containing those must be quoted.
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := rune(input[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, value: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, value: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", pos: i})
+			i++
+		case c == '-':
+			tokens = append(tokens, token{kind: tokenMinus, value: "-", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			value, end, err := lexString(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value, pos: i})
+			i = end
+		default:
+			if op := matchComparator(input[i:]); op != "" {
+				tokens = append(tokens, token{kind: tokenComparator, value: op, pos: i})
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(input) && isWordByte(input[i]) {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("unexpected character %q at position %d", input[i], i)
+			}
+			tokens = append(tokens, token{kind: tokenWord, value: input[start:i], pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input)}), nil
+}
+
+// lexString reads a quoted string starting at input[start], backslash escapes the next character
+func lexString(input string, start int) (string, int, error) {
+	quote := input[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if i+1 == len(input) {
+				return "", 0, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			sb.WriteByte(input[i])
+		case quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(input[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at position %d", start)
+}
+
+func matchComparator(input string) string {
+	for _, op := range comparators {
+		if strings.HasPrefix(input, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isWordByte(b byte) bool {
+	switch b {
+	case ' ', '\t', '\n', '\r', '(', ')', ',', '"', '\'', '=', '!', '<', '>', ':':
+		return false
+	}
+	return true
+}
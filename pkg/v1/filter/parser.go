@@ -0,0 +1,261 @@
+package filter
+
+import (
+	"fmt"
+)
+
+const (
+	// MaxLength is the maximum accepted length of a filter expression
+	MaxLength = 2048
+
+	// maxDepth limits the nesting of parentheses and negations
+	maxDepth = 16
+)
+
+// node is a node of a parsed filter expression
+type node interface{}
+
+// andNode matches if all of its children match
+type andNode struct{ children []node }
+
+// orNode matches if any of its children matches
+type orNode struct{ children []node }
+
+// notNode matches if its child does not match
+type notNode struct{ child node }
+
+// restriction compares a field with one or more values
+type restriction struct {
+	field      string
+	comparator string // one of comparators or "IN"
+	values     []string
+	pos        int
+}
+
+// parser is a recursive descent parser for the AIP-160 filter grammar:
+//
+//	expression  = sequence { "AND" sequence }
+//	sequence    = factor { factor }
+//	factor      = term { "OR" term }
+//	term        = [ "NOT" | "-" ] simple
+//	simple      = "(" expression ")" | restriction
+//	restriction = field comparator value | field "IN" "(" value { "," value } ")"
+//
+// As in AIP-160, OR binds tighter than AND and a sequence of terms is an implicit AND.
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func parse(input string) (node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.value, t.pos)
+	}
+	return n, nil
+}
+
+func (p *parser) expression() (node, error) {
+	return p.list("AND", p.sequence, func(children []node) node { return andNode{children} })
+}
+
+func (p *parser) sequence() (node, error) {
+	first, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []node{first}
+	for p.startsTerm() {
+		next, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return andNode{children}, nil
+}
+
+func (p *parser) factor() (node, error) {
+	return p.list("OR", p.term, func(children []node) node { return orNode{children} })
+}
+
+// list parses elements separated by a keyword
+func (p *parser) list(keyword string, element func() (node, error), combine func([]node) node) (node, error) {
+	first, err := element()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []node{first}
+	for p.isKeyword(keyword) {
+		p.next()
+		next, err := element()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return combine(children), nil
+}
+
+func (p *parser) term() (node, error) {
+	if p.isKeyword("NOT") || p.peek().kind == tokenMinus {
+		p.next()
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+
+		child, err := p.simple()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{child}, nil
+	}
+	return p.simple()
+}
+
+func (p *parser) simple() (node, error) {
+	if p.peek().kind != tokenLParen {
+		return p.restriction()
+	}
+
+	p.next()
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	n, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.next(); t.kind != tokenRParen {
+		return nil, fmt.Errorf("expected ) at position %d", t.pos)
+	}
+	return n, nil
+}
+
+func (p *parser) restriction() (node, error) {
+	field := p.next()
+	if field.kind != tokenWord || isReserved(field.value) {
+		return nil, fmt.Errorf("expected field name at position %d", field.pos)
+	}
+
+	if p.isKeyword("IN") {
+		p.next()
+		values, err := p.valueList()
+		if err != nil {
+			return nil, err
+		}
+		return restriction{field: field.value, comparator: "IN", values: values, pos: field.pos}, nil
+	}
+
+	comparator := p.next()
+	if comparator.kind != tokenComparator {
+		return nil, fmt.Errorf("expected comparator after %s at position %d", field.value, comparator.pos)
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	return restriction{field: field.value, comparator: comparator.value, values: []string{value}, pos: field.pos}, nil
+}
+
+func (p *parser) valueList() ([]string, error) {
+	if t := p.next(); t.kind != tokenLParen {
+		return nil, fmt.Errorf("expected ( after IN at position %d", t.pos)
+	}
+
+	var values []string
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		switch t := p.next(); t.kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return values, nil
+		default:
+			return nil, fmt.Errorf("expected , or ) at position %d", t.pos)
+		}
+	}
+}
+
+func (p *parser) value() (string, error) {
+	t := p.next()
+	if t.kind == tokenString || (t.kind == tokenWord && !isReserved(t.value)) {
+		return t.value, nil
+	}
+	return "", fmt.Errorf("expected value at position %d", t.pos)
+}
+
+// startsTerm reports whether the next token starts another term of an implicit AND sequence
+func (p *parser) startsTerm() bool {
+	t := p.peek()
+	switch t.kind {
+	case tokenLParen, tokenMinus:
+		return true
+	case tokenWord:
+		return !isReserved(t.value) || t.value == "NOT"
+	}
+	return false
+}
+
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return fmt.Errorf("filter is nested too deeply")
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && t.value == keyword
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func isReserved(word string) bool {
+	switch word {
+	case "AND", "OR", "NOT", "IN":
+		return true
+	}
+	return false
+}
@@ -5,6 +5,19 @@ import "github.com/spf13/viper"
 type ServerConfig struct {
 	Port              int
 	EnableHealthCheck bool
+	TLS               TLSConfig
+}
+
+// TLSConfig configures transport security and client certificate verification
+type TLSConfig struct {
+	Enabled bool
+	// CertFile and KeyFile are the PEM encoded server certificate and key
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables mTLS, client certificates are verified against the CAs in it
+	ClientCAFile string
+	// ClientAuth is either "require" (default) or "optional" when ClientCAFile is set
+	ClientAuth string
 }
 
 func NewServerConfigFromEnv() ServerConfig {
@@ -13,8 +26,20 @@ func NewServerConfigFromEnv() ServerConfig {
 
 	vi.SetDefault("GRPC_SERVER_PORT", 3000)
 	vi.SetDefault("GRPC_SERVER_HEALTH", true)
+	vi.SetDefault("GRPC_TLS_ENABLED", false)
+	vi.SetDefault("GRPC_TLS_CERT_FILE", "")
+	vi.SetDefault("GRPC_TLS_KEY_FILE", "")
+	vi.SetDefault("GRPC_TLS_CLIENT_CA_FILE", "")
+	vi.SetDefault("GRPC_TLS_CLIENT_AUTH", ClientAuthRequire)
 	return ServerConfig{
 		Port:              vi.GetInt("GRPC_SERVER_PORT"),
 		EnableHealthCheck: vi.GetBool("GRPC_SERVER_HEALTH"),
+		TLS: TLSConfig{
+			Enabled:      vi.GetBool("GRPC_TLS_ENABLED"),
+			CertFile:     vi.GetString("GRPC_TLS_CERT_FILE"),
+			KeyFile:      vi.GetString("GRPC_TLS_KEY_FILE"),
+			ClientCAFile: vi.GetString("GRPC_TLS_CLIENT_CA_FILE"),
+			ClientAuth:   vi.GetString("GRPC_TLS_CLIENT_AUTH"),
+		},
 	}
 }
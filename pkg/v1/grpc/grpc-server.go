@@ -10,6 +10,7 @@ import (
 
 	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -27,6 +28,7 @@ type server struct {
 	config     ServerConfig
 	options    *GrpcOption
 	grpcServer *grpc.Server
+	tlsErr     error
 }
 
 type GrpcOption struct {
@@ -41,14 +43,37 @@ func New(options ...OptionFn) GrpcServer {
 		o(grpcOption)
 	}
 
+	config := NewServerConfigFromEnv()
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(grpcOption.UnaryInterceptors...),
+		grpc.ChainStreamInterceptor(grpcOption.StreamInterceptors...),
+	}
+
+	// TLS errors are reported on Init since the server must exist for service registration
+	var tlsErr error
+	if config.TLS.Enabled {
+		tlsConfig, err := NewTLSConfig(config.TLS)
+		if err != nil {
+			tlsErr = err
+		} else {
+			serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+	}
+
 	return &server{
-		config:     NewServerConfigFromEnv(),
+		config:     config,
 		options:    grpcOption,
-		grpcServer: grpc.NewServer(grpc.ChainUnaryInterceptor(grpcOption.UnaryInterceptors...), grpc.ChainStreamInterceptor(grpcOption.StreamInterceptors...)),
+		grpcServer: grpc.NewServer(serverOptions...),
+		tlsErr:     tlsErr,
 	}
 }
 
 func (s *server) Init() error {
+	if s.tlsErr != nil {
+		slog.Error("Failed to configure TLS", "err", s.tlsErr)
+		return s.tlsErr
+	}
+
 	reflection.Register(s.grpcServer)
 
 	listener, err := net.Listen("tcp", ":3000")
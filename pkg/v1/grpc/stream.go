@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// serverStream overrides the context of a server stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// WithStreamContext returns the stream with ctx as its context.
+// Stream interceptors use it to pass values to the handler, like unary interceptors do with the handler context.
+func WithStreamContext(stream grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	if s, ok := stream.(*serverStream); ok {
+		return &serverStream{ServerStream: s.ServerStream, ctx: ctx}
+	}
+	return &serverStream{ServerStream: stream, ctx: ctx}
+}
+
+// WithInterceptors adds a unary and a stream interceptor to the gRPC server options.
+// Either may be nil if the middleware only applies to one kind of call.
+func WithInterceptors(unary grpc.UnaryServerInterceptor, stream grpc.StreamServerInterceptor) OptionFn {
+	return func(opt *GrpcOption) {
+		if unary != nil {
+			opt.UnaryInterceptors = append(opt.UnaryInterceptors, unary)
+		}
+		if stream != nil {
+			opt.StreamInterceptors = append(opt.StreamInterceptors, stream)
+		}
+	}
+}
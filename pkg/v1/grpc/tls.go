@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Client certificate verification modes
+const (
+	ClientAuthRequire  = "require"  // handshake fails without a valid client certificate
+	ClientAuthOptional = "optional" // client certificate is verified only if presented
+)
+
+// NewTLSConfig builds the server TLS configuration. When a client CA is configured
+// client certificates are verified against it (mTLS).
+func NewTLSConfig(conf TLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if conf.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	caPEM, err := os.ReadFile(conf.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", conf.ClientCAFile)
+	}
+	tlsConfig.ClientCAs = clientCAs
+
+	switch strings.ToLower(conf.ClientAuth) {
+	case "", ClientAuthRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	case ClientAuthOptional:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("unsupported client auth mode %q", conf.ClientAuth)
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,31 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// Fake records the messages instead of sending them, for tests.
+// Err is returned by Send if set, nothing is recorded then.
+type Fake struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error
+}
+
+func (f *Fake) Send(ctx context.Context, message Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.messages = append(f.messages, message)
+	return nil
+}
+
+// Messages returns the recorded messages in order
+func (f *Fake) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.messages...)
+}
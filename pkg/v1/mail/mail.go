@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Supported mail drivers
+const (
+	DriverNone = "none" // no mail provider is configured
+	DriverLog  = "log"  // messages are written to the log instead of being sent, for development only
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender sends emails. A nil Sender means no provider is configured.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewFromEnv creates the sender selected with MAIL_DRIVER, nil if no provider is configured
+func NewFromEnv() Sender {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault("MAIL_DRIVER", DriverNone)
+
+	switch driver := strings.ToLower(vi.GetString("MAIL_DRIVER")); driver {
+	case DriverLog:
+		slog.Warn("emails are written to the log, do not use the log driver in production")
+		return LogSender{}
+	case DriverNone, "":
+		return nil
+	default:
+		slog.Warn("unsupported mail driver, emails will not be sent", "driver", driver)
+		return nil
+	}
+}
+
+// LogSender writes the messages to the log
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, message Message) error {
+	slog.InfoContext(ctx, "email message",
+		slog.String("to", message.To), slog.String("subject", message.Subject), slog.String("body", message.Body))
+	return nil
+}
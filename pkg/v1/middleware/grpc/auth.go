@@ -2,6 +2,8 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"strings"
 
 	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
@@ -22,35 +24,84 @@ const (
 	DeviceIDKey contextKey = "device_id"
 	// TokenFamilyKey is the key used to store the token family ID in the context
 	TokenFamilyKey contextKey = "token_family"
+	// RolesKey is the key used to store the roles of the access token in the context
+	RolesKey contextKey = "roles"
+	// ServicePrincipalKey is the key used to store the mTLS authenticated service principal in the context
+	ServicePrincipalKey contextKey = "service_principal"
 )
 
-func AuthInterceptor(jwtManager *auth.JWTManager) grpc.UnaryServerInterceptor {
+// AuthInterceptor authenticates callers by bearer token. Internal callers without a bearer token
+// are authenticated by their verified client certificate when it maps to a service principal,
+// which is authorized by the roles configured for it.
+func AuthInterceptor(jwtManager *auth.JWTManager, principals *auth.PrincipalMapper) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		// Extract device information from metadata
-		deviceID := extractDeviceID(ctx)
-
-		// Add device ID to context for downstream use
-		ctx = context.WithValue(ctx, DeviceIDKey, deviceID)
+		ctx, err := authenticate(ctx, info.FullMethod, jwtManager, principals)
+		if err != nil {
+			return nil, err
+		}
 
-		claims, err := jwtManager.Authorize(ctx, info.FullMethod, tokenParser)
+		return handler(ctx, req)
+	}
+}
 
+// StreamAuthInterceptor authenticates streaming calls like AuthInterceptor
+func StreamAuthInterceptor(jwtManager *auth.JWTManager, principals *auth.PrincipalMapper) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), info.FullMethod, jwtManager, principals)
 		if err != nil {
-			return nil, errwrap.ErrUnauthenticated.SetOriginError(err).SetMessage(err.Error())
+			return err
 		}
 
-		if claims != nil {
-			// Add claims information to context
-			ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+		return handler(srv, grpcserver.WithStreamContext(ss, ctx))
+	}
+}
+
+func WithAuthInterceptor(jwtManager *auth.JWTManager, principals *auth.PrincipalMapper) grpcserver.OptionFn {
+	return grpcserver.WithInterceptors(AuthInterceptor(jwtManager, principals), StreamAuthInterceptor(jwtManager, principals))
+}
+
+// authenticate returns the context with the caller identity of the request
+func authenticate(ctx context.Context, method string, jwtManager *auth.JWTManager, principals *auth.PrincipalMapper) (context.Context, error) {
+	// Extract device information from metadata
+	deviceID := extractDeviceID(ctx)
+
+	// Add device ID to context for downstream use
+	ctx = context.WithValue(ctx, DeviceIDKey, deviceID)
+
+	// Client certificate authentication for service to service calls
+	if !hasAuthorizationHeader(ctx) {
+		if principal, ok := principals.Resolve(auth.PeerCertificate(ctx)); ok {
+			roles := principals.Roles(principal)
+			if err := jwtManager.AuthorizeRoles(method, roles); err != nil {
+				return nil, errwrap.ErrPermissionDenied.SetOriginError(err).SetMessage(err.Error())
+			}
+			ctx = context.WithValue(ctx, ServicePrincipalKey, principal)
+			return context.WithValue(ctx, RolesKey, roles), nil
 		}
+	}
 
-		return handler(ctx, req)
+	claims, err := jwtManager.Authorize(ctx, method, tokenParser)
+
+	if errors.Is(err, auth.ErrPermissionDenied) {
+		return nil, errwrap.ErrPermissionDenied.SetOriginError(err).SetMessage(err.Error())
+	}
+	if err != nil {
+		return nil, errwrap.ErrUnauthenticated.SetOriginError(err).SetMessage(err.Error())
 	}
-}
 
-func WithAuthInterceptor(jwtManager *auth.JWTManager) grpcserver.OptionFn {
-	return func(opt *grpcserver.GrpcOption) {
-		opt.UnaryInterceptors = append(opt.UnaryInterceptors, AuthInterceptor(jwtManager))
+	if claims != nil {
+		// Add claims information to context
+		ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, RolesKey, claims.Roles)
 	}
+
+	return ctx, nil
+}
+
+// hasAuthorizationHeader reports whether the caller sent an authorization header
+func hasAuthorizationHeader(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	return ok && len(md["authorization"]) > 0
 }
 
 func tokenParser(ctx context.Context) (string, error) {
@@ -104,6 +155,18 @@ func GetDeviceID(ctx context.Context) (string, bool) {
 	return deviceID, ok && deviceID != ""
 }
 
+// HasRole reports whether the access token of the caller grants the role
+func HasRole(ctx context.Context, role string) bool {
+	roles, _ := ctx.Value(RolesKey).([]string)
+	return slices.Contains(roles, role)
+}
+
+// GetServicePrincipal retrieves the mTLS authenticated service principal from the context
+func GetServicePrincipal(ctx context.Context) (string, bool) {
+	principal, ok := ctx.Value(ServicePrincipalKey).(string)
+	return principal, ok && principal != ""
+}
+
 // GetTokenFamily retrieves the token family ID from the context
 func GetTokenFamily(ctx context.Context) (string, bool) {
 	familyID, ok := ctx.Value(TokenFamilyKey).(string)
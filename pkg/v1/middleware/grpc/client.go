@@ -0,0 +1,138 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+
+	grpcserver "github.com/nsaltun/user-service-grpc/pkg/v1/grpc"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const configKeyTrustedProxies = "TRUSTED_PROXY_CIDRS"
+
+// ClientIPKey is the key used to store the resolved client address in the context
+const ClientIPKey contextKey = "client_ip"
+
+// ClientIPResolver determines the address of the client of a request. The x-forwarded-for header
+// is only followed while the addresses it passes through are trusted proxies, so clients cannot
+// choose the address they are known by.
+type ClientIPResolver struct {
+	trustedProxies []netip.Prefix
+}
+
+// NewClientIPResolver creates a resolver trusting x-forwarded-for from the given proxy networks
+func NewClientIPResolver(trustedProxies []netip.Prefix) *ClientIPResolver {
+	return &ClientIPResolver{trustedProxies: trustedProxies}
+}
+
+// NewClientIPResolverFromEnv reads the trusted proxy networks from TRUSTED_PROXY_CIDRS as a comma
+// separated list, e.g. "10.0.0.0/8,127.0.0.1/32". Without trusted proxies the peer address is used.
+func NewClientIPResolverFromEnv() *ClientIPResolver {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault(configKeyTrustedProxies, "")
+
+	var trustedProxies []netip.Prefix
+	for _, cidr := range strings.Split(vi.GetString(configKeyTrustedProxies), ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			slog.Warn("ignoring invalid trusted proxy network", slog.String("cidr", cidr), slog.Any("error", err))
+			continue
+		}
+		trustedProxies = append(trustedProxies, prefix.Masked())
+	}
+
+	return NewClientIPResolver(trustedProxies)
+}
+
+// Resolve returns the address of the client, empty if it is unknown.
+//
+// Starting at the peer, x-forwarded-for is walked from the closest hop to the farthest for as long
+// as the current address is a trusted proxy. The first untrusted address is the client.
+func (r *ClientIPResolver) Resolve(ctx context.Context) string {
+	addr, ok := peerAddr(ctx)
+	if !ok {
+		return ""
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var hops []string
+	for _, value := range md.Get("x-forwarded-for") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0 && r.isTrusted(addr); i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// A malformed hop cannot be followed, the last proxy is the closest known client
+			break
+		}
+		addr = hop.Unmap()
+	}
+	return addr.String()
+}
+
+func (r *ClientIPResolver) isTrusted(addr netip.Addr) bool {
+	return r != nil && slices.ContainsFunc(r.trustedProxies, func(prefix netip.Prefix) bool {
+		return prefix.Contains(addr)
+	})
+}
+
+// peerAddr returns the IP address of the gRPC peer
+func peerAddr(ctx context.Context) (netip.Addr, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return netip.Addr{}, false
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// ClientIPInterceptor stores the resolved client address in the context
+func ClientIPInterceptor(resolver *ClientIPResolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(context.WithValue(ctx, ClientIPKey, resolver.Resolve(ctx)), req)
+	}
+}
+
+// StreamClientIPInterceptor stores the resolved client address in the context of streaming calls
+func StreamClientIPInterceptor(resolver *ClientIPResolver) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := context.WithValue(ss.Context(), ClientIPKey, resolver.Resolve(ss.Context()))
+		return handler(srv, grpcserver.WithStreamContext(ss, ctx))
+	}
+}
+
+// WithClientIPInterceptor adds the client address interceptors to the gRPC server options
+func WithClientIPInterceptor(resolver *ClientIPResolver) grpcserver.OptionFn {
+	return grpcserver.WithInterceptors(ClientIPInterceptor(resolver), StreamClientIPInterceptor(resolver))
+}
+
+// GetClientIP returns the address of the client resolved by the client address interceptor.
+// Without the interceptor the peer address is returned, empty if it is unknown.
+func GetClientIP(ctx context.Context) string {
+	if ip, ok := ctx.Value(ClientIPKey).(string); ok {
+		return ip
+	}
+	if addr, ok := peerAddr(ctx); ok {
+		return addr.String()
+	}
+	return ""
+}
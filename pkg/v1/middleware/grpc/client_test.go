@@ -0,0 +1,61 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	resolver := NewClientIPResolver([]netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.1/32"),
+	})
+
+	tests := []struct {
+		name string
+		peer string
+		xff  []string
+		want string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"forwarded header from untrusted peer is ignored", "203.0.113.7:5000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:5000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed hop before the trusted proxy", "10.1.2.3:5000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "10.1.2.3:5000", []string{"198.51.100.1, 192.168.1.1", "10.9.9.9"}, "198.51.100.1"},
+		{"malformed hop", "10.1.2.3:5000", []string{"not-an-ip"}, "10.1.2.3"},
+		{"ipv4 mapped peer", "[::ffff:10.1.2.3]:5000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"ipv6 client", "[2001:db8::1]:5000", nil, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := net.ResolveTCPAddr("tcp", tt.peer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+			if tt.xff != nil {
+				md := metadata.MD{}
+				md.Append("x-forwarded-for", tt.xff...)
+				ctx = metadata.NewIncomingContext(ctx, md)
+			}
+
+			assert.Equal(t, tt.want, resolver.Resolve(ctx))
+		})
+	}
+
+	assert.Empty(t, resolver.Resolve(context.Background()), "no peer")
+}
+
+func TestGetClientIP(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 5000}
+	ctx := metadata.NewIncomingContext(peer.NewContext(context.Background(), &peer.Peer{Addr: addr}), metadata.Pairs("x-forwarded-for", "1.2.3.4"))
+
+	assert.Equal(t, "203.0.113.7", GetClientIP(ctx), "without the interceptor the peer address is used")
+	assert.Equal(t, "198.51.100.1", GetClientIP(context.WithValue(ctx, ClientIPKey, "198.51.100.1")))
+}
@@ -18,20 +18,48 @@ func ErrorInterceptor() grpc.UnaryServerInterceptor {
 			return resp, nil
 		}
 
-		// Check if the error implements IError interface
-		if ierr, ok := err.(errwrap.IError); ok {
-			return resp, status.Error(ierr.GrpcCode(), ierr.Message())
-		}
-		ierr := errwrap.ErrInternal.SetOriginError(err)
+		return resp, toStatusError(err)
+	}
+}
 
-		// If error doesn't implement IError, return internal server error
-		return resp, status.Error(ierr.GrpcCode(), ierr.ErrorResp().Message)
+// StreamErrorInterceptor handles error mapping from application errors to gRPC errors for streaming calls
+func StreamErrorInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return toStatusError(err)
+		}
+		return nil
 	}
 }
 
-// WithErrorInterceptor adds the error interceptor to the gRPC server options
+// WithErrorInterceptor adds the error interceptors to the gRPC server options
 func WithErrorInterceptor() grpcserver.OptionFn {
-	return func(opt *grpcserver.GrpcOption) {
-		opt.UnaryInterceptors = append(opt.UnaryInterceptors, ErrorInterceptor())
+	return grpcserver.WithInterceptors(ErrorInterceptor(), StreamErrorInterceptor())
+}
+
+func toStatusError(err error) error {
+	// Errors that already carry a status (e.g. context cancellation of a stream) are kept
+	if _, ok := err.(errwrap.IError); !ok {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
 	}
+	return ToStatus(err).Err()
+}
+
+// ToStatus maps an application error to a gRPC status, like the error interceptors do for the error of a call.
+// Batch calls use it for the errors of single items.
+func ToStatus(err error) *status.Status {
+	// Check if the error implements IError interface
+	if ierr, ok := err.(errwrap.IError); ok {
+		return status.New(ierr.GrpcCode(), ierr.Message())
+	}
+
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+	ierr := errwrap.ErrInternal.SetOriginError(err)
+
+	// If error doesn't implement IError, return internal server error
+	return status.New(ierr.GrpcCode(), ierr.ErrorResp().Message)
 }
@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// ifMatchKeys are the metadata keys carrying an If-Match precondition.
+// A gateway forwards the If-Match header as "grpcgateway-if-match".
+var ifMatchKeys = []string{"if-match", "grpcgateway-if-match"}
+
+// IfMatchVersion returns the resource version requested by an If-Match etag in the request metadata.
+// It returns nil when no etag or the wildcard "*" is given.
+func IfMatchVersion(ctx context.Context) (*int32, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	for _, key := range ifMatchKeys {
+		values := md.Get(key)
+		if len(values) == 0 || values[0] == "" {
+			continue
+		}
+
+		etag := strings.TrimSpace(values[0])
+		if etag == "*" {
+			return nil, nil
+		}
+		etag = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+
+		version, err := strconv.ParseInt(etag, 10, 32)
+		if err != nil {
+			return nil, errwrap.NewError("invalid if-match etag", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+		}
+		v := int32(version)
+		return &v, nil
+	}
+
+	return nil, nil
+}
+
+// SetETag sends the resource version to the client as etag response header
+func SetETag(ctx context.Context, version int32) error {
+	return grpc.SetHeader(ctx, metadata.Pairs("etag", strconv.Quote(strconv.FormatInt(int64(version), 10))))
+}
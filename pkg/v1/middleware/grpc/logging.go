@@ -27,8 +27,21 @@ func LoggingInterceptor() grpc.UnaryServerInterceptor {
 	}
 }
 
-func WithLoggingInterceptor() grpcserver.OptionFn {
-	return func(opt *grpcserver.GrpcOption) {
-		opt.UnaryInterceptors = append(opt.UnaryInterceptors, LoggingInterceptor())
+// StreamLoggingInterceptor logs the start and end of streaming calls, messages are not logged
+func StreamLoggingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		slog.InfoContext(ctx, fmt.Sprintf("Handling stream %s", info.FullMethod))
+		err := handler(srv, ss)
+		if err != nil {
+			slog.InfoContext(ctx, fmt.Sprintf("Finished stream %s with error", info.FullMethod), "err", err)
+		} else {
+			slog.InfoContext(ctx, fmt.Sprintf("Finished stream %s successfully", info.FullMethod))
+		}
+		return err
 	}
 }
+
+func WithLoggingInterceptor() grpcserver.OptionFn {
+	return grpcserver.WithInterceptors(LoggingInterceptor(), StreamLoggingInterceptor())
+}
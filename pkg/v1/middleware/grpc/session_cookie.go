@@ -0,0 +1,257 @@
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	grpcserver "github.com/nsaltun/user-service-grpc/pkg/v1/grpc"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// csrfTokenBytes is the amount of random bytes used for a CSRF token
+const csrfTokenBytes = 32
+
+// httpTransportKeys are metadata keys that are only set when a request was proxied over HTTP
+// by a gateway (grpc-gateway) or a Connect handler. Generic proxy headers like x-forwarded-for
+// are left out, plain gRPC clients can send them too.
+var httpTransportKeys = []string{
+	"grpcgateway-user-agent",
+	"grpcgateway-cookie",
+	"connect-protocol-version",
+}
+
+// SessionCookieConfig configures the browser cookie based session mode
+type SessionCookieConfig struct {
+	Enabled          bool
+	RefreshCookie    string
+	CSRFCookie       string
+	CSRFHeader       string
+	Domain           string
+	Path             string
+	SameSite         http.SameSite
+	Secure           bool
+	CSRFExemptMethod []string
+}
+
+// NewSessionCookieConfigFromEnv reads the session cookie configuration from environment
+func NewSessionCookieConfigFromEnv() SessionCookieConfig {
+	vi := viper.New()
+	vi.AutomaticEnv()
+
+	vi.SetDefault("SESSION_COOKIE_ENABLED", false)
+	vi.SetDefault("SESSION_COOKIE_NAME", "refresh_token")
+	vi.SetDefault("SESSION_COOKIE_DOMAIN", "")
+	vi.SetDefault("SESSION_COOKIE_PATH", "/v1/auth")
+	vi.SetDefault("SESSION_COOKIE_SAMESITE", "strict")
+	vi.SetDefault("SESSION_COOKIE_SECURE", true)
+	vi.SetDefault("CSRF_COOKIE_NAME", "csrf_token")
+	vi.SetDefault("CSRF_HEADER_NAME", "x-csrf-token")
+
+	sameSite := http.SameSiteStrictMode
+	switch strings.ToLower(vi.GetString("SESSION_COOKIE_SAMESITE")) {
+	case "lax":
+		sameSite = http.SameSiteLaxMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+
+	return SessionCookieConfig{
+		Enabled:       vi.GetBool("SESSION_COOKIE_ENABLED"),
+		RefreshCookie: vi.GetString("SESSION_COOKIE_NAME"),
+		CSRFCookie:    vi.GetString("CSRF_COOKIE_NAME"),
+		CSRFHeader:    strings.ToLower(vi.GetString("CSRF_HEADER_NAME")),
+		Domain:        vi.GetString("SESSION_COOKIE_DOMAIN"),
+		Path:          vi.GetString("SESSION_COOKIE_PATH"),
+		SameSite:      sameSite,
+		Secure:        vi.GetBool("SESSION_COOKIE_SECURE"),
+		// Login starts a session, there is no cookie to protect yet
+		CSRFExemptMethod: []string{"/core.user.v1.AuthAPI/Login"},
+	}
+}
+
+// SessionCookies keeps the refresh token of browser clients in an HttpOnly cookie
+// and protects cookie authenticated calls with double-submit CSRF tokens.
+type SessionCookies struct {
+	config SessionCookieConfig
+	maxAge time.Duration
+}
+
+// NewSessionCookies creates session cookie handling, maxAge should match the refresh token lifetime
+func NewSessionCookies(config SessionCookieConfig, maxAge time.Duration) *SessionCookies {
+	return &SessionCookies{config: config, maxAge: maxAge}
+}
+
+// Enabled reports whether the refresh token of the current request should travel in a cookie.
+// Only requests coming over HTTP use cookies, plain gRPC clients keep getting tokens in the body.
+func (s *SessionCookies) Enabled(ctx context.Context) bool {
+	if s == nil || !s.config.Enabled {
+		return false
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+
+	for _, key := range httpTransportKeys {
+		if len(md.Get(key)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSession sends the refresh token and a fresh CSRF token to the client as cookies
+func (s *SessionCookies) SetSession(ctx context.Context, refreshToken string) error {
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+
+	refresh := s.cookie(s.config.RefreshCookie, refreshToken, s.maxAge)
+	refresh.HttpOnly = true
+
+	// CSRF cookie must be readable by the browser to be echoed in the header
+	csrf := s.cookie(s.config.CSRFCookie, csrfToken, s.maxAge)
+	csrf.Path = "/"
+
+	return grpc.SetHeader(ctx, metadata.Pairs("set-cookie", refresh.String(), "set-cookie", csrf.String()))
+}
+
+// ClearSession expires the session cookies on the client
+func (s *SessionCookies) ClearSession(ctx context.Context) error {
+	refresh := s.cookie(s.config.RefreshCookie, "", -1)
+	refresh.HttpOnly = true
+
+	csrf := s.cookie(s.config.CSRFCookie, "", -1)
+	csrf.Path = "/"
+
+	return grpc.SetHeader(ctx, metadata.Pairs("set-cookie", refresh.String(), "set-cookie", csrf.String()))
+}
+
+// RefreshToken returns the refresh token sent by the client in the session cookie
+func (s *SessionCookies) RefreshToken(ctx context.Context) string {
+	return cookieValue(ctx, s.config.RefreshCookie)
+}
+
+func (s *SessionCookies) cookie(name, value string, maxAge time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   s.config.Domain,
+		Path:     s.config.Path,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   s.config.Secure,
+		SameSite: s.config.SameSite,
+	}
+}
+
+// CSRFInterceptor rejects cookie authenticated requests whose CSRF header does not match the CSRF cookie
+func CSRFInterceptor(sessionCookies *SessionCookies) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := sessionCookies.checkCSRF(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamCSRFInterceptor applies the CSRF check of CSRFInterceptor to streaming calls
+func StreamCSRFInterceptor(sessionCookies *SessionCookies) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := sessionCookies.checkCSRF(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// WithCSRFInterceptor adds the CSRF interceptors to the gRPC server options
+func WithCSRFInterceptor(sessionCookies *SessionCookies) grpcserver.OptionFn {
+	return grpcserver.WithInterceptors(CSRFInterceptor(sessionCookies), StreamCSRFInterceptor(sessionCookies))
+}
+
+// checkCSRF verifies the CSRF token of cookie authenticated requests
+func (s *SessionCookies) checkCSRF(ctx context.Context, method string) error {
+	if !s.Enabled(ctx) || s.isCSRFExempt(method) {
+		return nil
+	}
+
+	// Requests without the session cookie are not cookie authenticated
+	if s.RefreshToken(ctx) == "" {
+		return nil
+	}
+
+	return s.verifyCSRF(ctx)
+}
+
+// verifyCSRF checks the double-submitted CSRF token
+func (s *SessionCookies) verifyCSRF(ctx context.Context) error {
+	cookieToken := cookieValue(ctx, s.config.CSRFCookie)
+	if cookieToken == "" {
+		return errwrap.ErrPermissionDenied.SetMessage("csrf cookie is not provided")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	headerTokens := md.Get(s.config.CSRFHeader)
+	if len(headerTokens) == 0 || headerTokens[0] == "" {
+		return errwrap.ErrPermissionDenied.SetMessage("csrf token is not provided")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerTokens[0])) != 1 {
+		return errwrap.ErrPermissionDenied.SetMessage("csrf token mismatch")
+	}
+
+	return nil
+}
+
+func (s *SessionCookies) isCSRFExempt(method string) bool {
+	for _, m := range s.config.CSRFExemptMethod {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// cookieValue returns the value of a cookie from the request metadata.
+// A gateway forwards the Cookie header as "grpcgateway-cookie", Connect and proxies keep "cookie".
+func cookieValue(ctx context.Context, name string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	lines := append(md.Get("cookie"), md.Get("grpcgateway-cookie")...)
+	for _, line := range lines {
+		cookies, err := http.ParseCookie(line)
+		if err != nil {
+			continue
+		}
+		for _, c := range cookies {
+			if c.Name == name {
+				return c.Value
+			}
+		}
+	}
+	return ""
+}
+
+func newCSRFToken() (string, error) {
+	raw := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate csrf token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	testLoginMethod   = "/core.user.v1.AuthAPI/Login"
+	testRefreshMethod = "/core.user.v1.AuthAPI/Refresh"
+)
+
+func newTestSessionCookies(enabled bool) *SessionCookies {
+	return NewSessionCookies(SessionCookieConfig{
+		Enabled:          enabled,
+		RefreshCookie:    "refresh_token",
+		CSRFCookie:       "csrf_token",
+		CSRFHeader:       "x-csrf-token",
+		Path:             "/v1/auth",
+		Secure:           true,
+		CSRFExemptMethod: []string{testLoginMethod},
+	}, time.Hour)
+}
+
+func incomingContext(kv ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+}
+
+func TestSessionCookiesEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		ctx     context.Context
+		want    bool
+	}{
+		{"grpc-gateway", true, incomingContext("grpcgateway-user-agent", "Mozilla/5.0"), true},
+		{"grpc-gateway cookie", true, incomingContext("grpcgateway-cookie", "refresh_token=abc"), true},
+		{"connect", true, incomingContext("connect-protocol-version", "1"), true},
+		{"plain grpc", true, incomingContext("user-agent", "grpc-go/1.70"), false},
+		{"plain grpc with forwarded headers", true, incomingContext("x-forwarded-for", "1.2.3.4", "x-forwarded-host", "example.com"), false},
+		{"no metadata", true, context.Background(), false},
+		{"disabled", false, incomingContext("grpcgateway-user-agent", "Mozilla/5.0"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newTestSessionCookies(tt.enabled).Enabled(tt.ctx))
+		})
+	}
+
+	var unset *SessionCookies
+	assert.False(t, unset.Enabled(incomingContext("grpcgateway-user-agent", "Mozilla/5.0")))
+}
+
+func TestCheckCSRF(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		ctx    context.Context
+		code   codes.Code
+	}{
+		{"matching token", testRefreshMethod, incomingContext("grpcgateway-cookie", "refresh_token=abc; csrf_token=xyz", "x-csrf-token", "xyz"), codes.OK},
+		{"cookie header", testRefreshMethod, incomingContext("connect-protocol-version", "1", "cookie", "refresh_token=abc; csrf_token=xyz", "x-csrf-token", "xyz"), codes.OK},
+		{"missing header", testRefreshMethod, incomingContext("grpcgateway-cookie", "refresh_token=abc; csrf_token=xyz"), codes.PermissionDenied},
+		{"empty header", testRefreshMethod, incomingContext("grpcgateway-cookie", "refresh_token=abc; csrf_token=xyz", "x-csrf-token", ""), codes.PermissionDenied},
+		{"mismatching header", testRefreshMethod, incomingContext("grpcgateway-cookie", "refresh_token=abc; csrf_token=xyz", "x-csrf-token", "other"), codes.PermissionDenied},
+		{"missing csrf cookie", testRefreshMethod, incomingContext("grpcgateway-cookie", "refresh_token=abc", "x-csrf-token", "xyz"), codes.PermissionDenied},
+		{"exempt method", testLoginMethod, incomingContext("grpcgateway-cookie", "refresh_token=abc; csrf_token=xyz"), codes.OK},
+		{"no session cookie", testRefreshMethod, incomingContext("grpcgateway-user-agent", "Mozilla/5.0"), codes.OK},
+		{"plain grpc", testRefreshMethod, incomingContext("cookie", "refresh_token=abc; csrf_token=xyz"), codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTestSessionCookies(true).checkCSRF(tt.ctx, tt.method)
+			if tt.code == codes.OK {
+				assert.NoError(t, err)
+				return
+			}
+			var wrapped errwrap.IError
+			require.ErrorAs(t, err, &wrapped)
+			assert.Equal(t, tt.code, wrapped.GrpcCode())
+		})
+	}
+}
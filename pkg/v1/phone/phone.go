@@ -0,0 +1,39 @@
+// Package phone validates phone numbers and normalizes them to E.164, e.g. +905321234567.
+package phone
+
+import (
+	"strings"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nyaruka/phonenumbers"
+	"google.golang.org/grpc/codes"
+)
+
+// unknownRegion makes the parser accept international numbers only
+const unknownRegion = "ZZ"
+
+// Normalize validates a phone number and returns it in E.164 format.
+// Numbers without a leading + are read as national numbers of country, an ISO 3166-1 alpha-2 code.
+// Errors are returned as InvalidArgument.
+func Normalize(number, country string) (string, error) {
+	region := strings.ToUpper(strings.TrimSpace(country))
+	if len(region) != 2 {
+		region = unknownRegion
+	}
+
+	parsed, err := phonenumbers.Parse(number, region)
+	if err != nil {
+		if region == unknownRegion {
+			return "", invalidNumber("phone must be in international format, starting with + and the country code")
+		}
+		return "", invalidNumber("invalid phone number")
+	}
+	if !phonenumbers.IsValidNumber(parsed) {
+		return "", invalidNumber("invalid phone number")
+	}
+	return phonenumbers.Format(parsed, phonenumbers.E164), nil
+}
+
+func invalidNumber(message string) error {
+	return errwrap.NewError(message, codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+}
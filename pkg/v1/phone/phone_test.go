@@ -0,0 +1,50 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		number   string
+		country  string
+		expected string
+	}{
+		{"international", "+90 532 123 45 67", "", "+905321234567"},
+		{"international ignores country", "+49 30 901820", "TR", "+4930901820"},
+		{"national with trunk prefix", "0532 123 45 67", "TR", "+905321234567"},
+		{"national lower case country", "(201) 555-0123", "us", "+12015550123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := Normalize(tt.number, tt.country)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, normalized)
+		})
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		number  string
+		country string
+	}{
+		{"national without country", "0532 123 45 67", ""},
+		{"national with unknown country", "0532 123 45 67", "Turkey"},
+		{"too short", "+90 532", ""},
+		{"not a number", "call me", "TR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Normalize(tt.number, tt.country)
+			assert.Error(t, err)
+		})
+	}
+}
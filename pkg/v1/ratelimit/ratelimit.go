@@ -0,0 +1,45 @@
+// Package ratelimit limits the calls per key, e.g. per client address.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows up to a number of calls per key in fixed time windows.
+// All counts are dropped when a window ends, so keys seen once do not pile up.
+type Limiter struct {
+	mu          sync.Mutex
+	limit       int
+	window      time.Duration
+	windowStart time.Time
+	counts      map[string]int
+	now         func() time.Time
+}
+
+// New creates a limiter of limit calls per key and window
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:  limit,
+		window: window,
+		counts: map[string]int{},
+		now:    time.Now,
+	}
+}
+
+// Allow counts a call of the key and reports whether it is within the limit
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.windowStart) >= l.window {
+		l.windowStart = now
+		clear(l.counts)
+	}
+	if l.counts[key] >= l.limit {
+		return false
+	}
+	l.counts[key]++
+	return true
+}
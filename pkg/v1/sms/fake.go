@@ -0,0 +1,37 @@
+package sms
+
+import (
+	"context"
+	"sync"
+)
+
+// Message is a text message recorded by Fake
+type Message struct {
+	To   string
+	Text string
+}
+
+// Fake records the messages instead of sending them, for tests.
+// Err is returned by Send if set, nothing is recorded then.
+type Fake struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error
+}
+
+func (f *Fake) Send(ctx context.Context, to, message string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.messages = append(f.messages, Message{To: to, Text: message})
+	return nil
+}
+
+// Messages returns the recorded messages in order
+func (f *Fake) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.messages...)
+}
@@ -0,0 +1,46 @@
+package sms
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Supported SMS drivers
+const (
+	DriverNone = "none" // no SMS provider is configured
+	DriverLog  = "log"  // messages are written to the log instead of being sent, for development only
+)
+
+// Sender sends text messages to phone numbers in E.164 format. A nil Sender means no provider is configured.
+type Sender interface {
+	Send(ctx context.Context, to, message string) error
+}
+
+// NewFromEnv creates the sender selected with SMS_DRIVER, nil if no provider is configured
+func NewFromEnv() Sender {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault("SMS_DRIVER", DriverNone)
+
+	switch driver := strings.ToLower(vi.GetString("SMS_DRIVER")); driver {
+	case DriverLog:
+		slog.Warn("SMS messages are written to the log, do not use the log driver in production")
+		return LogSender{}
+	case DriverNone, "":
+		return nil
+	default:
+		slog.Warn("unsupported SMS driver, messages will not be sent", "driver", driver)
+		return nil
+	}
+}
+
+// LogSender writes the messages to the log
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, to, message string) error {
+	slog.InfoContext(ctx, "SMS message", slog.String("to", to), slog.String("message", message))
+	return nil
+}
@@ -0,0 +1,73 @@
+// Package textnorm folds text for case, accent and diacritic insensitive matching.
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// specialFolds maps letters that do not decompose into a base letter and a combining mark
+var specialFolds = strings.NewReplacer(
+	"ı", "i", // Turkish dotless i
+	"ß", "ss",
+	"æ", "ae",
+	"ø", "o",
+	"đ", "d",
+	"ł", "l",
+	"œ", "oe",
+)
+
+// Normalize lowercases s and removes diacritics, e.g. "Ahmet Yılmaz Şahin" becomes "ahmet yilmaz sahin"
+func Normalize(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, strings.ToLower(s))
+	if err != nil {
+		folded = strings.ToLower(s)
+	}
+	return specialFolds.Replace(folded)
+}
+
+// Key folds s for case insensitive identity comparisons, e.g. of emails and nicknames.
+// Compatibility characters such as full-width letters are unified (NFKC) and the case is folded.
+// Unlike Normalize it keeps diacritics, "şahin" and "sahin" are different keys.
+func Key(s string) string {
+	return norm.NFKC.String(cases.Fold().String(norm.NFKC.String(strings.TrimSpace(s))))
+}
+
+// Words splits s into normalized words of letters and digits
+func Words(s string) []string {
+	return strings.FieldsFunc(Normalize(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// EdgeGrams returns the distinct prefixes of the words that are at least minLength runes long,
+// e.g. "yilmaz" with minLength 2 gives "yi", "yil", "yilm", "yilma" and "yilmaz".
+// Words shorter than minLength are kept as they are.
+func EdgeGrams(words []string, minLength int) []string {
+	seen := map[string]bool{}
+	var grams []string
+	add := func(gram string) {
+		if !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+
+	for _, word := range words {
+		r := []rune(word)
+		if len(r) < minLength {
+			add(word)
+			continue
+		}
+		for i := minLength; i <= len(r); i++ {
+			add(string(r[:i]))
+		}
+	}
+	return grams
+}
@@ -30,6 +30,8 @@ func NewMeta() Meta {
 	}
 }
 
+// Update refreshes the update time and increments the version for optimistic concurrency control
 func (m *Meta) Update() {
 	m.UpdatedAt = time.Now().UTC()
+	m.Version++
 }
@@ -0,0 +1,149 @@
+package types
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
+	"github.com/nsaltun/user-service-grpc/pkg/v1/stack"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	configKeyPageTokenSecret = "PAGE_TOKEN_SECRET"
+	configKeyPageTokenTTL    = "PAGE_TOKEN_TTL"
+
+	// pageTokenSecretBytes is the size of the generated secret when none is configured
+	pageTokenSecretBytes = 32
+)
+
+var (
+	errInvalidPageToken = errwrap.NewError("invalid page token", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	errPageTokenQuery   = errwrap.NewError("page token does not match the query", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	errPageTokenExpired = errwrap.NewError("page token has expired", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+)
+
+// pageCursor is the payload of a page token
+type pageCursor struct {
+	// Key holds the sort key values of the last item of the previous page
+	Key []bson.RawValue `bson:"k"`
+
+	// Query is the fingerprint of the filter and order the token was issued for
+	Query string `bson:"q"`
+
+	// Expires is the unix time after which the token is rejected, zero if it does not expire
+	Expires int64 `bson:"e,omitempty"`
+}
+
+// PageTokenCodec issues and verifies opaque, HMAC signed page tokens for keyset pagination
+type PageTokenCodec struct {
+	stack.AbstractProvider
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+
+	// ttlConfig is the configured lifetime, parsed on Init
+	ttlConfig string
+}
+
+// NewPageTokenCodec creates a page token codec signing with the given secret.
+// Tokens expire after ttl, a zero ttl issues tokens that do not expire.
+func NewPageTokenCodec(secret []byte, ttl time.Duration) *PageTokenCodec {
+	return &PageTokenCodec{secret: secret, ttl: ttl, now: time.Now}
+}
+
+// NewPageTokenCodecFromEnv creates a codec reading the signing secret from PAGE_TOKEN_SECRET and the token
+// lifetime from PAGE_TOKEN_TTL on Init. Without a secret a random one is generated, then tokens are only
+// valid on this instance until restart.
+func NewPageTokenCodecFromEnv() *PageTokenCodec {
+	vi := viper.New()
+	vi.AutomaticEnv()
+	vi.SetDefault(configKeyPageTokenSecret, "")
+	vi.SetDefault(configKeyPageTokenTTL, "24h")
+
+	c := NewPageTokenCodec([]byte(vi.GetString(configKeyPageTokenSecret)), 0)
+	c.ttlConfig = vi.GetString(configKeyPageTokenTTL)
+	return c
+}
+
+// Init validates the configured lifetime and generates a secret if none is configured
+func (c *PageTokenCodec) Init() error {
+	if c.ttlConfig != "" {
+		ttl, err := time.ParseDuration(c.ttlConfig)
+		if err != nil || ttl < 0 {
+			return fmt.Errorf("invalid %s %q, it must be a non-negative duration", configKeyPageTokenTTL, c.ttlConfig)
+		}
+		c.ttl = ttl
+	}
+
+	if len(c.secret) == 0 {
+		slog.Warn("PAGE_TOKEN_SECRET is not set, page tokens are not valid across instances and restarts")
+		secret := make([]byte, pageTokenSecretBytes)
+		if _, err := rand.Read(secret); err != nil {
+			return fmt.Errorf("failed to generate page token secret: %w", err)
+		}
+		c.secret = secret
+	}
+	return nil
+}
+
+// Encode issues a page token for the sort key of the last returned item
+func (c *PageTokenCodec) Encode(key []bson.RawValue, query string) (string, error) {
+	cursor := pageCursor{Key: key, Query: query}
+	if c.ttl > 0 {
+		cursor.Expires = c.now().Add(c.ttl).Unix()
+	}
+	payload, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(encoded)), nil
+}
+
+// Decode verifies a page token issued for the same query and returns its sort key
+func (c *PageTokenCodec) Decode(token string, query string) ([]bson.RawValue, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, errInvalidPageToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || subtle.ConstantTimeCompare(sig, c.sign(encoded)) != 1 {
+		return nil, errInvalidPageToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errInvalidPageToken
+	}
+
+	var cursor pageCursor
+	if err := bson.Unmarshal(payload, &cursor); err != nil {
+		return nil, errInvalidPageToken
+	}
+	if cursor.Query != query {
+		return nil, errPageTokenQuery
+	}
+	if cursor.Expires != 0 && c.now().Unix() > cursor.Expires {
+		return nil, errPageTokenExpired
+	}
+
+	return cursor.Key, nil
+}
+
+func (c *PageTokenCodec) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
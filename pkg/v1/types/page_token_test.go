@@ -0,0 +1,113 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// testKey is the sort key of a user created at 2024-01-01 with id user-1
+func testKey(t *testing.T) []bson.RawValue {
+	t.Helper()
+	doc, err := bson.Marshal(bson.D{{Key: "createdAt", Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, {Key: "_id", Value: "user-1"}})
+	require.NoError(t, err)
+	key, err := Sort{{Field: "createdAt"}, {Field: "_id"}}.KeyOf(doc)
+	require.NoError(t, err)
+	return key
+}
+
+func TestPageTokenRoundTrip(t *testing.T) {
+	codec := NewPageTokenCodec([]byte("secret"), time.Hour)
+	key := testKey(t)
+
+	token, err := codec.Encode(key, "query")
+	require.NoError(t, err)
+
+	decoded, err := codec.Decode(token, "query")
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+	assert.True(t, key[0].Equal(decoded[0]))
+	assert.Equal(t, "user-1", decoded[1].StringValue())
+
+	_, err = codec.Decode(token, "other query")
+	assert.ErrorIs(t, err, errPageTokenQuery)
+}
+
+func TestPageTokenTampered(t *testing.T) {
+	codec := NewPageTokenCodec([]byte("secret"), time.Hour)
+	token, err := codec.Encode(testKey(t), "query")
+	require.NoError(t, err)
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// A token of the same query signed with another secret, and a token of another query
+	forged, err := NewPageTokenCodec([]byte("other secret"), time.Hour).Encode(testKey(t), "query")
+	require.NoError(t, err)
+	other, err := codec.Encode(testKey(t), "other query")
+	require.NoError(t, err)
+	otherPayload, _, _ := strings.Cut(other, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"without signature", payload},
+		{"other secret", forged},
+		{"payload of another token", otherPayload + "." + signature},
+		{"changed payload", "A" + payload[1:] + "." + signature},
+		{"changed signature", payload + "." + strings.Repeat("A", len(signature))},
+		{"signature not base64", payload + ".!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.token == token {
+				t.Fatal("the tampered token equals the issued one")
+			}
+			_, err := codec.Decode(tt.token, "query")
+			assert.ErrorIs(t, err, errInvalidPageToken)
+		})
+	}
+}
+
+func TestPageTokenExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	codec := NewPageTokenCodec([]byte("secret"), time.Hour)
+	codec.now = func() time.Time { return now }
+
+	token, err := codec.Encode(testKey(t), "query")
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	_, err = codec.Decode(token, "query")
+	assert.NoError(t, err, "a token is valid until its expire time")
+
+	now = now.Add(time.Second)
+	_, err = codec.Decode(token, "query")
+	assert.ErrorIs(t, err, errPageTokenExpired)
+
+	// Without a ttl tokens do not expire
+	codec.ttl = 0
+	token, err = codec.Encode(testKey(t), "query")
+	require.NoError(t, err)
+	now = now.Add(365 * 24 * time.Hour)
+	_, err = codec.Decode(token, "query")
+	assert.NoError(t, err)
+}
+
+func TestPageTokenCodecFromEnv(t *testing.T) {
+	t.Setenv(configKeyPageTokenSecret, "")
+	t.Setenv(configKeyPageTokenTTL, "30m")
+	codec := NewPageTokenCodecFromEnv()
+	require.NoError(t, codec.Init())
+	assert.Equal(t, 30*time.Minute, codec.ttl)
+	assert.Len(t, codec.secret, pageTokenSecretBytes, "a secret is generated")
+
+	for _, ttl := range []string{"soon", "-1h"} {
+		t.Setenv(configKeyPageTokenTTL, ttl)
+		assert.Error(t, NewPageTokenCodecFromEnv().Init(), ttl)
+	}
+}
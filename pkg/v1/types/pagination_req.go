@@ -5,6 +5,7 @@ import (
 
 	"github.com/nsaltun/user-service-grpc/pkg/v1/errwrap"
 	pb "github.com/nsaltun/user-service-grpc/proto/gen/go/shared/types/v1"
+	"go.mongodb.org/mongo-driver/bson"
 	"google.golang.org/grpc/codes"
 )
 
@@ -20,6 +21,15 @@ const (
 type PaginationReq struct {
 	Offset int64 `bson:"offset" json:"offset"`
 	Limit  int64 `bson:"limit" json:"limit"`
+
+	// PageToken continues a keyset paginated list, it cannot be combined with Offset
+	PageToken string `bson:"page_token" json:"page_token"`
+
+	// SkipTotal skips counting the total records
+	SkipTotal bool `bson:"skip_total" json:"skip_total"`
+
+	// After holds the decoded sort key of PageToken
+	After []bson.RawValue `bson:"-" json:"-"`
 }
 
 func NewPaginationReq(offset int64, limit int64) PaginationReq {
@@ -37,11 +47,21 @@ func NewPaginationReq(offset int64, limit int64) PaginationReq {
 
 func (p PaginationReq) FromProto(proto *pb.List) PaginationReq {
 	return PaginationReq{
-		Offset: proto.Offset,
-		Limit:  proto.Limit,
+		Offset:    proto.Offset,
+		Limit:     proto.Limit,
+		PageToken: proto.PageToken,
+		SkipTotal: proto.SkipTotal,
 	}
 }
 
+// ValidatePageToken rejects requests that combine offset and keyset pagination
+func ValidatePageToken(pageToken string, offset int64) error {
+	if pageToken != "" && offset > 0 {
+		return errwrap.NewError("page_token cannot be combined with offset", codes.InvalidArgument.String()).SetGrpcCode(codes.InvalidArgument)
+	}
+	return nil
+}
+
 // validatePaginationParams validates
 func ValidatePaginationParams(limit, offset int64) error {
 	if limit < MinLimit || limit > MaxLimit {
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MaxOrderByFields limits the number of fields a list can be ordered by
+const MaxOrderByFields = 2
+
+// SortField is a single field of a sort order
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Sort is an ordered list of sort fields.
+// For keyset pagination the last field must be unique (usually `_id`) to make the order total.
+type Sort []SortField
+
+// ToBson converts the sort order into a MongoDB sort document
+func (s Sort) ToBson() bson.D {
+	sortDoc := make(bson.D, 0, len(s))
+	for _, f := range s {
+		direction := 1
+		if f.Desc {
+			direction = -1
+		}
+		sortDoc = append(sortDoc, bson.E{Key: f.Field, Value: direction})
+	}
+	return sortDoc
+}
+
+// After returns a MongoDB filter matching the documents that come after the given sort key in this order.
+//
+// For the order (a, b) and key (x, y) it builds `{$or: [{a: {$gt: x}}, {a: x, b: {$gt: y}}]}`,
+// with $lt instead of $gt for descending fields.
+func (s Sort) After(key []bson.RawValue) bson.M {
+	or := make(bson.A, 0, len(s))
+	for i, f := range s {
+		branch := bson.M{}
+		for j := 0; j < i; j++ {
+			branch[s[j].Field] = key[j]
+		}
+		op := "$gt"
+		if f.Desc {
+			op = "$lt"
+		}
+		branch[f.Field] = bson.M{op: key[i]}
+		or = append(or, branch)
+	}
+	return bson.M{"$or": or}
+}
+
+// KeyOf returns the sort key values of a document.
+// It fails if the document lacks a sort field, as the key could not be resumed after.
+func (s Sort) KeyOf(doc bson.Raw) ([]bson.RawValue, error) {
+	key := make([]bson.RawValue, 0, len(s))
+	for _, f := range s {
+		value, err := doc.LookupErr(strings.Split(f.Field, ".")...)
+		if err != nil {
+			return nil, fmt.Errorf("sort field %s: %w", f.Field, err)
+		}
+		key = append(key, value)
+	}
+	return key, nil
+}
+
+// CoveredBy reports whether index can return the documents in this order without an in-memory sort,
+// given the fields the query filters by equality.
+//
+// Leading index keys with an equality filter are skipped, the following keys must start with the sort fields
+// in the same directions or all in the reversed directions. Sort fields with an equality filter are constant and ignored.
+func (s Sort) CoveredBy(index Sort, equality []string) bool {
+	sort := slices.DeleteFunc(slices.Clone(s), func(f SortField) bool {
+		return slices.Contains(equality, f.Field)
+	})
+
+	i := 0
+	for i < len(index) && slices.Contains(equality, index[i].Field) {
+		i++
+	}
+	keys := index[i:]
+	if len(sort) > len(keys) {
+		return false
+	}
+
+	reversed := len(sort) > 0 && sort[0].Desc != keys[0].Desc
+	for j, f := range sort {
+		if f.Field != keys[j].Field || (f.Desc != keys[j].Desc) != reversed {
+			return false
+		}
+	}
+	return true
+}
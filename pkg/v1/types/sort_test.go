@@ -0,0 +1,112 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSortKeyOf(t *testing.T) {
+	doc, err := bson.Marshal(bson.D{{Key: "_id", Value: "user-1"}, {Key: "meta", Value: bson.D{{Key: "version", Value: int32(3)}}}})
+	require.NoError(t, err)
+
+	key, err := Sort{{Field: "meta.version", Desc: true}, {Field: "_id"}}.KeyOf(doc)
+	require.NoError(t, err)
+	require.Len(t, key, 2)
+	assert.Equal(t, int32(3), key[0].Int32())
+	assert.Equal(t, "user-1", key[1].StringValue())
+
+	_, err = Sort{{Field: "last_name"}, {Field: "_id"}}.KeyOf(doc)
+	assert.ErrorContains(t, err, "last_name", "a missing sort field is an error")
+}
+
+// rawValue marshals v as the value of a sort key
+func rawValue(t *testing.T, v any) bson.RawValue {
+	t.Helper()
+	typ, data, err := bson.MarshalValue(v)
+	require.NoError(t, err)
+	return bson.RawValue{Type: typ, Value: data}
+}
+
+func TestSortAfter(t *testing.T) {
+	x, y, z := rawValue(t, "x"), rawValue(t, int32(2)), rawValue(t, "user-1")
+
+	tests := []struct {
+		name string
+		sort Sort
+		key  []bson.RawValue
+		want bson.M
+	}{
+		{
+			"single ascending field",
+			Sort{{Field: "_id"}},
+			[]bson.RawValue{z},
+			bson.M{"$or": bson.A{bson.M{"_id": bson.M{"$gt": z}}}},
+		},
+		{
+			"single descending field",
+			Sort{{Field: "_id", Desc: true}},
+			[]bson.RawValue{z},
+			bson.M{"$or": bson.A{bson.M{"_id": bson.M{"$lt": z}}}},
+		},
+		{
+			"multiple ascending fields",
+			Sort{{Field: "last_name"}, {Field: "version"}, {Field: "_id"}},
+			[]bson.RawValue{x, y, z},
+			bson.M{"$or": bson.A{
+				bson.M{"last_name": bson.M{"$gt": x}},
+				bson.M{"last_name": x, "version": bson.M{"$gt": y}},
+				bson.M{"last_name": x, "version": y, "_id": bson.M{"$gt": z}},
+			}},
+		},
+		{
+			"multiple fields in mixed directions",
+			Sort{{Field: "last_name", Desc: true}, {Field: "version"}, {Field: "_id", Desc: true}},
+			[]bson.RawValue{x, y, z},
+			bson.M{"$or": bson.A{
+				bson.M{"last_name": bson.M{"$lt": x}},
+				bson.M{"last_name": x, "version": bson.M{"$gt": y}},
+				bson.M{"last_name": x, "version": y, "_id": bson.M{"$lt": z}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.sort.After(tt.key))
+		})
+	}
+}
+
+func TestSortCoveredBy(t *testing.T) {
+	createdIndex := Sort{{Field: "createdAt"}, {Field: "_id"}}
+	statusIndex := Sort{{Field: "status"}, {Field: "country"}, {Field: "last_name"}, {Field: "_id"}}
+
+	tests := []struct {
+		name     string
+		sort     Sort
+		index    Sort
+		equality []string
+		want     bool
+	}{
+		{"same fields and directions", Sort{{Field: "createdAt"}, {Field: "_id"}}, createdIndex, nil, true},
+		{"all directions reversed", Sort{{Field: "createdAt", Desc: true}, {Field: "_id", Desc: true}}, createdIndex, nil, true},
+		{"mixed directions", Sort{{Field: "createdAt", Desc: true}, {Field: "_id"}}, createdIndex, nil, false},
+		{"prefix of the index", Sort{{Field: "createdAt"}}, createdIndex, nil, true},
+		{"more fields than the index", Sort{{Field: "createdAt"}, {Field: "_id"}, {Field: "email"}}, createdIndex, nil, false},
+		{"other field", Sort{{Field: "updatedAt"}, {Field: "_id"}}, createdIndex, nil, false},
+		{"fields in another order", Sort{{Field: "_id"}, {Field: "createdAt"}}, createdIndex, nil, false},
+		{"empty sort", Sort{}, createdIndex, nil, true},
+		{"leading key with equality filter", Sort{{Field: "country"}, {Field: "last_name"}, {Field: "_id"}}, statusIndex, []string{"status"}, true},
+		{"leading key without equality filter", Sort{{Field: "country"}, {Field: "last_name"}, {Field: "_id"}}, statusIndex, nil, false},
+		{"leading keys with equality filters, reversed", Sort{{Field: "last_name", Desc: true}, {Field: "_id", Desc: true}}, statusIndex, []string{"status", "country"}, true},
+		{"sort field with equality filter is ignored", Sort{{Field: "status", Desc: true}, {Field: "country"}, {Field: "last_name"}}, statusIndex, []string{"status"}, true},
+		{"skipped index key", Sort{{Field: "last_name"}, {Field: "_id"}}, statusIndex, []string{"status"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.sort.CoveredBy(tt.index, tt.equality))
+		})
+	}
+}
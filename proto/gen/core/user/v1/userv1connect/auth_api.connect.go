@@ -51,7 +51,7 @@ var (
 
 // AuthAPIClient is a client for the core.user.v1.AuthAPI service.
 type AuthAPIClient interface {
-	// Login authenticates a user with email and password
+	// Login authenticates a user with email or verified phone and password
 	Login(context.Context, *connect.Request[v1.LoginRequest]) (*connect.Response[v1.LoginResponse], error)
 	// Refresh generates new access token using refresh token
 	Refresh(context.Context, *connect.Request[v1.RefreshRequest]) (*connect.Response[v1.RefreshResponse], error)
@@ -114,7 +114,7 @@ func (c *authAPIClient) Logout(ctx context.Context, req *connect.Request[v1.Logo
 
 // AuthAPIHandler is an implementation of the core.user.v1.AuthAPI service.
 type AuthAPIHandler interface {
-	// Login authenticates a user with email and password
+	// Login authenticates a user with email or verified phone and password
 	Login(context.Context, *connect.Request[v1.LoginRequest]) (*connect.Response[v1.LoginResponse], error)
 	// Refresh generates new access token using refresh token
 	Refresh(context.Context, *connect.Request[v1.RefreshRequest]) (*connect.Response[v1.RefreshResponse], error)
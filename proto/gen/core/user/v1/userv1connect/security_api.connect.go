@@ -0,0 +1,115 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: core/user/v1/security_api.proto
+
+package userv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/nsaltun/user-service-grpc/proto/gen/go/core/user/v1"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// SecurityAPIName is the fully-qualified name of the SecurityAPI service.
+	SecurityAPIName = "core.user.v1.SecurityAPI"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// SecurityAPIListSecurityEventsProcedure is the fully-qualified name of the SecurityAPI's
+	// ListSecurityEvents RPC.
+	SecurityAPIListSecurityEventsProcedure = "/core.user.v1.SecurityAPI/ListSecurityEvents"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	securityAPIServiceDescriptor                  = v1.File_core_user_v1_security_api_proto.Services().ByName("SecurityAPI")
+	securityAPIListSecurityEventsMethodDescriptor = securityAPIServiceDescriptor.Methods().ByName("ListSecurityEvents")
+)
+
+// SecurityAPIClient is a client for the core.user.v1.SecurityAPI service.
+type SecurityAPIClient interface {
+	// ListSecurityEvents returns security events, newest first
+	ListSecurityEvents(context.Context, *connect.Request[v1.ListSecurityEventsRequest]) (*connect.Response[v1.ListSecurityEventsResponse], error)
+}
+
+// NewSecurityAPIClient constructs a client for the core.user.v1.SecurityAPI service. By default, it
+// uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewSecurityAPIClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) SecurityAPIClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &securityAPIClient{
+		listSecurityEvents: connect.NewClient[v1.ListSecurityEventsRequest, v1.ListSecurityEventsResponse](
+			httpClient,
+			baseURL+SecurityAPIListSecurityEventsProcedure,
+			connect.WithSchema(securityAPIListSecurityEventsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// securityAPIClient implements SecurityAPIClient.
+type securityAPIClient struct {
+	listSecurityEvents *connect.Client[v1.ListSecurityEventsRequest, v1.ListSecurityEventsResponse]
+}
+
+// ListSecurityEvents calls core.user.v1.SecurityAPI.ListSecurityEvents.
+func (c *securityAPIClient) ListSecurityEvents(ctx context.Context, req *connect.Request[v1.ListSecurityEventsRequest]) (*connect.Response[v1.ListSecurityEventsResponse], error) {
+	return c.listSecurityEvents.CallUnary(ctx, req)
+}
+
+// SecurityAPIHandler is an implementation of the core.user.v1.SecurityAPI service.
+type SecurityAPIHandler interface {
+	// ListSecurityEvents returns security events, newest first
+	ListSecurityEvents(context.Context, *connect.Request[v1.ListSecurityEventsRequest]) (*connect.Response[v1.ListSecurityEventsResponse], error)
+}
+
+// NewSecurityAPIHandler builds an HTTP handler from the service implementation. It returns the path
+// on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewSecurityAPIHandler(svc SecurityAPIHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	securityAPIListSecurityEventsHandler := connect.NewUnaryHandler(
+		SecurityAPIListSecurityEventsProcedure,
+		svc.ListSecurityEvents,
+		connect.WithSchema(securityAPIListSecurityEventsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/core.user.v1.SecurityAPI/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case SecurityAPIListSecurityEventsProcedure:
+			securityAPIListSecurityEventsHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedSecurityAPIHandler returns CodeUnimplemented from all methods.
+type UnimplementedSecurityAPIHandler struct{}
+
+func (UnimplementedSecurityAPIHandler) ListSecurityEvents(context.Context, *connect.Request[v1.ListSecurityEventsRequest]) (*connect.Response[v1.ListSecurityEventsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.SecurityAPI.ListSecurityEvents is not implemented"))
+}
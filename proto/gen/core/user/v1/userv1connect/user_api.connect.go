@@ -39,17 +39,90 @@ const (
 	UserAPIUpdateUserByIdProcedure = "/core.user.v1.UserAPI/UpdateUserById"
 	// UserAPIDeleteUserByIdProcedure is the fully-qualified name of the UserAPI's DeleteUserById RPC.
 	UserAPIDeleteUserByIdProcedure = "/core.user.v1.UserAPI/DeleteUserById"
+	// UserAPIRestoreUserProcedure is the fully-qualified name of the UserAPI's RestoreUser RPC.
+	UserAPIRestoreUserProcedure = "/core.user.v1.UserAPI/RestoreUser"
+	// UserAPIRequestErasureProcedure is the fully-qualified name of the UserAPI's RequestErasure RPC.
+	UserAPIRequestErasureProcedure = "/core.user.v1.UserAPI/RequestErasure"
 	// UserAPIListUsersProcedure is the fully-qualified name of the UserAPI's ListUsers RPC.
 	UserAPIListUsersProcedure = "/core.user.v1.UserAPI/ListUsers"
+	// UserAPIGetUserProcedure is the fully-qualified name of the UserAPI's GetUser RPC.
+	UserAPIGetUserProcedure = "/core.user.v1.UserAPI/GetUser"
+	// UserAPIGetMeProcedure is the fully-qualified name of the UserAPI's GetMe RPC.
+	UserAPIGetMeProcedure = "/core.user.v1.UserAPI/GetMe"
+	// UserAPIUpdateMeProcedure is the fully-qualified name of the UserAPI's UpdateMe RPC.
+	UserAPIUpdateMeProcedure = "/core.user.v1.UserAPI/UpdateMe"
+	// UserAPIDeleteMeProcedure is the fully-qualified name of the UserAPI's DeleteMe RPC.
+	UserAPIDeleteMeProcedure = "/core.user.v1.UserAPI/DeleteMe"
+	// UserAPISearchUsersProcedure is the fully-qualified name of the UserAPI's SearchUsers RPC.
+	UserAPISearchUsersProcedure = "/core.user.v1.UserAPI/SearchUsers"
+	// UserAPIExportUsersProcedure is the fully-qualified name of the UserAPI's ExportUsers RPC.
+	UserAPIExportUsersProcedure = "/core.user.v1.UserAPI/ExportUsers"
+	// UserAPIWatchUsersProcedure is the fully-qualified name of the UserAPI's WatchUsers RPC.
+	UserAPIWatchUsersProcedure = "/core.user.v1.UserAPI/WatchUsers"
+	// UserAPIImportUsersProcedure is the fully-qualified name of the UserAPI's ImportUsers RPC.
+	UserAPIImportUsersProcedure = "/core.user.v1.UserAPI/ImportUsers"
+	// UserAPIBatchGetUsersProcedure is the fully-qualified name of the UserAPI's BatchGetUsers RPC.
+	UserAPIBatchGetUsersProcedure = "/core.user.v1.UserAPI/BatchGetUsers"
+	// UserAPIBatchUpdateUserStatusProcedure is the fully-qualified name of the UserAPI's
+	// BatchUpdateUserStatus RPC.
+	UserAPIBatchUpdateUserStatusProcedure = "/core.user.v1.UserAPI/BatchUpdateUserStatus"
+	// UserAPIBatchDeleteUsersProcedure is the fully-qualified name of the UserAPI's BatchDeleteUsers
+	// RPC.
+	UserAPIBatchDeleteUsersProcedure = "/core.user.v1.UserAPI/BatchDeleteUsers"
+	// UserAPIExportMyDataProcedure is the fully-qualified name of the UserAPI's ExportMyData RPC.
+	UserAPIExportMyDataProcedure = "/core.user.v1.UserAPI/ExportMyData"
+	// UserAPIExportUserDataProcedure is the fully-qualified name of the UserAPI's ExportUserData RPC.
+	UserAPIExportUserDataProcedure = "/core.user.v1.UserAPI/ExportUserData"
+	// UserAPISendPhoneVerificationProcedure is the fully-qualified name of the UserAPI's
+	// SendPhoneVerification RPC.
+	UserAPISendPhoneVerificationProcedure = "/core.user.v1.UserAPI/SendPhoneVerification"
+	// UserAPIVerifyPhoneProcedure is the fully-qualified name of the UserAPI's VerifyPhone RPC.
+	UserAPIVerifyPhoneProcedure = "/core.user.v1.UserAPI/VerifyPhone"
+	// UserAPIRequestEmailChangeProcedure is the fully-qualified name of the UserAPI's
+	// RequestEmailChange RPC.
+	UserAPIRequestEmailChangeProcedure = "/core.user.v1.UserAPI/RequestEmailChange"
+	// UserAPIConfirmEmailChangeProcedure is the fully-qualified name of the UserAPI's
+	// ConfirmEmailChange RPC.
+	UserAPIConfirmEmailChangeProcedure = "/core.user.v1.UserAPI/ConfirmEmailChange"
+	// UserAPIRevertEmailChangeProcedure is the fully-qualified name of the UserAPI's RevertEmailChange
+	// RPC.
+	UserAPIRevertEmailChangeProcedure = "/core.user.v1.UserAPI/RevertEmailChange"
+	// UserAPIAcceptInviteProcedure is the fully-qualified name of the UserAPI's AcceptInvite RPC.
+	UserAPIAcceptInviteProcedure = "/core.user.v1.UserAPI/AcceptInvite"
+	// UserAPICheckNicknameAvailabilityProcedure is the fully-qualified name of the UserAPI's
+	// CheckNicknameAvailability RPC.
+	UserAPICheckNicknameAvailabilityProcedure = "/core.user.v1.UserAPI/CheckNicknameAvailability"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
 var (
-	userAPIServiceDescriptor              = v1.File_core_user_v1_user_api_proto.Services().ByName("UserAPI")
-	userAPICreateUserMethodDescriptor     = userAPIServiceDescriptor.Methods().ByName("CreateUser")
-	userAPIUpdateUserByIdMethodDescriptor = userAPIServiceDescriptor.Methods().ByName("UpdateUserById")
-	userAPIDeleteUserByIdMethodDescriptor = userAPIServiceDescriptor.Methods().ByName("DeleteUserById")
-	userAPIListUsersMethodDescriptor      = userAPIServiceDescriptor.Methods().ByName("ListUsers")
+	userAPIServiceDescriptor                         = v1.File_core_user_v1_user_api_proto.Services().ByName("UserAPI")
+	userAPICreateUserMethodDescriptor                = userAPIServiceDescriptor.Methods().ByName("CreateUser")
+	userAPIUpdateUserByIdMethodDescriptor            = userAPIServiceDescriptor.Methods().ByName("UpdateUserById")
+	userAPIDeleteUserByIdMethodDescriptor            = userAPIServiceDescriptor.Methods().ByName("DeleteUserById")
+	userAPIRestoreUserMethodDescriptor               = userAPIServiceDescriptor.Methods().ByName("RestoreUser")
+	userAPIRequestErasureMethodDescriptor            = userAPIServiceDescriptor.Methods().ByName("RequestErasure")
+	userAPIListUsersMethodDescriptor                 = userAPIServiceDescriptor.Methods().ByName("ListUsers")
+	userAPIGetUserMethodDescriptor                   = userAPIServiceDescriptor.Methods().ByName("GetUser")
+	userAPIGetMeMethodDescriptor                     = userAPIServiceDescriptor.Methods().ByName("GetMe")
+	userAPIUpdateMeMethodDescriptor                  = userAPIServiceDescriptor.Methods().ByName("UpdateMe")
+	userAPIDeleteMeMethodDescriptor                  = userAPIServiceDescriptor.Methods().ByName("DeleteMe")
+	userAPISearchUsersMethodDescriptor               = userAPIServiceDescriptor.Methods().ByName("SearchUsers")
+	userAPIExportUsersMethodDescriptor               = userAPIServiceDescriptor.Methods().ByName("ExportUsers")
+	userAPIWatchUsersMethodDescriptor                = userAPIServiceDescriptor.Methods().ByName("WatchUsers")
+	userAPIImportUsersMethodDescriptor               = userAPIServiceDescriptor.Methods().ByName("ImportUsers")
+	userAPIBatchGetUsersMethodDescriptor             = userAPIServiceDescriptor.Methods().ByName("BatchGetUsers")
+	userAPIBatchUpdateUserStatusMethodDescriptor     = userAPIServiceDescriptor.Methods().ByName("BatchUpdateUserStatus")
+	userAPIBatchDeleteUsersMethodDescriptor          = userAPIServiceDescriptor.Methods().ByName("BatchDeleteUsers")
+	userAPIExportMyDataMethodDescriptor              = userAPIServiceDescriptor.Methods().ByName("ExportMyData")
+	userAPIExportUserDataMethodDescriptor            = userAPIServiceDescriptor.Methods().ByName("ExportUserData")
+	userAPISendPhoneVerificationMethodDescriptor     = userAPIServiceDescriptor.Methods().ByName("SendPhoneVerification")
+	userAPIVerifyPhoneMethodDescriptor               = userAPIServiceDescriptor.Methods().ByName("VerifyPhone")
+	userAPIRequestEmailChangeMethodDescriptor        = userAPIServiceDescriptor.Methods().ByName("RequestEmailChange")
+	userAPIConfirmEmailChangeMethodDescriptor        = userAPIServiceDescriptor.Methods().ByName("ConfirmEmailChange")
+	userAPIRevertEmailChangeMethodDescriptor         = userAPIServiceDescriptor.Methods().ByName("RevertEmailChange")
+	userAPIAcceptInviteMethodDescriptor              = userAPIServiceDescriptor.Methods().ByName("AcceptInvite")
+	userAPICheckNicknameAvailabilityMethodDescriptor = userAPIServiceDescriptor.Methods().ByName("CheckNicknameAvailability")
 )
 
 // UserAPIClient is a client for the core.user.v1.UserAPI service.
@@ -57,7 +130,51 @@ type UserAPIClient interface {
 	CreateUser(context.Context, *connect.Request[v1.CreateUserRequest]) (*connect.Response[v1.CreateUserResponse], error)
 	UpdateUserById(context.Context, *connect.Request[v1.UpdateUserByIdRequest]) (*connect.Response[v1.UpdateUserByIdResponse], error)
 	DeleteUserById(context.Context, *connect.Request[v1.DeleteUserByIdRequest]) (*connect.Response[v1.DeleteUserByIdResponse], error)
+	// RestoreUser reactivates a deleted user
+	RestoreUser(context.Context, *connect.Request[v1.RestoreUserRequest]) (*connect.Response[v1.RestoreUserResponse], error)
+	// RequestErasure deactivates a user and schedules the removal of its personal data
+	RequestErasure(context.Context, *connect.Request[v1.RequestErasureRequest]) (*connect.Response[v1.RequestErasureResponse], error)
 	ListUsers(context.Context, *connect.Request[v1.ListUsersRequest]) (*connect.Response[v1.ListUsersResponse], error)
+	// GetUser looks up a single user by exactly one of id, email or nickname
+	GetUser(context.Context, *connect.Request[v1.GetUserRequest]) (*connect.Response[v1.GetUserResponse], error)
+	// GetMe returns the profile of the authenticated user
+	GetMe(context.Context, *connect.Request[v1.GetMeRequest]) (*connect.Response[v1.GetMeResponse], error)
+	// UpdateMe updates the self-editable fields of the authenticated user
+	UpdateMe(context.Context, *connect.Request[v1.UpdateMeRequest]) (*connect.Response[v1.UpdateMeResponse], error)
+	// DeleteMe deactivates the authenticated user and revokes all of its tokens
+	DeleteMe(context.Context, *connect.Request[v1.DeleteMeRequest]) (*connect.Response[v1.DeleteMeResponse], error)
+	// SearchUsers finds active users by name or nickname, ignoring case and accents and matching word prefixes
+	SearchUsers(context.Context, *connect.Request[v1.SearchUsersRequest]) (*connect.Response[v1.SearchUsersResponse], error)
+	// ExportUsers streams all users matching the filter in creation order
+	ExportUsers(context.Context, *connect.Request[v1.ExportUsersRequest]) (*connect.ServerStreamForClient[v1.ExportUsersResponse], error)
+	// WatchUsers streams created, updated, deactivated and deleted users as they change
+	WatchUsers(context.Context, *connect.Request[v1.WatchUsersRequest]) (*connect.ServerStreamForClient[v1.WatchUsersResponse], error)
+	// ImportUsers creates users from CSV or JSONL rows and reports the outcome of every row
+	ImportUsers(context.Context) *connect.ClientStreamForClient[v1.ImportUsersRequest, v1.ImportUsersResponse]
+	// BatchGetUsers returns up to 1000 users by id
+	BatchGetUsers(context.Context, *connect.Request[v1.BatchGetUsersRequest]) (*connect.Response[v1.BatchGetUsersResponse], error)
+	// BatchUpdateUserStatus sets the status of up to 500 users
+	BatchUpdateUserStatus(context.Context, *connect.Request[v1.BatchUpdateUserStatusRequest]) (*connect.Response[v1.BatchUpdateUserStatusResponse], error)
+	// BatchDeleteUsers deactivates up to 500 users like DeleteUserById
+	BatchDeleteUsers(context.Context, *connect.Request[v1.BatchDeleteUsersRequest]) (*connect.Response[v1.BatchDeleteUsersResponse], error)
+	// ExportMyData streams everything stored about the authenticated user as a JSON document
+	ExportMyData(context.Context, *connect.Request[v1.ExportMyDataRequest]) (*connect.ServerStreamForClient[v1.ExportDataResponse], error)
+	// ExportUserData streams everything stored about a user as a JSON document, or writes it to the blob store
+	ExportUserData(context.Context, *connect.Request[v1.ExportUserDataRequest]) (*connect.ServerStreamForClient[v1.ExportDataResponse], error)
+	// SendPhoneVerification sends a verification code by SMS to the phone of the authenticated user
+	SendPhoneVerification(context.Context, *connect.Request[v1.SendPhoneVerificationRequest]) (*connect.Response[v1.SendPhoneVerificationResponse], error)
+	// VerifyPhone verifies the phone of the authenticated user with the code sent by SMS
+	VerifyPhone(context.Context, *connect.Request[v1.VerifyPhoneRequest]) (*connect.Response[v1.VerifyPhoneResponse], error)
+	// RequestEmailChange sends a confirmation link to the new email of the authenticated user
+	RequestEmailChange(context.Context, *connect.Request[v1.RequestEmailChangeRequest]) (*connect.Response[v1.RequestEmailChangeResponse], error)
+	// ConfirmEmailChange replaces the email of the authenticated user with the confirmed new address
+	ConfirmEmailChange(context.Context, *connect.Request[v1.ConfirmEmailChangeRequest]) (*connect.Response[v1.ConfirmEmailChangeResponse], error)
+	// RevertEmailChange restores the previous email with the token sent to it and revokes all sessions, it needs no authentication
+	RevertEmailChange(context.Context, *connect.Request[v1.RevertEmailChangeRequest]) (*connect.Response[v1.RevertEmailChangeResponse], error)
+	// AcceptInvite sets the password of a user imported without one with its invite token, it needs no authentication
+	AcceptInvite(context.Context, *connect.Request[v1.AcceptInviteRequest]) (*connect.Response[v1.AcceptInviteResponse], error)
+	// CheckNicknameAvailability reports whether a nickname can be chosen and suggests available ones, it needs no authentication
+	CheckNicknameAvailability(context.Context, *connect.Request[v1.CheckNicknameAvailabilityRequest]) (*connect.Response[v1.CheckNicknameAvailabilityResponse], error)
 }
 
 // NewUserAPIClient constructs a client for the core.user.v1.UserAPI service. By default, it uses
@@ -88,21 +205,175 @@ func NewUserAPIClient(httpClient connect.HTTPClient, baseURL string, opts ...con
 			connect.WithSchema(userAPIDeleteUserByIdMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		restoreUser: connect.NewClient[v1.RestoreUserRequest, v1.RestoreUserResponse](
+			httpClient,
+			baseURL+UserAPIRestoreUserProcedure,
+			connect.WithSchema(userAPIRestoreUserMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		requestErasure: connect.NewClient[v1.RequestErasureRequest, v1.RequestErasureResponse](
+			httpClient,
+			baseURL+UserAPIRequestErasureProcedure,
+			connect.WithSchema(userAPIRequestErasureMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 		listUsers: connect.NewClient[v1.ListUsersRequest, v1.ListUsersResponse](
 			httpClient,
 			baseURL+UserAPIListUsersProcedure,
 			connect.WithSchema(userAPIListUsersMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		getUser: connect.NewClient[v1.GetUserRequest, v1.GetUserResponse](
+			httpClient,
+			baseURL+UserAPIGetUserProcedure,
+			connect.WithSchema(userAPIGetUserMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getMe: connect.NewClient[v1.GetMeRequest, v1.GetMeResponse](
+			httpClient,
+			baseURL+UserAPIGetMeProcedure,
+			connect.WithSchema(userAPIGetMeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		updateMe: connect.NewClient[v1.UpdateMeRequest, v1.UpdateMeResponse](
+			httpClient,
+			baseURL+UserAPIUpdateMeProcedure,
+			connect.WithSchema(userAPIUpdateMeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		deleteMe: connect.NewClient[v1.DeleteMeRequest, v1.DeleteMeResponse](
+			httpClient,
+			baseURL+UserAPIDeleteMeProcedure,
+			connect.WithSchema(userAPIDeleteMeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		searchUsers: connect.NewClient[v1.SearchUsersRequest, v1.SearchUsersResponse](
+			httpClient,
+			baseURL+UserAPISearchUsersProcedure,
+			connect.WithSchema(userAPISearchUsersMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		exportUsers: connect.NewClient[v1.ExportUsersRequest, v1.ExportUsersResponse](
+			httpClient,
+			baseURL+UserAPIExportUsersProcedure,
+			connect.WithSchema(userAPIExportUsersMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		watchUsers: connect.NewClient[v1.WatchUsersRequest, v1.WatchUsersResponse](
+			httpClient,
+			baseURL+UserAPIWatchUsersProcedure,
+			connect.WithSchema(userAPIWatchUsersMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		importUsers: connect.NewClient[v1.ImportUsersRequest, v1.ImportUsersResponse](
+			httpClient,
+			baseURL+UserAPIImportUsersProcedure,
+			connect.WithSchema(userAPIImportUsersMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchGetUsers: connect.NewClient[v1.BatchGetUsersRequest, v1.BatchGetUsersResponse](
+			httpClient,
+			baseURL+UserAPIBatchGetUsersProcedure,
+			connect.WithSchema(userAPIBatchGetUsersMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchUpdateUserStatus: connect.NewClient[v1.BatchUpdateUserStatusRequest, v1.BatchUpdateUserStatusResponse](
+			httpClient,
+			baseURL+UserAPIBatchUpdateUserStatusProcedure,
+			connect.WithSchema(userAPIBatchUpdateUserStatusMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchDeleteUsers: connect.NewClient[v1.BatchDeleteUsersRequest, v1.BatchDeleteUsersResponse](
+			httpClient,
+			baseURL+UserAPIBatchDeleteUsersProcedure,
+			connect.WithSchema(userAPIBatchDeleteUsersMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		exportMyData: connect.NewClient[v1.ExportMyDataRequest, v1.ExportDataResponse](
+			httpClient,
+			baseURL+UserAPIExportMyDataProcedure,
+			connect.WithSchema(userAPIExportMyDataMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		exportUserData: connect.NewClient[v1.ExportUserDataRequest, v1.ExportDataResponse](
+			httpClient,
+			baseURL+UserAPIExportUserDataProcedure,
+			connect.WithSchema(userAPIExportUserDataMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		sendPhoneVerification: connect.NewClient[v1.SendPhoneVerificationRequest, v1.SendPhoneVerificationResponse](
+			httpClient,
+			baseURL+UserAPISendPhoneVerificationProcedure,
+			connect.WithSchema(userAPISendPhoneVerificationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		verifyPhone: connect.NewClient[v1.VerifyPhoneRequest, v1.VerifyPhoneResponse](
+			httpClient,
+			baseURL+UserAPIVerifyPhoneProcedure,
+			connect.WithSchema(userAPIVerifyPhoneMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		requestEmailChange: connect.NewClient[v1.RequestEmailChangeRequest, v1.RequestEmailChangeResponse](
+			httpClient,
+			baseURL+UserAPIRequestEmailChangeProcedure,
+			connect.WithSchema(userAPIRequestEmailChangeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		confirmEmailChange: connect.NewClient[v1.ConfirmEmailChangeRequest, v1.ConfirmEmailChangeResponse](
+			httpClient,
+			baseURL+UserAPIConfirmEmailChangeProcedure,
+			connect.WithSchema(userAPIConfirmEmailChangeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		revertEmailChange: connect.NewClient[v1.RevertEmailChangeRequest, v1.RevertEmailChangeResponse](
+			httpClient,
+			baseURL+UserAPIRevertEmailChangeProcedure,
+			connect.WithSchema(userAPIRevertEmailChangeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		acceptInvite: connect.NewClient[v1.AcceptInviteRequest, v1.AcceptInviteResponse](
+			httpClient,
+			baseURL+UserAPIAcceptInviteProcedure,
+			connect.WithSchema(userAPIAcceptInviteMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		checkNicknameAvailability: connect.NewClient[v1.CheckNicknameAvailabilityRequest, v1.CheckNicknameAvailabilityResponse](
+			httpClient,
+			baseURL+UserAPICheckNicknameAvailabilityProcedure,
+			connect.WithSchema(userAPICheckNicknameAvailabilityMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // userAPIClient implements UserAPIClient.
 type userAPIClient struct {
-	createUser     *connect.Client[v1.CreateUserRequest, v1.CreateUserResponse]
-	updateUserById *connect.Client[v1.UpdateUserByIdRequest, v1.UpdateUserByIdResponse]
-	deleteUserById *connect.Client[v1.DeleteUserByIdRequest, v1.DeleteUserByIdResponse]
-	listUsers      *connect.Client[v1.ListUsersRequest, v1.ListUsersResponse]
+	createUser                *connect.Client[v1.CreateUserRequest, v1.CreateUserResponse]
+	updateUserById            *connect.Client[v1.UpdateUserByIdRequest, v1.UpdateUserByIdResponse]
+	deleteUserById            *connect.Client[v1.DeleteUserByIdRequest, v1.DeleteUserByIdResponse]
+	restoreUser               *connect.Client[v1.RestoreUserRequest, v1.RestoreUserResponse]
+	requestErasure            *connect.Client[v1.RequestErasureRequest, v1.RequestErasureResponse]
+	listUsers                 *connect.Client[v1.ListUsersRequest, v1.ListUsersResponse]
+	getUser                   *connect.Client[v1.GetUserRequest, v1.GetUserResponse]
+	getMe                     *connect.Client[v1.GetMeRequest, v1.GetMeResponse]
+	updateMe                  *connect.Client[v1.UpdateMeRequest, v1.UpdateMeResponse]
+	deleteMe                  *connect.Client[v1.DeleteMeRequest, v1.DeleteMeResponse]
+	searchUsers               *connect.Client[v1.SearchUsersRequest, v1.SearchUsersResponse]
+	exportUsers               *connect.Client[v1.ExportUsersRequest, v1.ExportUsersResponse]
+	watchUsers                *connect.Client[v1.WatchUsersRequest, v1.WatchUsersResponse]
+	importUsers               *connect.Client[v1.ImportUsersRequest, v1.ImportUsersResponse]
+	batchGetUsers             *connect.Client[v1.BatchGetUsersRequest, v1.BatchGetUsersResponse]
+	batchUpdateUserStatus     *connect.Client[v1.BatchUpdateUserStatusRequest, v1.BatchUpdateUserStatusResponse]
+	batchDeleteUsers          *connect.Client[v1.BatchDeleteUsersRequest, v1.BatchDeleteUsersResponse]
+	exportMyData              *connect.Client[v1.ExportMyDataRequest, v1.ExportDataResponse]
+	exportUserData            *connect.Client[v1.ExportUserDataRequest, v1.ExportDataResponse]
+	sendPhoneVerification     *connect.Client[v1.SendPhoneVerificationRequest, v1.SendPhoneVerificationResponse]
+	verifyPhone               *connect.Client[v1.VerifyPhoneRequest, v1.VerifyPhoneResponse]
+	requestEmailChange        *connect.Client[v1.RequestEmailChangeRequest, v1.RequestEmailChangeResponse]
+	confirmEmailChange        *connect.Client[v1.ConfirmEmailChangeRequest, v1.ConfirmEmailChangeResponse]
+	revertEmailChange         *connect.Client[v1.RevertEmailChangeRequest, v1.RevertEmailChangeResponse]
+	acceptInvite              *connect.Client[v1.AcceptInviteRequest, v1.AcceptInviteResponse]
+	checkNicknameAvailability *connect.Client[v1.CheckNicknameAvailabilityRequest, v1.CheckNicknameAvailabilityResponse]
 }
 
 // CreateUser calls core.user.v1.UserAPI.CreateUser.
@@ -120,17 +391,171 @@ func (c *userAPIClient) DeleteUserById(ctx context.Context, req *connect.Request
 	return c.deleteUserById.CallUnary(ctx, req)
 }
 
+// RestoreUser calls core.user.v1.UserAPI.RestoreUser.
+func (c *userAPIClient) RestoreUser(ctx context.Context, req *connect.Request[v1.RestoreUserRequest]) (*connect.Response[v1.RestoreUserResponse], error) {
+	return c.restoreUser.CallUnary(ctx, req)
+}
+
+// RequestErasure calls core.user.v1.UserAPI.RequestErasure.
+func (c *userAPIClient) RequestErasure(ctx context.Context, req *connect.Request[v1.RequestErasureRequest]) (*connect.Response[v1.RequestErasureResponse], error) {
+	return c.requestErasure.CallUnary(ctx, req)
+}
+
 // ListUsers calls core.user.v1.UserAPI.ListUsers.
 func (c *userAPIClient) ListUsers(ctx context.Context, req *connect.Request[v1.ListUsersRequest]) (*connect.Response[v1.ListUsersResponse], error) {
 	return c.listUsers.CallUnary(ctx, req)
 }
 
+// GetUser calls core.user.v1.UserAPI.GetUser.
+func (c *userAPIClient) GetUser(ctx context.Context, req *connect.Request[v1.GetUserRequest]) (*connect.Response[v1.GetUserResponse], error) {
+	return c.getUser.CallUnary(ctx, req)
+}
+
+// GetMe calls core.user.v1.UserAPI.GetMe.
+func (c *userAPIClient) GetMe(ctx context.Context, req *connect.Request[v1.GetMeRequest]) (*connect.Response[v1.GetMeResponse], error) {
+	return c.getMe.CallUnary(ctx, req)
+}
+
+// UpdateMe calls core.user.v1.UserAPI.UpdateMe.
+func (c *userAPIClient) UpdateMe(ctx context.Context, req *connect.Request[v1.UpdateMeRequest]) (*connect.Response[v1.UpdateMeResponse], error) {
+	return c.updateMe.CallUnary(ctx, req)
+}
+
+// DeleteMe calls core.user.v1.UserAPI.DeleteMe.
+func (c *userAPIClient) DeleteMe(ctx context.Context, req *connect.Request[v1.DeleteMeRequest]) (*connect.Response[v1.DeleteMeResponse], error) {
+	return c.deleteMe.CallUnary(ctx, req)
+}
+
+// SearchUsers calls core.user.v1.UserAPI.SearchUsers.
+func (c *userAPIClient) SearchUsers(ctx context.Context, req *connect.Request[v1.SearchUsersRequest]) (*connect.Response[v1.SearchUsersResponse], error) {
+	return c.searchUsers.CallUnary(ctx, req)
+}
+
+// ExportUsers calls core.user.v1.UserAPI.ExportUsers.
+func (c *userAPIClient) ExportUsers(ctx context.Context, req *connect.Request[v1.ExportUsersRequest]) (*connect.ServerStreamForClient[v1.ExportUsersResponse], error) {
+	return c.exportUsers.CallServerStream(ctx, req)
+}
+
+// WatchUsers calls core.user.v1.UserAPI.WatchUsers.
+func (c *userAPIClient) WatchUsers(ctx context.Context, req *connect.Request[v1.WatchUsersRequest]) (*connect.ServerStreamForClient[v1.WatchUsersResponse], error) {
+	return c.watchUsers.CallServerStream(ctx, req)
+}
+
+// ImportUsers calls core.user.v1.UserAPI.ImportUsers.
+func (c *userAPIClient) ImportUsers(ctx context.Context) *connect.ClientStreamForClient[v1.ImportUsersRequest, v1.ImportUsersResponse] {
+	return c.importUsers.CallClientStream(ctx)
+}
+
+// BatchGetUsers calls core.user.v1.UserAPI.BatchGetUsers.
+func (c *userAPIClient) BatchGetUsers(ctx context.Context, req *connect.Request[v1.BatchGetUsersRequest]) (*connect.Response[v1.BatchGetUsersResponse], error) {
+	return c.batchGetUsers.CallUnary(ctx, req)
+}
+
+// BatchUpdateUserStatus calls core.user.v1.UserAPI.BatchUpdateUserStatus.
+func (c *userAPIClient) BatchUpdateUserStatus(ctx context.Context, req *connect.Request[v1.BatchUpdateUserStatusRequest]) (*connect.Response[v1.BatchUpdateUserStatusResponse], error) {
+	return c.batchUpdateUserStatus.CallUnary(ctx, req)
+}
+
+// BatchDeleteUsers calls core.user.v1.UserAPI.BatchDeleteUsers.
+func (c *userAPIClient) BatchDeleteUsers(ctx context.Context, req *connect.Request[v1.BatchDeleteUsersRequest]) (*connect.Response[v1.BatchDeleteUsersResponse], error) {
+	return c.batchDeleteUsers.CallUnary(ctx, req)
+}
+
+// ExportMyData calls core.user.v1.UserAPI.ExportMyData.
+func (c *userAPIClient) ExportMyData(ctx context.Context, req *connect.Request[v1.ExportMyDataRequest]) (*connect.ServerStreamForClient[v1.ExportDataResponse], error) {
+	return c.exportMyData.CallServerStream(ctx, req)
+}
+
+// ExportUserData calls core.user.v1.UserAPI.ExportUserData.
+func (c *userAPIClient) ExportUserData(ctx context.Context, req *connect.Request[v1.ExportUserDataRequest]) (*connect.ServerStreamForClient[v1.ExportDataResponse], error) {
+	return c.exportUserData.CallServerStream(ctx, req)
+}
+
+// SendPhoneVerification calls core.user.v1.UserAPI.SendPhoneVerification.
+func (c *userAPIClient) SendPhoneVerification(ctx context.Context, req *connect.Request[v1.SendPhoneVerificationRequest]) (*connect.Response[v1.SendPhoneVerificationResponse], error) {
+	return c.sendPhoneVerification.CallUnary(ctx, req)
+}
+
+// VerifyPhone calls core.user.v1.UserAPI.VerifyPhone.
+func (c *userAPIClient) VerifyPhone(ctx context.Context, req *connect.Request[v1.VerifyPhoneRequest]) (*connect.Response[v1.VerifyPhoneResponse], error) {
+	return c.verifyPhone.CallUnary(ctx, req)
+}
+
+// RequestEmailChange calls core.user.v1.UserAPI.RequestEmailChange.
+func (c *userAPIClient) RequestEmailChange(ctx context.Context, req *connect.Request[v1.RequestEmailChangeRequest]) (*connect.Response[v1.RequestEmailChangeResponse], error) {
+	return c.requestEmailChange.CallUnary(ctx, req)
+}
+
+// ConfirmEmailChange calls core.user.v1.UserAPI.ConfirmEmailChange.
+func (c *userAPIClient) ConfirmEmailChange(ctx context.Context, req *connect.Request[v1.ConfirmEmailChangeRequest]) (*connect.Response[v1.ConfirmEmailChangeResponse], error) {
+	return c.confirmEmailChange.CallUnary(ctx, req)
+}
+
+// RevertEmailChange calls core.user.v1.UserAPI.RevertEmailChange.
+func (c *userAPIClient) RevertEmailChange(ctx context.Context, req *connect.Request[v1.RevertEmailChangeRequest]) (*connect.Response[v1.RevertEmailChangeResponse], error) {
+	return c.revertEmailChange.CallUnary(ctx, req)
+}
+
+// AcceptInvite calls core.user.v1.UserAPI.AcceptInvite.
+func (c *userAPIClient) AcceptInvite(ctx context.Context, req *connect.Request[v1.AcceptInviteRequest]) (*connect.Response[v1.AcceptInviteResponse], error) {
+	return c.acceptInvite.CallUnary(ctx, req)
+}
+
+// CheckNicknameAvailability calls core.user.v1.UserAPI.CheckNicknameAvailability.
+func (c *userAPIClient) CheckNicknameAvailability(ctx context.Context, req *connect.Request[v1.CheckNicknameAvailabilityRequest]) (*connect.Response[v1.CheckNicknameAvailabilityResponse], error) {
+	return c.checkNicknameAvailability.CallUnary(ctx, req)
+}
+
 // UserAPIHandler is an implementation of the core.user.v1.UserAPI service.
 type UserAPIHandler interface {
 	CreateUser(context.Context, *connect.Request[v1.CreateUserRequest]) (*connect.Response[v1.CreateUserResponse], error)
 	UpdateUserById(context.Context, *connect.Request[v1.UpdateUserByIdRequest]) (*connect.Response[v1.UpdateUserByIdResponse], error)
 	DeleteUserById(context.Context, *connect.Request[v1.DeleteUserByIdRequest]) (*connect.Response[v1.DeleteUserByIdResponse], error)
+	// RestoreUser reactivates a deleted user
+	RestoreUser(context.Context, *connect.Request[v1.RestoreUserRequest]) (*connect.Response[v1.RestoreUserResponse], error)
+	// RequestErasure deactivates a user and schedules the removal of its personal data
+	RequestErasure(context.Context, *connect.Request[v1.RequestErasureRequest]) (*connect.Response[v1.RequestErasureResponse], error)
 	ListUsers(context.Context, *connect.Request[v1.ListUsersRequest]) (*connect.Response[v1.ListUsersResponse], error)
+	// GetUser looks up a single user by exactly one of id, email or nickname
+	GetUser(context.Context, *connect.Request[v1.GetUserRequest]) (*connect.Response[v1.GetUserResponse], error)
+	// GetMe returns the profile of the authenticated user
+	GetMe(context.Context, *connect.Request[v1.GetMeRequest]) (*connect.Response[v1.GetMeResponse], error)
+	// UpdateMe updates the self-editable fields of the authenticated user
+	UpdateMe(context.Context, *connect.Request[v1.UpdateMeRequest]) (*connect.Response[v1.UpdateMeResponse], error)
+	// DeleteMe deactivates the authenticated user and revokes all of its tokens
+	DeleteMe(context.Context, *connect.Request[v1.DeleteMeRequest]) (*connect.Response[v1.DeleteMeResponse], error)
+	// SearchUsers finds active users by name or nickname, ignoring case and accents and matching word prefixes
+	SearchUsers(context.Context, *connect.Request[v1.SearchUsersRequest]) (*connect.Response[v1.SearchUsersResponse], error)
+	// ExportUsers streams all users matching the filter in creation order
+	ExportUsers(context.Context, *connect.Request[v1.ExportUsersRequest], *connect.ServerStream[v1.ExportUsersResponse]) error
+	// WatchUsers streams created, updated, deactivated and deleted users as they change
+	WatchUsers(context.Context, *connect.Request[v1.WatchUsersRequest], *connect.ServerStream[v1.WatchUsersResponse]) error
+	// ImportUsers creates users from CSV or JSONL rows and reports the outcome of every row
+	ImportUsers(context.Context, *connect.ClientStream[v1.ImportUsersRequest]) (*connect.Response[v1.ImportUsersResponse], error)
+	// BatchGetUsers returns up to 1000 users by id
+	BatchGetUsers(context.Context, *connect.Request[v1.BatchGetUsersRequest]) (*connect.Response[v1.BatchGetUsersResponse], error)
+	// BatchUpdateUserStatus sets the status of up to 500 users
+	BatchUpdateUserStatus(context.Context, *connect.Request[v1.BatchUpdateUserStatusRequest]) (*connect.Response[v1.BatchUpdateUserStatusResponse], error)
+	// BatchDeleteUsers deactivates up to 500 users like DeleteUserById
+	BatchDeleteUsers(context.Context, *connect.Request[v1.BatchDeleteUsersRequest]) (*connect.Response[v1.BatchDeleteUsersResponse], error)
+	// ExportMyData streams everything stored about the authenticated user as a JSON document
+	ExportMyData(context.Context, *connect.Request[v1.ExportMyDataRequest], *connect.ServerStream[v1.ExportDataResponse]) error
+	// ExportUserData streams everything stored about a user as a JSON document, or writes it to the blob store
+	ExportUserData(context.Context, *connect.Request[v1.ExportUserDataRequest], *connect.ServerStream[v1.ExportDataResponse]) error
+	// SendPhoneVerification sends a verification code by SMS to the phone of the authenticated user
+	SendPhoneVerification(context.Context, *connect.Request[v1.SendPhoneVerificationRequest]) (*connect.Response[v1.SendPhoneVerificationResponse], error)
+	// VerifyPhone verifies the phone of the authenticated user with the code sent by SMS
+	VerifyPhone(context.Context, *connect.Request[v1.VerifyPhoneRequest]) (*connect.Response[v1.VerifyPhoneResponse], error)
+	// RequestEmailChange sends a confirmation link to the new email of the authenticated user
+	RequestEmailChange(context.Context, *connect.Request[v1.RequestEmailChangeRequest]) (*connect.Response[v1.RequestEmailChangeResponse], error)
+	// ConfirmEmailChange replaces the email of the authenticated user with the confirmed new address
+	ConfirmEmailChange(context.Context, *connect.Request[v1.ConfirmEmailChangeRequest]) (*connect.Response[v1.ConfirmEmailChangeResponse], error)
+	// RevertEmailChange restores the previous email with the token sent to it and revokes all sessions, it needs no authentication
+	RevertEmailChange(context.Context, *connect.Request[v1.RevertEmailChangeRequest]) (*connect.Response[v1.RevertEmailChangeResponse], error)
+	// AcceptInvite sets the password of a user imported without one with its invite token, it needs no authentication
+	AcceptInvite(context.Context, *connect.Request[v1.AcceptInviteRequest]) (*connect.Response[v1.AcceptInviteResponse], error)
+	// CheckNicknameAvailability reports whether a nickname can be chosen and suggests available ones, it needs no authentication
+	CheckNicknameAvailability(context.Context, *connect.Request[v1.CheckNicknameAvailabilityRequest]) (*connect.Response[v1.CheckNicknameAvailabilityResponse], error)
 }
 
 // NewUserAPIHandler builds an HTTP handler from the service implementation. It returns the path on
@@ -157,12 +582,144 @@ func NewUserAPIHandler(svc UserAPIHandler, opts ...connect.HandlerOption) (strin
 		connect.WithSchema(userAPIDeleteUserByIdMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	userAPIRestoreUserHandler := connect.NewUnaryHandler(
+		UserAPIRestoreUserProcedure,
+		svc.RestoreUser,
+		connect.WithSchema(userAPIRestoreUserMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIRequestErasureHandler := connect.NewUnaryHandler(
+		UserAPIRequestErasureProcedure,
+		svc.RequestErasure,
+		connect.WithSchema(userAPIRequestErasureMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	userAPIListUsersHandler := connect.NewUnaryHandler(
 		UserAPIListUsersProcedure,
 		svc.ListUsers,
 		connect.WithSchema(userAPIListUsersMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	userAPIGetUserHandler := connect.NewUnaryHandler(
+		UserAPIGetUserProcedure,
+		svc.GetUser,
+		connect.WithSchema(userAPIGetUserMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIGetMeHandler := connect.NewUnaryHandler(
+		UserAPIGetMeProcedure,
+		svc.GetMe,
+		connect.WithSchema(userAPIGetMeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIUpdateMeHandler := connect.NewUnaryHandler(
+		UserAPIUpdateMeProcedure,
+		svc.UpdateMe,
+		connect.WithSchema(userAPIUpdateMeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIDeleteMeHandler := connect.NewUnaryHandler(
+		UserAPIDeleteMeProcedure,
+		svc.DeleteMe,
+		connect.WithSchema(userAPIDeleteMeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPISearchUsersHandler := connect.NewUnaryHandler(
+		UserAPISearchUsersProcedure,
+		svc.SearchUsers,
+		connect.WithSchema(userAPISearchUsersMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIExportUsersHandler := connect.NewServerStreamHandler(
+		UserAPIExportUsersProcedure,
+		svc.ExportUsers,
+		connect.WithSchema(userAPIExportUsersMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIWatchUsersHandler := connect.NewServerStreamHandler(
+		UserAPIWatchUsersProcedure,
+		svc.WatchUsers,
+		connect.WithSchema(userAPIWatchUsersMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIImportUsersHandler := connect.NewClientStreamHandler(
+		UserAPIImportUsersProcedure,
+		svc.ImportUsers,
+		connect.WithSchema(userAPIImportUsersMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIBatchGetUsersHandler := connect.NewUnaryHandler(
+		UserAPIBatchGetUsersProcedure,
+		svc.BatchGetUsers,
+		connect.WithSchema(userAPIBatchGetUsersMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIBatchUpdateUserStatusHandler := connect.NewUnaryHandler(
+		UserAPIBatchUpdateUserStatusProcedure,
+		svc.BatchUpdateUserStatus,
+		connect.WithSchema(userAPIBatchUpdateUserStatusMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIBatchDeleteUsersHandler := connect.NewUnaryHandler(
+		UserAPIBatchDeleteUsersProcedure,
+		svc.BatchDeleteUsers,
+		connect.WithSchema(userAPIBatchDeleteUsersMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIExportMyDataHandler := connect.NewServerStreamHandler(
+		UserAPIExportMyDataProcedure,
+		svc.ExportMyData,
+		connect.WithSchema(userAPIExportMyDataMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIExportUserDataHandler := connect.NewServerStreamHandler(
+		UserAPIExportUserDataProcedure,
+		svc.ExportUserData,
+		connect.WithSchema(userAPIExportUserDataMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPISendPhoneVerificationHandler := connect.NewUnaryHandler(
+		UserAPISendPhoneVerificationProcedure,
+		svc.SendPhoneVerification,
+		connect.WithSchema(userAPISendPhoneVerificationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIVerifyPhoneHandler := connect.NewUnaryHandler(
+		UserAPIVerifyPhoneProcedure,
+		svc.VerifyPhone,
+		connect.WithSchema(userAPIVerifyPhoneMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIRequestEmailChangeHandler := connect.NewUnaryHandler(
+		UserAPIRequestEmailChangeProcedure,
+		svc.RequestEmailChange,
+		connect.WithSchema(userAPIRequestEmailChangeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIConfirmEmailChangeHandler := connect.NewUnaryHandler(
+		UserAPIConfirmEmailChangeProcedure,
+		svc.ConfirmEmailChange,
+		connect.WithSchema(userAPIConfirmEmailChangeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIRevertEmailChangeHandler := connect.NewUnaryHandler(
+		UserAPIRevertEmailChangeProcedure,
+		svc.RevertEmailChange,
+		connect.WithSchema(userAPIRevertEmailChangeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPIAcceptInviteHandler := connect.NewUnaryHandler(
+		UserAPIAcceptInviteProcedure,
+		svc.AcceptInvite,
+		connect.WithSchema(userAPIAcceptInviteMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userAPICheckNicknameAvailabilityHandler := connect.NewUnaryHandler(
+		UserAPICheckNicknameAvailabilityProcedure,
+		svc.CheckNicknameAvailability,
+		connect.WithSchema(userAPICheckNicknameAvailabilityMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/core.user.v1.UserAPI/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case UserAPICreateUserProcedure:
@@ -171,8 +728,52 @@ func NewUserAPIHandler(svc UserAPIHandler, opts ...connect.HandlerOption) (strin
 			userAPIUpdateUserByIdHandler.ServeHTTP(w, r)
 		case UserAPIDeleteUserByIdProcedure:
 			userAPIDeleteUserByIdHandler.ServeHTTP(w, r)
+		case UserAPIRestoreUserProcedure:
+			userAPIRestoreUserHandler.ServeHTTP(w, r)
+		case UserAPIRequestErasureProcedure:
+			userAPIRequestErasureHandler.ServeHTTP(w, r)
 		case UserAPIListUsersProcedure:
 			userAPIListUsersHandler.ServeHTTP(w, r)
+		case UserAPIGetUserProcedure:
+			userAPIGetUserHandler.ServeHTTP(w, r)
+		case UserAPIGetMeProcedure:
+			userAPIGetMeHandler.ServeHTTP(w, r)
+		case UserAPIUpdateMeProcedure:
+			userAPIUpdateMeHandler.ServeHTTP(w, r)
+		case UserAPIDeleteMeProcedure:
+			userAPIDeleteMeHandler.ServeHTTP(w, r)
+		case UserAPISearchUsersProcedure:
+			userAPISearchUsersHandler.ServeHTTP(w, r)
+		case UserAPIExportUsersProcedure:
+			userAPIExportUsersHandler.ServeHTTP(w, r)
+		case UserAPIWatchUsersProcedure:
+			userAPIWatchUsersHandler.ServeHTTP(w, r)
+		case UserAPIImportUsersProcedure:
+			userAPIImportUsersHandler.ServeHTTP(w, r)
+		case UserAPIBatchGetUsersProcedure:
+			userAPIBatchGetUsersHandler.ServeHTTP(w, r)
+		case UserAPIBatchUpdateUserStatusProcedure:
+			userAPIBatchUpdateUserStatusHandler.ServeHTTP(w, r)
+		case UserAPIBatchDeleteUsersProcedure:
+			userAPIBatchDeleteUsersHandler.ServeHTTP(w, r)
+		case UserAPIExportMyDataProcedure:
+			userAPIExportMyDataHandler.ServeHTTP(w, r)
+		case UserAPIExportUserDataProcedure:
+			userAPIExportUserDataHandler.ServeHTTP(w, r)
+		case UserAPISendPhoneVerificationProcedure:
+			userAPISendPhoneVerificationHandler.ServeHTTP(w, r)
+		case UserAPIVerifyPhoneProcedure:
+			userAPIVerifyPhoneHandler.ServeHTTP(w, r)
+		case UserAPIRequestEmailChangeProcedure:
+			userAPIRequestEmailChangeHandler.ServeHTTP(w, r)
+		case UserAPIConfirmEmailChangeProcedure:
+			userAPIConfirmEmailChangeHandler.ServeHTTP(w, r)
+		case UserAPIRevertEmailChangeProcedure:
+			userAPIRevertEmailChangeHandler.ServeHTTP(w, r)
+		case UserAPIAcceptInviteProcedure:
+			userAPIAcceptInviteHandler.ServeHTTP(w, r)
+		case UserAPICheckNicknameAvailabilityProcedure:
+			userAPICheckNicknameAvailabilityHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -194,6 +795,94 @@ func (UnimplementedUserAPIHandler) DeleteUserById(context.Context, *connect.Requ
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.DeleteUserById is not implemented"))
 }
 
+func (UnimplementedUserAPIHandler) RestoreUser(context.Context, *connect.Request[v1.RestoreUserRequest]) (*connect.Response[v1.RestoreUserResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.RestoreUser is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) RequestErasure(context.Context, *connect.Request[v1.RequestErasureRequest]) (*connect.Response[v1.RequestErasureResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.RequestErasure is not implemented"))
+}
+
 func (UnimplementedUserAPIHandler) ListUsers(context.Context, *connect.Request[v1.ListUsersRequest]) (*connect.Response[v1.ListUsersResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.ListUsers is not implemented"))
 }
+
+func (UnimplementedUserAPIHandler) GetUser(context.Context, *connect.Request[v1.GetUserRequest]) (*connect.Response[v1.GetUserResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.GetUser is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) GetMe(context.Context, *connect.Request[v1.GetMeRequest]) (*connect.Response[v1.GetMeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.GetMe is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) UpdateMe(context.Context, *connect.Request[v1.UpdateMeRequest]) (*connect.Response[v1.UpdateMeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.UpdateMe is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) DeleteMe(context.Context, *connect.Request[v1.DeleteMeRequest]) (*connect.Response[v1.DeleteMeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.DeleteMe is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) SearchUsers(context.Context, *connect.Request[v1.SearchUsersRequest]) (*connect.Response[v1.SearchUsersResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.SearchUsers is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) ExportUsers(context.Context, *connect.Request[v1.ExportUsersRequest], *connect.ServerStream[v1.ExportUsersResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.ExportUsers is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) WatchUsers(context.Context, *connect.Request[v1.WatchUsersRequest], *connect.ServerStream[v1.WatchUsersResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.WatchUsers is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) ImportUsers(context.Context, *connect.ClientStream[v1.ImportUsersRequest]) (*connect.Response[v1.ImportUsersResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.ImportUsers is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) BatchGetUsers(context.Context, *connect.Request[v1.BatchGetUsersRequest]) (*connect.Response[v1.BatchGetUsersResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.BatchGetUsers is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) BatchUpdateUserStatus(context.Context, *connect.Request[v1.BatchUpdateUserStatusRequest]) (*connect.Response[v1.BatchUpdateUserStatusResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.BatchUpdateUserStatus is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) BatchDeleteUsers(context.Context, *connect.Request[v1.BatchDeleteUsersRequest]) (*connect.Response[v1.BatchDeleteUsersResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.BatchDeleteUsers is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) ExportMyData(context.Context, *connect.Request[v1.ExportMyDataRequest], *connect.ServerStream[v1.ExportDataResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.ExportMyData is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) ExportUserData(context.Context, *connect.Request[v1.ExportUserDataRequest], *connect.ServerStream[v1.ExportDataResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.ExportUserData is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) SendPhoneVerification(context.Context, *connect.Request[v1.SendPhoneVerificationRequest]) (*connect.Response[v1.SendPhoneVerificationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.SendPhoneVerification is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) VerifyPhone(context.Context, *connect.Request[v1.VerifyPhoneRequest]) (*connect.Response[v1.VerifyPhoneResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.VerifyPhone is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) RequestEmailChange(context.Context, *connect.Request[v1.RequestEmailChangeRequest]) (*connect.Response[v1.RequestEmailChangeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.RequestEmailChange is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) ConfirmEmailChange(context.Context, *connect.Request[v1.ConfirmEmailChangeRequest]) (*connect.Response[v1.ConfirmEmailChangeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.ConfirmEmailChange is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) RevertEmailChange(context.Context, *connect.Request[v1.RevertEmailChangeRequest]) (*connect.Response[v1.RevertEmailChangeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.RevertEmailChange is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) AcceptInvite(context.Context, *connect.Request[v1.AcceptInviteRequest]) (*connect.Response[v1.AcceptInviteResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.AcceptInvite is not implemented"))
+}
+
+func (UnimplementedUserAPIHandler) CheckNicknameAvailability(context.Context, *connect.Request[v1.CheckNicknameAvailabilityRequest]) (*connect.Response[v1.CheckNicknameAvailabilityResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("core.user.v1.UserAPI.CheckNicknameAvailability is not implemented"))
+}
@@ -28,7 +28,7 @@ const (
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AuthAPIClient interface {
-	// Login authenticates a user with email and password
+	// Login authenticates a user with email or verified phone and password
 	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
 	// Refresh generates new access token using refresh token
 	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error)
@@ -75,7 +75,7 @@ func (c *authAPIClient) Logout(ctx context.Context, in *LogoutRequest, opts ...g
 // All implementations must embed UnimplementedAuthAPIServer
 // for forward compatibility
 type AuthAPIServer interface {
-	// Login authenticates a user with email and password
+	// Login authenticates a user with email or verified phone and password
 	Login(context.Context, *LoginRequest) (*LoginResponse, error)
 	// Refresh generates new access token using refresh token
 	Refresh(context.Context, *RefreshRequest) (*RefreshResponse, error)
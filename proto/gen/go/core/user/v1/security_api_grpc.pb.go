@@ -0,0 +1,111 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: core/user/v1/security_api.proto
+
+package userv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	SecurityAPI_ListSecurityEvents_FullMethodName = "/core.user.v1.SecurityAPI/ListSecurityEvents"
+)
+
+// SecurityAPIClient is the client API for SecurityAPI service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SecurityAPIClient interface {
+	// ListSecurityEvents returns security events, newest first
+	ListSecurityEvents(ctx context.Context, in *ListSecurityEventsRequest, opts ...grpc.CallOption) (*ListSecurityEventsResponse, error)
+}
+
+type securityAPIClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSecurityAPIClient(cc grpc.ClientConnInterface) SecurityAPIClient {
+	return &securityAPIClient{cc}
+}
+
+func (c *securityAPIClient) ListSecurityEvents(ctx context.Context, in *ListSecurityEventsRequest, opts ...grpc.CallOption) (*ListSecurityEventsResponse, error) {
+	out := new(ListSecurityEventsResponse)
+	err := c.cc.Invoke(ctx, SecurityAPI_ListSecurityEvents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SecurityAPIServer is the server API for SecurityAPI service.
+// All implementations must embed UnimplementedSecurityAPIServer
+// for forward compatibility
+type SecurityAPIServer interface {
+	// ListSecurityEvents returns security events, newest first
+	ListSecurityEvents(context.Context, *ListSecurityEventsRequest) (*ListSecurityEventsResponse, error)
+	mustEmbedUnimplementedSecurityAPIServer()
+}
+
+// UnimplementedSecurityAPIServer must be embedded to have forward compatible implementations.
+type UnimplementedSecurityAPIServer struct {
+}
+
+func (UnimplementedSecurityAPIServer) ListSecurityEvents(context.Context, *ListSecurityEventsRequest) (*ListSecurityEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSecurityEvents not implemented")
+}
+func (UnimplementedSecurityAPIServer) mustEmbedUnimplementedSecurityAPIServer() {}
+
+// UnsafeSecurityAPIServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SecurityAPIServer will
+// result in compilation errors.
+type UnsafeSecurityAPIServer interface {
+	mustEmbedUnimplementedSecurityAPIServer()
+}
+
+func RegisterSecurityAPIServer(s grpc.ServiceRegistrar, srv SecurityAPIServer) {
+	s.RegisterService(&SecurityAPI_ServiceDesc, srv)
+}
+
+func _SecurityAPI_ListSecurityEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSecurityEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecurityAPIServer).ListSecurityEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SecurityAPI_ListSecurityEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecurityAPIServer).ListSecurityEvents(ctx, req.(*ListSecurityEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SecurityAPI_ServiceDesc is the grpc.ServiceDesc for SecurityAPI service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SecurityAPI_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "core.user.v1.SecurityAPI",
+	HandlerType: (*SecurityAPIServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSecurityEvents",
+			Handler:    _SecurityAPI_ListSecurityEvents_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "core/user/v1/security_api.proto",
+}
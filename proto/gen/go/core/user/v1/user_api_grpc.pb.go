@@ -19,10 +19,32 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	UserAPI_CreateUser_FullMethodName     = "/core.user.v1.UserAPI/CreateUser"
-	UserAPI_UpdateUserById_FullMethodName = "/core.user.v1.UserAPI/UpdateUserById"
-	UserAPI_DeleteUserById_FullMethodName = "/core.user.v1.UserAPI/DeleteUserById"
-	UserAPI_ListUsers_FullMethodName      = "/core.user.v1.UserAPI/ListUsers"
+	UserAPI_CreateUser_FullMethodName                = "/core.user.v1.UserAPI/CreateUser"
+	UserAPI_UpdateUserById_FullMethodName            = "/core.user.v1.UserAPI/UpdateUserById"
+	UserAPI_DeleteUserById_FullMethodName            = "/core.user.v1.UserAPI/DeleteUserById"
+	UserAPI_RestoreUser_FullMethodName               = "/core.user.v1.UserAPI/RestoreUser"
+	UserAPI_RequestErasure_FullMethodName            = "/core.user.v1.UserAPI/RequestErasure"
+	UserAPI_ListUsers_FullMethodName                 = "/core.user.v1.UserAPI/ListUsers"
+	UserAPI_GetUser_FullMethodName                   = "/core.user.v1.UserAPI/GetUser"
+	UserAPI_GetMe_FullMethodName                     = "/core.user.v1.UserAPI/GetMe"
+	UserAPI_UpdateMe_FullMethodName                  = "/core.user.v1.UserAPI/UpdateMe"
+	UserAPI_DeleteMe_FullMethodName                  = "/core.user.v1.UserAPI/DeleteMe"
+	UserAPI_SearchUsers_FullMethodName               = "/core.user.v1.UserAPI/SearchUsers"
+	UserAPI_ExportUsers_FullMethodName               = "/core.user.v1.UserAPI/ExportUsers"
+	UserAPI_WatchUsers_FullMethodName                = "/core.user.v1.UserAPI/WatchUsers"
+	UserAPI_ImportUsers_FullMethodName               = "/core.user.v1.UserAPI/ImportUsers"
+	UserAPI_BatchGetUsers_FullMethodName             = "/core.user.v1.UserAPI/BatchGetUsers"
+	UserAPI_BatchUpdateUserStatus_FullMethodName     = "/core.user.v1.UserAPI/BatchUpdateUserStatus"
+	UserAPI_BatchDeleteUsers_FullMethodName          = "/core.user.v1.UserAPI/BatchDeleteUsers"
+	UserAPI_ExportMyData_FullMethodName              = "/core.user.v1.UserAPI/ExportMyData"
+	UserAPI_ExportUserData_FullMethodName            = "/core.user.v1.UserAPI/ExportUserData"
+	UserAPI_SendPhoneVerification_FullMethodName     = "/core.user.v1.UserAPI/SendPhoneVerification"
+	UserAPI_VerifyPhone_FullMethodName               = "/core.user.v1.UserAPI/VerifyPhone"
+	UserAPI_RequestEmailChange_FullMethodName        = "/core.user.v1.UserAPI/RequestEmailChange"
+	UserAPI_ConfirmEmailChange_FullMethodName        = "/core.user.v1.UserAPI/ConfirmEmailChange"
+	UserAPI_RevertEmailChange_FullMethodName         = "/core.user.v1.UserAPI/RevertEmailChange"
+	UserAPI_AcceptInvite_FullMethodName              = "/core.user.v1.UserAPI/AcceptInvite"
+	UserAPI_CheckNicknameAvailability_FullMethodName = "/core.user.v1.UserAPI/CheckNicknameAvailability"
 )
 
 // UserAPIClient is the client API for UserAPI service.
@@ -32,7 +54,51 @@ type UserAPIClient interface {
 	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*CreateUserResponse, error)
 	UpdateUserById(ctx context.Context, in *UpdateUserByIdRequest, opts ...grpc.CallOption) (*UpdateUserByIdResponse, error)
 	DeleteUserById(ctx context.Context, in *DeleteUserByIdRequest, opts ...grpc.CallOption) (*DeleteUserByIdResponse, error)
+	// RestoreUser reactivates a deleted user
+	RestoreUser(ctx context.Context, in *RestoreUserRequest, opts ...grpc.CallOption) (*RestoreUserResponse, error)
+	// RequestErasure deactivates a user and schedules the removal of its personal data
+	RequestErasure(ctx context.Context, in *RequestErasureRequest, opts ...grpc.CallOption) (*RequestErasureResponse, error)
 	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error)
+	// GetUser looks up a single user by exactly one of id, email or nickname
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*GetUserResponse, error)
+	// GetMe returns the profile of the authenticated user
+	GetMe(ctx context.Context, in *GetMeRequest, opts ...grpc.CallOption) (*GetMeResponse, error)
+	// UpdateMe updates the self-editable fields of the authenticated user
+	UpdateMe(ctx context.Context, in *UpdateMeRequest, opts ...grpc.CallOption) (*UpdateMeResponse, error)
+	// DeleteMe deactivates the authenticated user and revokes all of its tokens
+	DeleteMe(ctx context.Context, in *DeleteMeRequest, opts ...grpc.CallOption) (*DeleteMeResponse, error)
+	// SearchUsers finds active users by name or nickname, ignoring case and accents and matching word prefixes
+	SearchUsers(ctx context.Context, in *SearchUsersRequest, opts ...grpc.CallOption) (*SearchUsersResponse, error)
+	// ExportUsers streams all users matching the filter in creation order
+	ExportUsers(ctx context.Context, in *ExportUsersRequest, opts ...grpc.CallOption) (UserAPI_ExportUsersClient, error)
+	// WatchUsers streams created, updated, deactivated and deleted users as they change
+	WatchUsers(ctx context.Context, in *WatchUsersRequest, opts ...grpc.CallOption) (UserAPI_WatchUsersClient, error)
+	// ImportUsers creates users from CSV or JSONL rows and reports the outcome of every row
+	ImportUsers(ctx context.Context, opts ...grpc.CallOption) (UserAPI_ImportUsersClient, error)
+	// BatchGetUsers returns up to 1000 users by id
+	BatchGetUsers(ctx context.Context, in *BatchGetUsersRequest, opts ...grpc.CallOption) (*BatchGetUsersResponse, error)
+	// BatchUpdateUserStatus sets the status of up to 500 users
+	BatchUpdateUserStatus(ctx context.Context, in *BatchUpdateUserStatusRequest, opts ...grpc.CallOption) (*BatchUpdateUserStatusResponse, error)
+	// BatchDeleteUsers deactivates up to 500 users like DeleteUserById
+	BatchDeleteUsers(ctx context.Context, in *BatchDeleteUsersRequest, opts ...grpc.CallOption) (*BatchDeleteUsersResponse, error)
+	// ExportMyData streams everything stored about the authenticated user as a JSON document
+	ExportMyData(ctx context.Context, in *ExportMyDataRequest, opts ...grpc.CallOption) (UserAPI_ExportMyDataClient, error)
+	// ExportUserData streams everything stored about a user as a JSON document, or writes it to the blob store
+	ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (UserAPI_ExportUserDataClient, error)
+	// SendPhoneVerification sends a verification code by SMS to the phone of the authenticated user
+	SendPhoneVerification(ctx context.Context, in *SendPhoneVerificationRequest, opts ...grpc.CallOption) (*SendPhoneVerificationResponse, error)
+	// VerifyPhone verifies the phone of the authenticated user with the code sent by SMS
+	VerifyPhone(ctx context.Context, in *VerifyPhoneRequest, opts ...grpc.CallOption) (*VerifyPhoneResponse, error)
+	// RequestEmailChange sends a confirmation link to the new email of the authenticated user
+	RequestEmailChange(ctx context.Context, in *RequestEmailChangeRequest, opts ...grpc.CallOption) (*RequestEmailChangeResponse, error)
+	// ConfirmEmailChange replaces the email of the authenticated user with the confirmed new address
+	ConfirmEmailChange(ctx context.Context, in *ConfirmEmailChangeRequest, opts ...grpc.CallOption) (*ConfirmEmailChangeResponse, error)
+	// RevertEmailChange restores the previous email with the token sent to it and revokes all sessions, it needs no authentication
+	RevertEmailChange(ctx context.Context, in *RevertEmailChangeRequest, opts ...grpc.CallOption) (*RevertEmailChangeResponse, error)
+	// AcceptInvite sets the password of a user imported without one with its invite token, it needs no authentication
+	AcceptInvite(ctx context.Context, in *AcceptInviteRequest, opts ...grpc.CallOption) (*AcceptInviteResponse, error)
+	// CheckNicknameAvailability reports whether a nickname can be chosen and suggests available ones, it needs no authentication
+	CheckNicknameAvailability(ctx context.Context, in *CheckNicknameAvailabilityRequest, opts ...grpc.CallOption) (*CheckNicknameAvailabilityResponse, error)
 }
 
 type userAPIClient struct {
@@ -70,6 +136,24 @@ func (c *userAPIClient) DeleteUserById(ctx context.Context, in *DeleteUserByIdRe
 	return out, nil
 }
 
+func (c *userAPIClient) RestoreUser(ctx context.Context, in *RestoreUserRequest, opts ...grpc.CallOption) (*RestoreUserResponse, error) {
+	out := new(RestoreUserResponse)
+	err := c.cc.Invoke(ctx, UserAPI_RestoreUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) RequestErasure(ctx context.Context, in *RequestErasureRequest, opts ...grpc.CallOption) (*RequestErasureResponse, error) {
+	out := new(RequestErasureResponse)
+	err := c.cc.Invoke(ctx, UserAPI_RequestErasure_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *userAPIClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error) {
 	out := new(ListUsersResponse)
 	err := c.cc.Invoke(ctx, UserAPI_ListUsers_FullMethodName, in, out, opts...)
@@ -79,6 +163,303 @@ func (c *userAPIClient) ListUsers(ctx context.Context, in *ListUsersRequest, opt
 	return out, nil
 }
 
+func (c *userAPIClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*GetUserResponse, error) {
+	out := new(GetUserResponse)
+	err := c.cc.Invoke(ctx, UserAPI_GetUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) GetMe(ctx context.Context, in *GetMeRequest, opts ...grpc.CallOption) (*GetMeResponse, error) {
+	out := new(GetMeResponse)
+	err := c.cc.Invoke(ctx, UserAPI_GetMe_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) UpdateMe(ctx context.Context, in *UpdateMeRequest, opts ...grpc.CallOption) (*UpdateMeResponse, error) {
+	out := new(UpdateMeResponse)
+	err := c.cc.Invoke(ctx, UserAPI_UpdateMe_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) DeleteMe(ctx context.Context, in *DeleteMeRequest, opts ...grpc.CallOption) (*DeleteMeResponse, error) {
+	out := new(DeleteMeResponse)
+	err := c.cc.Invoke(ctx, UserAPI_DeleteMe_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) SearchUsers(ctx context.Context, in *SearchUsersRequest, opts ...grpc.CallOption) (*SearchUsersResponse, error) {
+	out := new(SearchUsersResponse)
+	err := c.cc.Invoke(ctx, UserAPI_SearchUsers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) ExportUsers(ctx context.Context, in *ExportUsersRequest, opts ...grpc.CallOption) (UserAPI_ExportUsersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserAPI_ServiceDesc.Streams[0], UserAPI_ExportUsers_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userAPIExportUsersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type UserAPI_ExportUsersClient interface {
+	Recv() (*ExportUsersResponse, error)
+	grpc.ClientStream
+}
+
+type userAPIExportUsersClient struct {
+	grpc.ClientStream
+}
+
+func (x *userAPIExportUsersClient) Recv() (*ExportUsersResponse, error) {
+	m := new(ExportUsersResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userAPIClient) WatchUsers(ctx context.Context, in *WatchUsersRequest, opts ...grpc.CallOption) (UserAPI_WatchUsersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserAPI_ServiceDesc.Streams[1], UserAPI_WatchUsers_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userAPIWatchUsersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type UserAPI_WatchUsersClient interface {
+	Recv() (*WatchUsersResponse, error)
+	grpc.ClientStream
+}
+
+type userAPIWatchUsersClient struct {
+	grpc.ClientStream
+}
+
+func (x *userAPIWatchUsersClient) Recv() (*WatchUsersResponse, error) {
+	m := new(WatchUsersResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userAPIClient) ImportUsers(ctx context.Context, opts ...grpc.CallOption) (UserAPI_ImportUsersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserAPI_ServiceDesc.Streams[2], UserAPI_ImportUsers_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userAPIImportUsersClient{stream}
+	return x, nil
+}
+
+type UserAPI_ImportUsersClient interface {
+	Send(*ImportUsersRequest) error
+	CloseAndRecv() (*ImportUsersResponse, error)
+	grpc.ClientStream
+}
+
+type userAPIImportUsersClient struct {
+	grpc.ClientStream
+}
+
+func (x *userAPIImportUsersClient) Send(m *ImportUsersRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *userAPIImportUsersClient) CloseAndRecv() (*ImportUsersResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportUsersResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userAPIClient) BatchGetUsers(ctx context.Context, in *BatchGetUsersRequest, opts ...grpc.CallOption) (*BatchGetUsersResponse, error) {
+	out := new(BatchGetUsersResponse)
+	err := c.cc.Invoke(ctx, UserAPI_BatchGetUsers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) BatchUpdateUserStatus(ctx context.Context, in *BatchUpdateUserStatusRequest, opts ...grpc.CallOption) (*BatchUpdateUserStatusResponse, error) {
+	out := new(BatchUpdateUserStatusResponse)
+	err := c.cc.Invoke(ctx, UserAPI_BatchUpdateUserStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) BatchDeleteUsers(ctx context.Context, in *BatchDeleteUsersRequest, opts ...grpc.CallOption) (*BatchDeleteUsersResponse, error) {
+	out := new(BatchDeleteUsersResponse)
+	err := c.cc.Invoke(ctx, UserAPI_BatchDeleteUsers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) ExportMyData(ctx context.Context, in *ExportMyDataRequest, opts ...grpc.CallOption) (UserAPI_ExportMyDataClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserAPI_ServiceDesc.Streams[3], UserAPI_ExportMyData_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userAPIExportMyDataClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type UserAPI_ExportMyDataClient interface {
+	Recv() (*ExportDataResponse, error)
+	grpc.ClientStream
+}
+
+type userAPIExportMyDataClient struct {
+	grpc.ClientStream
+}
+
+func (x *userAPIExportMyDataClient) Recv() (*ExportDataResponse, error) {
+	m := new(ExportDataResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userAPIClient) ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (UserAPI_ExportUserDataClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserAPI_ServiceDesc.Streams[4], UserAPI_ExportUserData_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userAPIExportUserDataClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type UserAPI_ExportUserDataClient interface {
+	Recv() (*ExportDataResponse, error)
+	grpc.ClientStream
+}
+
+type userAPIExportUserDataClient struct {
+	grpc.ClientStream
+}
+
+func (x *userAPIExportUserDataClient) Recv() (*ExportDataResponse, error) {
+	m := new(ExportDataResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userAPIClient) SendPhoneVerification(ctx context.Context, in *SendPhoneVerificationRequest, opts ...grpc.CallOption) (*SendPhoneVerificationResponse, error) {
+	out := new(SendPhoneVerificationResponse)
+	err := c.cc.Invoke(ctx, UserAPI_SendPhoneVerification_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) VerifyPhone(ctx context.Context, in *VerifyPhoneRequest, opts ...grpc.CallOption) (*VerifyPhoneResponse, error) {
+	out := new(VerifyPhoneResponse)
+	err := c.cc.Invoke(ctx, UserAPI_VerifyPhone_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) RequestEmailChange(ctx context.Context, in *RequestEmailChangeRequest, opts ...grpc.CallOption) (*RequestEmailChangeResponse, error) {
+	out := new(RequestEmailChangeResponse)
+	err := c.cc.Invoke(ctx, UserAPI_RequestEmailChange_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) ConfirmEmailChange(ctx context.Context, in *ConfirmEmailChangeRequest, opts ...grpc.CallOption) (*ConfirmEmailChangeResponse, error) {
+	out := new(ConfirmEmailChangeResponse)
+	err := c.cc.Invoke(ctx, UserAPI_ConfirmEmailChange_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) RevertEmailChange(ctx context.Context, in *RevertEmailChangeRequest, opts ...grpc.CallOption) (*RevertEmailChangeResponse, error) {
+	out := new(RevertEmailChangeResponse)
+	err := c.cc.Invoke(ctx, UserAPI_RevertEmailChange_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) AcceptInvite(ctx context.Context, in *AcceptInviteRequest, opts ...grpc.CallOption) (*AcceptInviteResponse, error) {
+	out := new(AcceptInviteResponse)
+	err := c.cc.Invoke(ctx, UserAPI_AcceptInvite_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAPIClient) CheckNicknameAvailability(ctx context.Context, in *CheckNicknameAvailabilityRequest, opts ...grpc.CallOption) (*CheckNicknameAvailabilityResponse, error) {
+	out := new(CheckNicknameAvailabilityResponse)
+	err := c.cc.Invoke(ctx, UserAPI_CheckNicknameAvailability_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserAPIServer is the server API for UserAPI service.
 // All implementations must embed UnimplementedUserAPIServer
 // for forward compatibility
@@ -86,7 +467,51 @@ type UserAPIServer interface {
 	CreateUser(context.Context, *CreateUserRequest) (*CreateUserResponse, error)
 	UpdateUserById(context.Context, *UpdateUserByIdRequest) (*UpdateUserByIdResponse, error)
 	DeleteUserById(context.Context, *DeleteUserByIdRequest) (*DeleteUserByIdResponse, error)
+	// RestoreUser reactivates a deleted user
+	RestoreUser(context.Context, *RestoreUserRequest) (*RestoreUserResponse, error)
+	// RequestErasure deactivates a user and schedules the removal of its personal data
+	RequestErasure(context.Context, *RequestErasureRequest) (*RequestErasureResponse, error)
 	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
+	// GetUser looks up a single user by exactly one of id, email or nickname
+	GetUser(context.Context, *GetUserRequest) (*GetUserResponse, error)
+	// GetMe returns the profile of the authenticated user
+	GetMe(context.Context, *GetMeRequest) (*GetMeResponse, error)
+	// UpdateMe updates the self-editable fields of the authenticated user
+	UpdateMe(context.Context, *UpdateMeRequest) (*UpdateMeResponse, error)
+	// DeleteMe deactivates the authenticated user and revokes all of its tokens
+	DeleteMe(context.Context, *DeleteMeRequest) (*DeleteMeResponse, error)
+	// SearchUsers finds active users by name or nickname, ignoring case and accents and matching word prefixes
+	SearchUsers(context.Context, *SearchUsersRequest) (*SearchUsersResponse, error)
+	// ExportUsers streams all users matching the filter in creation order
+	ExportUsers(*ExportUsersRequest, UserAPI_ExportUsersServer) error
+	// WatchUsers streams created, updated, deactivated and deleted users as they change
+	WatchUsers(*WatchUsersRequest, UserAPI_WatchUsersServer) error
+	// ImportUsers creates users from CSV or JSONL rows and reports the outcome of every row
+	ImportUsers(UserAPI_ImportUsersServer) error
+	// BatchGetUsers returns up to 1000 users by id
+	BatchGetUsers(context.Context, *BatchGetUsersRequest) (*BatchGetUsersResponse, error)
+	// BatchUpdateUserStatus sets the status of up to 500 users
+	BatchUpdateUserStatus(context.Context, *BatchUpdateUserStatusRequest) (*BatchUpdateUserStatusResponse, error)
+	// BatchDeleteUsers deactivates up to 500 users like DeleteUserById
+	BatchDeleteUsers(context.Context, *BatchDeleteUsersRequest) (*BatchDeleteUsersResponse, error)
+	// ExportMyData streams everything stored about the authenticated user as a JSON document
+	ExportMyData(*ExportMyDataRequest, UserAPI_ExportMyDataServer) error
+	// ExportUserData streams everything stored about a user as a JSON document, or writes it to the blob store
+	ExportUserData(*ExportUserDataRequest, UserAPI_ExportUserDataServer) error
+	// SendPhoneVerification sends a verification code by SMS to the phone of the authenticated user
+	SendPhoneVerification(context.Context, *SendPhoneVerificationRequest) (*SendPhoneVerificationResponse, error)
+	// VerifyPhone verifies the phone of the authenticated user with the code sent by SMS
+	VerifyPhone(context.Context, *VerifyPhoneRequest) (*VerifyPhoneResponse, error)
+	// RequestEmailChange sends a confirmation link to the new email of the authenticated user
+	RequestEmailChange(context.Context, *RequestEmailChangeRequest) (*RequestEmailChangeResponse, error)
+	// ConfirmEmailChange replaces the email of the authenticated user with the confirmed new address
+	ConfirmEmailChange(context.Context, *ConfirmEmailChangeRequest) (*ConfirmEmailChangeResponse, error)
+	// RevertEmailChange restores the previous email with the token sent to it and revokes all sessions, it needs no authentication
+	RevertEmailChange(context.Context, *RevertEmailChangeRequest) (*RevertEmailChangeResponse, error)
+	// AcceptInvite sets the password of a user imported without one with its invite token, it needs no authentication
+	AcceptInvite(context.Context, *AcceptInviteRequest) (*AcceptInviteResponse, error)
+	// CheckNicknameAvailability reports whether a nickname can be chosen and suggests available ones, it needs no authentication
+	CheckNicknameAvailability(context.Context, *CheckNicknameAvailabilityRequest) (*CheckNicknameAvailabilityResponse, error)
 	mustEmbedUnimplementedUserAPIServer()
 }
 
@@ -103,9 +528,75 @@ func (UnimplementedUserAPIServer) UpdateUserById(context.Context, *UpdateUserByI
 func (UnimplementedUserAPIServer) DeleteUserById(context.Context, *DeleteUserByIdRequest) (*DeleteUserByIdResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUserById not implemented")
 }
+func (UnimplementedUserAPIServer) RestoreUser(context.Context, *RestoreUserRequest) (*RestoreUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreUser not implemented")
+}
+func (UnimplementedUserAPIServer) RequestErasure(context.Context, *RequestErasureRequest) (*RequestErasureResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestErasure not implemented")
+}
 func (UnimplementedUserAPIServer) ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
 }
+func (UnimplementedUserAPIServer) GetUser(context.Context, *GetUserRequest) (*GetUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedUserAPIServer) GetMe(context.Context, *GetMeRequest) (*GetMeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMe not implemented")
+}
+func (UnimplementedUserAPIServer) UpdateMe(context.Context, *UpdateMeRequest) (*UpdateMeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateMe not implemented")
+}
+func (UnimplementedUserAPIServer) DeleteMe(context.Context, *DeleteMeRequest) (*DeleteMeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMe not implemented")
+}
+func (UnimplementedUserAPIServer) SearchUsers(context.Context, *SearchUsersRequest) (*SearchUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchUsers not implemented")
+}
+func (UnimplementedUserAPIServer) ExportUsers(*ExportUsersRequest, UserAPI_ExportUsersServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportUsers not implemented")
+}
+func (UnimplementedUserAPIServer) WatchUsers(*WatchUsersRequest, UserAPI_WatchUsersServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchUsers not implemented")
+}
+func (UnimplementedUserAPIServer) ImportUsers(UserAPI_ImportUsersServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportUsers not implemented")
+}
+func (UnimplementedUserAPIServer) BatchGetUsers(context.Context, *BatchGetUsersRequest) (*BatchGetUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetUsers not implemented")
+}
+func (UnimplementedUserAPIServer) BatchUpdateUserStatus(context.Context, *BatchUpdateUserStatusRequest) (*BatchUpdateUserStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdateUserStatus not implemented")
+}
+func (UnimplementedUserAPIServer) BatchDeleteUsers(context.Context, *BatchDeleteUsersRequest) (*BatchDeleteUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDeleteUsers not implemented")
+}
+func (UnimplementedUserAPIServer) ExportMyData(*ExportMyDataRequest, UserAPI_ExportMyDataServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportMyData not implemented")
+}
+func (UnimplementedUserAPIServer) ExportUserData(*ExportUserDataRequest, UserAPI_ExportUserDataServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportUserData not implemented")
+}
+func (UnimplementedUserAPIServer) SendPhoneVerification(context.Context, *SendPhoneVerificationRequest) (*SendPhoneVerificationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendPhoneVerification not implemented")
+}
+func (UnimplementedUserAPIServer) VerifyPhone(context.Context, *VerifyPhoneRequest) (*VerifyPhoneResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyPhone not implemented")
+}
+func (UnimplementedUserAPIServer) RequestEmailChange(context.Context, *RequestEmailChangeRequest) (*RequestEmailChangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestEmailChange not implemented")
+}
+func (UnimplementedUserAPIServer) ConfirmEmailChange(context.Context, *ConfirmEmailChangeRequest) (*ConfirmEmailChangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmEmailChange not implemented")
+}
+func (UnimplementedUserAPIServer) RevertEmailChange(context.Context, *RevertEmailChangeRequest) (*RevertEmailChangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevertEmailChange not implemented")
+}
+func (UnimplementedUserAPIServer) AcceptInvite(context.Context, *AcceptInviteRequest) (*AcceptInviteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptInvite not implemented")
+}
+func (UnimplementedUserAPIServer) CheckNicknameAvailability(context.Context, *CheckNicknameAvailabilityRequest) (*CheckNicknameAvailabilityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckNicknameAvailability not implemented")
+}
 func (UnimplementedUserAPIServer) mustEmbedUnimplementedUserAPIServer() {}
 
 // UnsafeUserAPIServer may be embedded to opt out of forward compatibility for this service.
@@ -173,6 +664,42 @@ func _UserAPI_DeleteUserById_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserAPI_RestoreUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).RestoreUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_RestoreUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).RestoreUser(ctx, req.(*RestoreUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_RequestErasure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestErasureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).RequestErasure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_RequestErasure_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).RequestErasure(ctx, req.(*RequestErasureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UserAPI_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListUsersRequest)
 	if err := dec(in); err != nil {
@@ -191,30 +718,504 @@ func _UserAPI_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
-// UserAPI_ServiceDesc is the grpc.ServiceDesc for UserAPI service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var UserAPI_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "core.user.v1.UserAPI",
-	HandlerType: (*UserAPIServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "CreateUser",
-			Handler:    _UserAPI_CreateUser_Handler,
-		},
-		{
-			MethodName: "UpdateUserById",
-			Handler:    _UserAPI_UpdateUserById_Handler,
-		},
-		{
-			MethodName: "DeleteUserById",
-			Handler:    _UserAPI_DeleteUserById_Handler,
-		},
-		{
-			MethodName: "ListUsers",
+func _UserAPI_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_GetUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_GetMe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).GetMe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_GetMe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).GetMe(ctx, req.(*GetMeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_UpdateMe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateMeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).UpdateMe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_UpdateMe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).UpdateMe(ctx, req.(*UpdateMeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_DeleteMe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).DeleteMe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_DeleteMe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).DeleteMe(ctx, req.(*DeleteMeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_SearchUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).SearchUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_SearchUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).SearchUsers(ctx, req.(*SearchUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_ExportUsers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportUsersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserAPIServer).ExportUsers(m, &userAPIExportUsersServer{stream})
+}
+
+type UserAPI_ExportUsersServer interface {
+	Send(*ExportUsersResponse) error
+	grpc.ServerStream
+}
+
+type userAPIExportUsersServer struct {
+	grpc.ServerStream
+}
+
+func (x *userAPIExportUsersServer) Send(m *ExportUsersResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _UserAPI_WatchUsers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchUsersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserAPIServer).WatchUsers(m, &userAPIWatchUsersServer{stream})
+}
+
+type UserAPI_WatchUsersServer interface {
+	Send(*WatchUsersResponse) error
+	grpc.ServerStream
+}
+
+type userAPIWatchUsersServer struct {
+	grpc.ServerStream
+}
+
+func (x *userAPIWatchUsersServer) Send(m *WatchUsersResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _UserAPI_ImportUsers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(UserAPIServer).ImportUsers(&userAPIImportUsersServer{stream})
+}
+
+type UserAPI_ImportUsersServer interface {
+	SendAndClose(*ImportUsersResponse) error
+	Recv() (*ImportUsersRequest, error)
+	grpc.ServerStream
+}
+
+type userAPIImportUsersServer struct {
+	grpc.ServerStream
+}
+
+func (x *userAPIImportUsersServer) SendAndClose(m *ImportUsersResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *userAPIImportUsersServer) Recv() (*ImportUsersRequest, error) {
+	m := new(ImportUsersRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _UserAPI_BatchGetUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).BatchGetUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_BatchGetUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).BatchGetUsers(ctx, req.(*BatchGetUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_BatchUpdateUserStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUpdateUserStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).BatchUpdateUserStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_BatchUpdateUserStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).BatchUpdateUserStatus(ctx, req.(*BatchUpdateUserStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_BatchDeleteUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).BatchDeleteUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_BatchDeleteUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).BatchDeleteUsers(ctx, req.(*BatchDeleteUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_ExportMyData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportMyDataRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserAPIServer).ExportMyData(m, &userAPIExportMyDataServer{stream})
+}
+
+type UserAPI_ExportMyDataServer interface {
+	Send(*ExportDataResponse) error
+	grpc.ServerStream
+}
+
+type userAPIExportMyDataServer struct {
+	grpc.ServerStream
+}
+
+func (x *userAPIExportMyDataServer) Send(m *ExportDataResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _UserAPI_ExportUserData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportUserDataRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserAPIServer).ExportUserData(m, &userAPIExportUserDataServer{stream})
+}
+
+type UserAPI_ExportUserDataServer interface {
+	Send(*ExportDataResponse) error
+	grpc.ServerStream
+}
+
+type userAPIExportUserDataServer struct {
+	grpc.ServerStream
+}
+
+func (x *userAPIExportUserDataServer) Send(m *ExportDataResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _UserAPI_SendPhoneVerification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendPhoneVerificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).SendPhoneVerification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_SendPhoneVerification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).SendPhoneVerification(ctx, req.(*SendPhoneVerificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_VerifyPhone_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyPhoneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).VerifyPhone(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_VerifyPhone_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).VerifyPhone(ctx, req.(*VerifyPhoneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_RequestEmailChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestEmailChangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).RequestEmailChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_RequestEmailChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).RequestEmailChange(ctx, req.(*RequestEmailChangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_ConfirmEmailChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmEmailChangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).ConfirmEmailChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_ConfirmEmailChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).ConfirmEmailChange(ctx, req.(*ConfirmEmailChangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_RevertEmailChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevertEmailChangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).RevertEmailChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_RevertEmailChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).RevertEmailChange(ctx, req.(*RevertEmailChangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_AcceptInvite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptInviteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).AcceptInvite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_AcceptInvite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).AcceptInvite(ctx, req.(*AcceptInviteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAPI_CheckNicknameAvailability_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckNicknameAvailabilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAPIServer).CheckNicknameAvailability(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAPI_CheckNicknameAvailability_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAPIServer).CheckNicknameAvailability(ctx, req.(*CheckNicknameAvailabilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserAPI_ServiceDesc is the grpc.ServiceDesc for UserAPI service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserAPI_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "core.user.v1.UserAPI",
+	HandlerType: (*UserAPIServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateUser",
+			Handler:    _UserAPI_CreateUser_Handler,
+		},
+		{
+			MethodName: "UpdateUserById",
+			Handler:    _UserAPI_UpdateUserById_Handler,
+		},
+		{
+			MethodName: "DeleteUserById",
+			Handler:    _UserAPI_DeleteUserById_Handler,
+		},
+		{
+			MethodName: "RestoreUser",
+			Handler:    _UserAPI_RestoreUser_Handler,
+		},
+		{
+			MethodName: "RequestErasure",
+			Handler:    _UserAPI_RequestErasure_Handler,
+		},
+		{
+			MethodName: "ListUsers",
 			Handler:    _UserAPI_ListUsers_Handler,
 		},
+		{
+			MethodName: "GetUser",
+			Handler:    _UserAPI_GetUser_Handler,
+		},
+		{
+			MethodName: "GetMe",
+			Handler:    _UserAPI_GetMe_Handler,
+		},
+		{
+			MethodName: "UpdateMe",
+			Handler:    _UserAPI_UpdateMe_Handler,
+		},
+		{
+			MethodName: "DeleteMe",
+			Handler:    _UserAPI_DeleteMe_Handler,
+		},
+		{
+			MethodName: "SearchUsers",
+			Handler:    _UserAPI_SearchUsers_Handler,
+		},
+		{
+			MethodName: "BatchGetUsers",
+			Handler:    _UserAPI_BatchGetUsers_Handler,
+		},
+		{
+			MethodName: "BatchUpdateUserStatus",
+			Handler:    _UserAPI_BatchUpdateUserStatus_Handler,
+		},
+		{
+			MethodName: "BatchDeleteUsers",
+			Handler:    _UserAPI_BatchDeleteUsers_Handler,
+		},
+		{
+			MethodName: "SendPhoneVerification",
+			Handler:    _UserAPI_SendPhoneVerification_Handler,
+		},
+		{
+			MethodName: "VerifyPhone",
+			Handler:    _UserAPI_VerifyPhone_Handler,
+		},
+		{
+			MethodName: "RequestEmailChange",
+			Handler:    _UserAPI_RequestEmailChange_Handler,
+		},
+		{
+			MethodName: "ConfirmEmailChange",
+			Handler:    _UserAPI_ConfirmEmailChange_Handler,
+		},
+		{
+			MethodName: "RevertEmailChange",
+			Handler:    _UserAPI_RevertEmailChange_Handler,
+		},
+		{
+			MethodName: "AcceptInvite",
+			Handler:    _UserAPI_AcceptInvite_Handler,
+		},
+		{
+			MethodName: "CheckNicknameAvailability",
+			Handler:    _UserAPI_CheckNicknameAvailability_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportUsers",
+			Handler:       _UserAPI_ExportUsers_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchUsers",
+			Handler:       _UserAPI_WatchUsers_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportUsers",
+			Handler:       _UserAPI_ImportUsers_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportMyData",
+			Handler:       _UserAPI_ExportMyData_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExportUserData",
+			Handler:       _UserAPI_ExportUserData_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "core/user/v1/user_api.proto",
 }